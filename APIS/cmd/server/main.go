@@ -1,9 +1,15 @@
 package main
 
 import (
+	"context"
 	"log"
 	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/httplog/v2"
@@ -11,15 +17,26 @@ import (
 	"github.com/jb-oliveira/fullcycle/APIS/configs"
 	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
 	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/database"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/jobs"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/mail"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/metrics"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/ratelimit"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/storage"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/throttle"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/tracing"
 	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/webserver/handlers"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/webserver/middlewares"
 
 	_ "github.com/jb-oliveira/fullcycle/APIS/docs"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
+// serviceName identifies the API in the request logs and the traces.
+const serviceName = "fullcycle-api"
+
 // @title           FullCycle API
-// @version         1.0
-// @description     This is a sample API for FullCycle course
+// @version         2.0
+// @description     This is a sample API for FullCycle course. Breaking change in 2.0: prices are written as {"amount": "12.34", "currency": "BRL"} instead of a bare number, they are still accepted as a number
 // @termsOfService  http://swagger.io/terms/
 
 // @contact.name   API Support
@@ -36,6 +53,11 @@ import (
 // @in header
 // @name Authorization
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
 	// Initialize the database
 	initDB()
 	// initialize the web
@@ -45,41 +67,238 @@ func main() {
 	}
 	log.Println("Configuration loaded successfully")
 
-	productDB := database.NewProductDB(configs.GetDB())
-	productHandler := handlers.NewProductHandler(productDB)
+	shutdownTracing, err := tracing.Setup(configs.GetWebConfig().TracingExporter, serviceName)
+	if err != nil {
+		log.Fatalf("failed to set up tracing: %v", err)
+	}
+
+	// Cancelled by SIGINT or SIGTERM, stops the server and the jobs
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	queryTimeout := time.Millisecond * time.Duration(configs.GetDbConfig().DBQueryTimeout)
+	productDB := database.NewProductDB(configs.GetDB(), queryTimeout)
+	tokenDB := database.NewTokenDB(configs.GetDB(), queryTimeout)
+	userDB := database.NewUserDB(configs.GetDB(), queryTimeout)
+	webConfig := configs.GetWebConfig()
+	blobStore, err := storage.New(ctx, storage.Config{
+		Driver:     webConfig.StorageDriver,
+		BaseURL:    webConfig.StorageBaseURL,
+		LocalDir:   webConfig.StorageLocalDir,
+		S3Bucket:   webConfig.StorageS3Bucket,
+		S3Region:   webConfig.StorageS3Region,
+		S3Endpoint: webConfig.StorageS3Endpoint,
+	})
+	if err != nil {
+		log.Fatalf("failed to set up the image storage: %v", err)
+	}
+	productHandler := handlers.NewProductHandler(productDB, blobStore, webConfig.ImageMaxSize, webConfig.ImportMaxSize,
+		time.Second*time.Duration(webConfig.ImportTimeout))
+	categoryHandler := handlers.NewCategoryHandler(database.NewCategoryDB(configs.GetDB(), queryTimeout))
+	if retention := configs.GetWebConfig().TrashRetention; retention > 0 && configs.GetWebConfig().TrashPurgeInterval > 0 {
+		go jobs.PurgeTrash(ctx, productDB, blobStore, 24*time.Hour*time.Duration(retention),
+			time.Second*time.Duration(configs.GetWebConfig().TrashPurgeInterval))
+	}
+	idempotencyDB := database.NewIdempotencyDB(configs.GetDB(), queryTimeout)
+	if interval := configs.GetWebConfig().IdempotencyPurgeInterval; interval > 0 {
+		go jobs.PurgeExpired(ctx, idempotencyDB, time.Second*time.Duration(interval))
+	}
+	if interval := configs.GetWebConfig().TokenPurgeInterval; interval > 0 {
+		go jobs.PurgeExpired(ctx, tokenDB, time.Second*time.Duration(interval))
+	}
+
+	// Clients without a valid token are limited by IP, so the limit can
+	// go before the Authenticator
+	rateLimitStore := ratelimit.NewMemoryStore()
+	rateLimit := func(group string, requests int, key middlewares.KeyFunc) func(http.Handler) http.Handler {
+		limit := ratelimit.Limit{Requests: requests, Period: time.Second * time.Duration(configs.GetWebConfig().RateLimitPeriod)}
+		return middlewares.RateLimit(rateLimitStore, group, limit, key)
+	}
 
 	r := chi.NewRouter()
-	logger := httplog.NewLogger("fullcycle-api", httplog.Options{
+	logger := httplog.NewLogger(serviceName, httplog.Options{
 		JSON:     true, // Structured JSON for prod
 		LogLevel: slog.LevelInfo,
 		Concise:  true, // Clean logs with fewer details
 	})
+	r.Use(middlewares.Metrics)
 	r.Use(httplog.RequestLogger(logger))
+	r.Use(middlewares.Tracing)
 	// r.Use(middleware.Logger)
 	r.Use(MiddlewareVazio)
+
+	sqlDB, err := configs.GetDB().DB()
+	if err != nil {
+		log.Fatalf("failed to get the database pool: %v", err)
+	}
+	healthHandler := handlers.NewHealthHandler(sqlDB)
+	r.Get("/healthz", healthHandler.Healthz)
+	r.Get("/readyz", healthHandler.Readyz)
+	r.Handle("/metrics", metrics.Handler())
+	// The images are public like the bucket of the S3 store
+	if localStore, ok := blobStore.(*storage.LocalStore); ok {
+		r.Handle(storage.LocalPath+"*", http.StripPrefix(strings.TrimSuffix(storage.LocalPath, "/"), localStore))
+	}
+
 	r.Route("/products", func(r chi.Router) {
-		r.Use(jwtauth.Verifier(configs.GetWebConfig().TokenAuth))
+		r.Use(middlewares.Verifier(configs.GetWebConfig().TokenAuth, tokenDB, userDB))
+		r.Use(rateLimit("products", configs.GetWebConfig().RateLimitProducts, middlewares.BySubject))
+		r.Use(jwtauth.Authenticator)
+		r.Use(middlewares.Actor)
+
+		// Role policy: everyone reads, editors write and only admins delete
+		readers := middlewares.RequireRole(entity.RoleViewer, entity.RoleEditor, entity.RoleAdmin)
+		writers := middlewares.RequireRole(entity.RoleEditor, entity.RoleAdmin)
+		admins := middlewares.RequireRole(entity.RoleAdmin)
+		// A retried create must not add the product twice
+		idempotent := middlewares.Idempotency(idempotencyDB,
+			time.Second*time.Duration(configs.GetWebConfig().IdempotencyExpiration))
+
+		r.With(writers, idempotent).Post("/", productHandler.CreateProduct)
+		r.With(writers).Post("/import", productHandler.ImportProducts)
+		r.With(readers).Get("/export", productHandler.ExportProducts)
+		r.With(admins).Get("/trash", productHandler.GetTrash)
+		r.With(readers).Get("/{id}", productHandler.GetProduct)
+		r.With(writers).Put("/{id}", productHandler.UpdateProduct)
+		r.With(writers).Patch("/{id}", productHandler.PatchProduct)
+		r.With(admins).Delete("/{id}", productHandler.DeleteProduct)
+		r.With(admins).Post("/{id}/restore", productHandler.RestoreProduct)
+		r.With(admins).Get("/{id}/history", productHandler.GetProductHistory)
+		r.With(writers).Put("/{id}/categories", productHandler.SetProductCategories)
+		r.With(writers).Post("/{id}/images", productHandler.UploadProductImage)
+		r.With(readers).Get("/", productHandler.GetProducts)
+	})
+
+	r.Route("/categories", func(r chi.Router) {
+		r.Use(middlewares.Verifier(configs.GetWebConfig().TokenAuth, tokenDB, userDB))
+		// Categories are part of the catalog, they get the products limit
+		r.Use(rateLimit("categories", configs.GetWebConfig().RateLimitProducts, middlewares.BySubject))
 		r.Use(jwtauth.Authenticator)
+		// Deleting a category audits the products it untags
+		r.Use(middlewares.Actor)
 
-		r.Post("/", productHandler.CreateProduct)
-		r.Get("/{id}", productHandler.GetProduct)
-		r.Put("/{id}", productHandler.UpdateProduct)
-		r.Delete("/{id}", productHandler.DeleteProduct)
-		r.Get("/", productHandler.GetProducts)
+		// Same role policy as the products
+		readers := middlewares.RequireRole(entity.RoleViewer, entity.RoleEditor, entity.RoleAdmin)
+		writers := middlewares.RequireRole(entity.RoleEditor, entity.RoleAdmin)
+		admins := middlewares.RequireRole(entity.RoleAdmin)
+
+		r.With(writers).Post("/", categoryHandler.CreateCategory)
+		r.With(readers).Get("/", categoryHandler.GetCategories)
+		r.With(readers).Get("/{id}", categoryHandler.GetCategory)
+		r.With(writers).Put("/{id}", categoryHandler.UpdateCategory)
+		r.With(admins).Delete("/{id}", categoryHandler.DeleteCategory)
 	})
 
-	userDB := database.NewUserDB(configs.GetDB())
-	userHandler := handlers.NewUserHandler(userDB, configs.GetWebConfig().TokenAuth, configs.GetWebConfig().JWTExpiration)
+	loginThrottle := throttle.NewLoginThrottle(
+		throttle.Policy{
+			MaxAttempts: configs.GetWebConfig().LoginMaxAttempts,
+			BaseLockout: time.Second * time.Duration(configs.GetWebConfig().LoginLockout),
+			MaxLockout:  time.Second * time.Duration(configs.GetWebConfig().LoginMaxLockout),
+		},
+		throttle.Policy{
+			MaxAttempts: configs.GetWebConfig().LoginMaxAttemptsPerIP,
+			BaseLockout: time.Second * time.Duration(configs.GetWebConfig().LoginLockout),
+			MaxLockout:  time.Second * time.Duration(configs.GetWebConfig().LoginMaxLockout),
+		},
+	)
+	userHandler := handlers.NewUserHandler(userDB, tokenDB, loginThrottle, configs.GetWebConfig().TokenAuth,
+		configs.GetWebConfig().JWTExpiration, configs.GetWebConfig().JWTRefreshExpiration)
+
+	passwordHandler := handlers.NewPasswordHandler(userDB, tokenDB, mail.NewLogMailer(),
+		configs.GetWebConfig().PasswordResetExpiration, configs.GetWebConfig().PasswordResetURL)
 
-	r.Post("/users", userHandler.CreateUser)
-	r.Post("/users/auth", userHandler.Auth)
+	r.Group(func(r chi.Router) {
+		r.Use(rateLimit("public", configs.GetWebConfig().RateLimitPublic, middlewares.ByIP))
 
-	r.Get("/docs/*", httpSwagger.Handler(httpSwagger.URL("http://localhost:8000/docs/doc.json")))
+		r.Post("/users", userHandler.CreateUser)
+		r.Post("/users/auth", userHandler.Auth)
+		r.Post("/users/auth/refresh", userHandler.Refresh)
+		r.Post("/users/password/forgot", passwordHandler.ForgotPassword)
+		r.Post("/users/password/reset", passwordHandler.ResetPassword)
+	})
 
-	http.ListenAndServe(":8000", r)
+	r.Group(func(r chi.Router) {
+		r.Use(middlewares.Verifier(configs.GetWebConfig().TokenAuth, tokenDB, userDB))
+		r.Use(rateLimit("users", configs.GetWebConfig().RateLimitUsers, middlewares.BySubject))
+		r.Use(jwtauth.Authenticator)
+
+		r.Post("/users/logout", userHandler.Logout)
+		r.Get("/users/me", userHandler.GetMe)
+		r.Put("/users/me", userHandler.UpdateMe)
+		r.Put("/users/me/password", userHandler.ChangePassword)
+		r.Delete("/users/me", userHandler.DeleteMe)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.RequireRole(entity.RoleAdmin))
+
+			r.Get("/users", userHandler.GetUsers)
+			r.Delete("/users/{id}", userHandler.DeleteUser)
+			r.Put("/users/{id}/role", userHandler.UpdateUserRole)
+		})
+	})
+
+	r.Get("/docs/*", httpSwagger.Handler(httpSwagger.URL("/docs/doc.json")))
+
+	server := &http.Server{
+		Addr:         ":" + webConfig.WebServerPort,
+		Handler:      r,
+		ReadTimeout:  time.Second * time.Duration(webConfig.WebReadTimeout),
+		WriteTimeout: time.Second * time.Duration(webConfig.WebWriteTimeout),
+		IdleTimeout:  time.Second * time.Duration(webConfig.WebIdleTimeout),
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("Listening on %s", server.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		log.Fatalf("web server failed: %v", err)
+	case <-ctx.Done():
+	}
+	stop()
+	log.Println("Shutting down, waiting for the in-flight requests")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(webConfig.WebShutdownTimeout))
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("graceful shutdown failed: %v", err)
+	}
+	// The reset emails sent in the background need the database too
+	passwordHandler.Wait()
+	// Only closed once no request can use it anymore
+	if err := sqlDB.Close(); err != nil {
+		log.Printf("failed to close the database pool: %v", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Printf("failed to flush the traces: %v", err)
+	}
+	log.Println("Server stopped")
 }
 
 func initDB() {
+	connectDB()
+
+	migrator, err := database.NewMigrator(context.Background(), configs.GetDB())
+	if err != nil {
+		log.Fatalf("failed to read the database migrations: %v", err)
+	}
+	defer migrator.Close()
+	if err := migrator.CheckSchema(); err != nil {
+		log.Fatalf("refusing to start: %v, run `server migrate up` first", err)
+	}
+	if err := database.RegisterMetrics(configs.GetDB()); err != nil {
+		log.Fatalf("failed to register the database metrics: %v", err)
+	}
+	if err := database.RegisterTracing(configs.GetDB()); err != nil {
+		log.Fatalf("failed to register the database tracing: %v", err)
+	}
+
+	log.Println("Database connection established")
+}
+
+func connectDB() {
 	_, err := configs.LoadDbConfig(".")
 	if err != nil {
 		log.Fatalf("failed to load database configuration: %v", err)
@@ -93,11 +312,6 @@ func initDB() {
 	if db == nil {
 		log.Fatal("database instance is null")
 	}
-
-	// Remove auto migrate and later see which is the best migration for GO
-	db.AutoMigrate(&entity.Product{}, &entity.User{})
-
-	log.Println("Database connection established")
 }
 
 func MiddlewareVazio(next http.Handler) http.Handler {
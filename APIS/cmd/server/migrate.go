@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/jb-oliveira/fullcycle/APIS/configs"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/database"
+)
+
+const migrateUsage = `usage: server migrate <command>
+
+commands:
+  up        apply every pending migration
+  down [N]  revert the last N migrations, 1 by default
+  version   print the schema version of the database`
+
+// runMigrate is the migrate subcommand, the server itself only checks the
+// schema version and never changes it.
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	connectDB()
+	migrator, err := database.NewMigrator(context.Background(), configs.GetDB())
+	if err != nil {
+		log.Fatalf("failed to read the database migrations: %v", err)
+	}
+	defer migrator.Close()
+
+	switch args[0] {
+	case "up":
+		err = migrator.Up()
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil {
+				log.Fatalf("invalid number of steps %q", args[1])
+			}
+		}
+		err = migrator.Down(steps)
+	case "version":
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("migration failed: %v", err)
+	}
+
+	version, dirty, err := migrator.Version()
+	if err != nil {
+		log.Fatalf("failed to read the schema version: %v", err)
+	}
+	status := ""
+	if dirty {
+		status = " (dirty)"
+	}
+	log.Printf("schema version %d%s, latest is %d", version, status, migrator.Latest())
+}
@@ -3,10 +3,13 @@ package configs
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/go-chi/jwtauth"
 	"github.com/spf13/viper"
+	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
@@ -17,19 +20,56 @@ var (
 )
 
 type confDB struct {
-	DBDriver   string `mapstructure:"DB_DRIVER"`
-	DBHost     string `mapstructure:"DB_HOST"`
-	DBPort     string `mapstructure:"DB_PORT"`
-	DBUser     string `mapstructure:"DB_USER"`
-	DBPassword string `mapstructure:"DB_PASSWORD"`
-	DBName     string `mapstructure:"DB_NAME"`
+	DBDriver       string `mapstructure:"DB_DRIVER"`
+	DBHost         string `mapstructure:"DB_HOST"`
+	DBPort         string `mapstructure:"DB_PORT"`
+	DBUser         string `mapstructure:"DB_USER"`
+	DBPassword     string `mapstructure:"DB_PASSWORD"`
+	DBName         string `mapstructure:"DB_NAME"`
+	DBQueryTimeout int    `mapstructure:"DB_QUERY_TIMEOUT"` // milliseconds, bounds every repository query
+	// Connection pool, ignored by sqlite that always uses a single connection
+	DBMaxOpenConns    int `mapstructure:"DB_MAX_OPEN_CONNS"`
+	DBMaxIdleConns    int `mapstructure:"DB_MAX_IDLE_CONNS"`
+	DBConnMaxLifetime int `mapstructure:"DB_CONN_MAX_LIFETIME"` // seconds, 0 keeps connections forever
 }
 
 type confWeb struct {
-	WebServerPort string `mapstructure:"WEB_PORT"`
-	JWTSecret     string `mapstructure:"JWT_SECRET"`
-	JWTExpiration int    `mapstructure:"JWT_EXPIRATION"`
-	TokenAuth     *jwtauth.JWTAuth
+	WebServerPort            string `mapstructure:"WEB_PORT"`
+	WebReadTimeout           int    `mapstructure:"WEB_READ_TIMEOUT"`     // seconds to read a request, headers and body
+	WebWriteTimeout          int    `mapstructure:"WEB_WRITE_TIMEOUT"`    // seconds to write a response
+	WebIdleTimeout           int    `mapstructure:"WEB_IDLE_TIMEOUT"`     // seconds a keep-alive connection waits for the next request
+	WebShutdownTimeout       int    `mapstructure:"WEB_SHUTDOWN_TIMEOUT"` // seconds the in-flight requests get to finish on shutdown
+	JWTSecret                string `mapstructure:"JWT_SECRET"`
+	JWTExpiration            int    `mapstructure:"JWT_EXPIRATION"`
+	JWTRefreshExpiration     int    `mapstructure:"JWT_REFRESH_EXPIRATION"`
+	PasswordResetExpiration  int    `mapstructure:"PASSWORD_RESET_EXPIRATION"`
+	PasswordResetURL         string `mapstructure:"PASSWORD_RESET_URL"`
+	TokenPurgeInterval       int    `mapstructure:"TOKEN_PURGE_INTERVAL"` // seconds between two purges of the expired tokens, 0 disables them
+	LoginMaxAttempts         int    `mapstructure:"LOGIN_MAX_ATTEMPTS"`
+	LoginMaxAttemptsPerIP    int    `mapstructure:"LOGIN_MAX_ATTEMPTS_PER_IP"`
+	LoginLockout             int    `mapstructure:"LOGIN_LOCKOUT"`
+	LoginMaxLockout          int    `mapstructure:"LOGIN_MAX_LOCKOUT"`
+	TrashRetention           int    `mapstructure:"TRASH_RETENTION"`            // days a deleted product is kept, 0 keeps them forever
+	TrashPurgeInterval       int    `mapstructure:"TRASH_PURGE_INTERVAL"`       // seconds between two purges
+	TracingExporter          string `mapstructure:"TRACING_EXPORTER"`           // none or stdout
+	IdempotencyExpiration    int    `mapstructure:"IDEMPOTENCY_EXPIRATION"`     // seconds a response to an Idempotency-Key is replayed
+	IdempotencyPurgeInterval int    `mapstructure:"IDEMPOTENCY_PURGE_INTERVAL"` // seconds between two purges of the expired keys, 0 disables them
+	ImportMaxSize            int64  `mapstructure:"IMPORT_MAX_SIZE"`            // bytes of a file sent to /products/import
+	ImportTimeout            int    `mapstructure:"IMPORT_TIMEOUT"`             // seconds to read a file sent to /products/import and answer it
+	// Requests allowed per RATE_LIMIT_PERIOD for every route group, 0 disables the limit
+	RateLimitPublic   int `mapstructure:"RATE_LIMIT_PUBLIC"`   // per client IP on the routes without token
+	RateLimitUsers    int `mapstructure:"RATE_LIMIT_USERS"`    // per user on the /users routes with token
+	RateLimitProducts int `mapstructure:"RATE_LIMIT_PRODUCTS"` // per user on the /products routes
+	RateLimitPeriod   int `mapstructure:"RATE_LIMIT_PERIOD"`   // seconds
+	// Product images, kept by the storage.BlobStore of STORAGE_DRIVER
+	ImageMaxSize      int64  `mapstructure:"IMAGE_MAX_SIZE"`   // bytes
+	StorageDriver     string `mapstructure:"STORAGE_DRIVER"`   // local or s3
+	StorageBaseURL    string `mapstructure:"STORAGE_BASE_URL"` // prefix of the image URLs, empty for the driver default
+	StorageLocalDir   string `mapstructure:"STORAGE_LOCAL_DIR"`
+	StorageS3Bucket   string `mapstructure:"STORAGE_S3_BUCKET"`
+	StorageS3Region   string `mapstructure:"STORAGE_S3_REGION"`
+	StorageS3Endpoint string `mapstructure:"STORAGE_S3_ENDPOINT"` // only for S3 compatible services
+	TokenAuth         *jwtauth.JWTAuth
 }
 
 func LoadDbConfig(path string) (*confDB, error) {
@@ -38,6 +78,10 @@ func LoadDbConfig(path string) (*confDB, error) {
 	v.SetConfigType("env")
 	v.AddConfigPath(path)
 	v.AutomaticEnv()
+	v.SetDefault("DB_QUERY_TIMEOUT", 3000) // 3 seconds in milliseconds
+	v.SetDefault("DB_MAX_OPEN_CONNS", 25)
+	v.SetDefault("DB_MAX_IDLE_CONNS", 5)
+	v.SetDefault("DB_CONN_MAX_LIFETIME", 300) // 5 minutes in seconds
 
 	err := v.ReadInConfig()
 	if err != nil {
@@ -60,6 +104,37 @@ func LoadWebConfig(path string) (*confWeb, error) {
 	v.SetConfigType("env")
 	v.AddConfigPath(path)
 	v.AutomaticEnv()
+	v.SetDefault("WEB_PORT", "8000")
+	v.SetDefault("WEB_READ_TIMEOUT", 15)
+	v.SetDefault("WEB_WRITE_TIMEOUT", 30)
+	v.SetDefault("WEB_IDLE_TIMEOUT", 60)
+	v.SetDefault("WEB_SHUTDOWN_TIMEOUT", 20)
+	v.SetDefault("JWT_REFRESH_EXPIRATION", 604800)  // 7 days in seconds
+	v.SetDefault("PASSWORD_RESET_EXPIRATION", 1800) // 30 minutes in seconds
+	v.SetDefault("PASSWORD_RESET_URL", "")
+	v.SetDefault("TOKEN_PURGE_INTERVAL", 3600) // 1 hour in seconds
+	v.SetDefault("LOGIN_MAX_ATTEMPTS", 5)
+	v.SetDefault("LOGIN_MAX_ATTEMPTS_PER_IP", 20)
+	v.SetDefault("LOGIN_LOCKOUT", 30)      // seconds, doubled on every new failure
+	v.SetDefault("LOGIN_MAX_LOCKOUT", 900) // 15 minutes in seconds
+	v.SetDefault("TRASH_RETENTION", 30)
+	v.SetDefault("TRASH_PURGE_INTERVAL", 3600) // 1 hour in seconds
+	v.SetDefault("TRACING_EXPORTER", "none")
+	v.SetDefault("IDEMPOTENCY_EXPIRATION", 86400)    // 24 hours in seconds
+	v.SetDefault("IDEMPOTENCY_PURGE_INTERVAL", 3600) // 1 hour in seconds
+	v.SetDefault("IMPORT_MAX_SIZE", 50<<20)          // 50 MB
+	v.SetDefault("IMPORT_TIMEOUT", 300)              // 5 minutes in seconds
+	v.SetDefault("RATE_LIMIT_PUBLIC", 30)
+	v.SetDefault("RATE_LIMIT_USERS", 120)
+	v.SetDefault("RATE_LIMIT_PRODUCTS", 600)
+	v.SetDefault("RATE_LIMIT_PERIOD", 60) // 1 minute in seconds
+	v.SetDefault("IMAGE_MAX_SIZE", 5<<20) // 5 MB
+	v.SetDefault("STORAGE_DRIVER", "local")
+	v.SetDefault("STORAGE_BASE_URL", "")
+	v.SetDefault("STORAGE_LOCAL_DIR", "uploads")
+	v.SetDefault("STORAGE_S3_BUCKET", "")
+	v.SetDefault("STORAGE_S3_REGION", "sa-east-1")
+	v.SetDefault("STORAGE_S3_ENDPOINT", "")
 
 	err := v.ReadInConfig()
 	if err != nil {
@@ -90,17 +165,50 @@ func InitGorm() error {
 		return fmt.Errorf("database configuration not loaded: call LoadDbConfig first")
 	}
 
-	dsn := buildDSN(dbConfig)
-	dialector := postgres.Open(dsn)
+	dialector, err := openDialector(dbConfig)
+	if err != nil {
+		return err
+	}
 
-	var err error
-	db, err = gorm.Open(dialector, &gorm.Config{})
+	db, err = gorm.Open(dialector, &gorm.Config{
+		// Lets the repositories tell duplicated keys apart from other errors
+		TranslateError: true,
+	})
 	if err != nil {
 		return fmt.Errorf("error opening database connection: %w", err)
 	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("error configuring the connection pool: %w", err)
+	}
+	if dialector.Name() == "sqlite" {
+		// SQLite has a single writer and every connection to :memory: is
+		// a new database, one connection avoids both problems
+		sqlDB.SetMaxOpenConns(1)
+	} else {
+		sqlDB.SetMaxOpenConns(dbConfig.DBMaxOpenConns)
+		sqlDB.SetMaxIdleConns(dbConfig.DBMaxIdleConns)
+		sqlDB.SetConnMaxLifetime(time.Second * time.Duration(dbConfig.DBConnMaxLifetime))
+	}
 	return nil
 }
 
+// openDialector returns the GORM dialector of the DB_DRIVER.
+func openDialector(config *confDB) (gorm.Dialector, error) {
+	dsn := buildDSN(config)
+	switch config.DBDriver {
+	case "postgres", "postgresql":
+		return postgres.Open(dsn), nil
+	case "mysql":
+		return mysql.Open(dsn), nil
+	case "sqlite", "sqlite3":
+		return sqlite.Open(dsn), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", config.DBDriver)
+	}
+}
+
 func GetDB() *gorm.DB {
 	if db == nil {
 		log.Fatal("GORM DB not initialized")
@@ -114,7 +222,8 @@ func buildDSN(config *confDB) string {
 		return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 			config.DBHost, config.DBPort, config.DBUser, config.DBPassword, config.DBName)
 	case "mysql":
-		return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+		// multiStatements lets the migrations run a whole file at once
+		return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local&multiStatements=true",
 			config.DBUser, config.DBPassword, config.DBHost, config.DBPort, config.DBName)
 	case "sqlite", "sqlite3":
 		return config.DBName
@@ -31,9 +31,43 @@ func cleanupViper() {
 	os.Unsetenv("DB_USER")
 	os.Unsetenv("DB_PASSWORD")
 	os.Unsetenv("DB_NAME")
+	os.Unsetenv("DB_QUERY_TIMEOUT")
+	os.Unsetenv("DB_MAX_OPEN_CONNS")
+	os.Unsetenv("DB_MAX_IDLE_CONNS")
+	os.Unsetenv("DB_CONN_MAX_LIFETIME")
 	os.Unsetenv("WEB_PORT")
+	os.Unsetenv("WEB_READ_TIMEOUT")
+	os.Unsetenv("WEB_WRITE_TIMEOUT")
+	os.Unsetenv("WEB_IDLE_TIMEOUT")
+	os.Unsetenv("WEB_SHUTDOWN_TIMEOUT")
 	os.Unsetenv("JWT_SECRET")
 	os.Unsetenv("JWT_EXPIRATION")
+	os.Unsetenv("JWT_REFRESH_EXPIRATION")
+	os.Unsetenv("PASSWORD_RESET_EXPIRATION")
+	os.Unsetenv("PASSWORD_RESET_URL")
+	os.Unsetenv("TOKEN_PURGE_INTERVAL")
+	os.Unsetenv("LOGIN_MAX_ATTEMPTS")
+	os.Unsetenv("LOGIN_MAX_ATTEMPTS_PER_IP")
+	os.Unsetenv("LOGIN_LOCKOUT")
+	os.Unsetenv("LOGIN_MAX_LOCKOUT")
+	os.Unsetenv("TRASH_RETENTION")
+	os.Unsetenv("TRASH_PURGE_INTERVAL")
+	os.Unsetenv("TRACING_EXPORTER")
+	os.Unsetenv("IDEMPOTENCY_EXPIRATION")
+	os.Unsetenv("IDEMPOTENCY_PURGE_INTERVAL")
+	os.Unsetenv("IMPORT_MAX_SIZE")
+	os.Unsetenv("IMPORT_TIMEOUT")
+	os.Unsetenv("RATE_LIMIT_PUBLIC")
+	os.Unsetenv("RATE_LIMIT_USERS")
+	os.Unsetenv("RATE_LIMIT_PRODUCTS")
+	os.Unsetenv("RATE_LIMIT_PERIOD")
+	os.Unsetenv("IMAGE_MAX_SIZE")
+	os.Unsetenv("STORAGE_DRIVER")
+	os.Unsetenv("STORAGE_BASE_URL")
+	os.Unsetenv("STORAGE_LOCAL_DIR")
+	os.Unsetenv("STORAGE_S3_BUCKET")
+	os.Unsetenv("STORAGE_S3_REGION")
+	os.Unsetenv("STORAGE_S3_ENDPOINT")
 }
 
 // TestLoadDbConfig tests loading database configuration with valid inputs.
@@ -133,6 +167,61 @@ DB_NAME=test db`,
 	}
 }
 
+// TestLoadDbConfig_QueryTimeout tests the default and the override of the
+// per query timeout
+func TestLoadDbConfig_QueryTimeout(t *testing.T) {
+	cleanupViper()
+	defer cleanupViper()
+
+	tmpDir := t.TempDir()
+	createTestEnvFile(t, tmpDir, `DB_DRIVER=postgres
+DB_HOST=localhost`)
+
+	config, err := LoadDbConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadDbConfig() error = %v, want nil", err)
+	}
+	if config.DBQueryTimeout != 3000 {
+		t.Errorf("DBQueryTimeout = %v, want %v", config.DBQueryTimeout, 3000)
+	}
+
+	cleanupViper()
+	createTestEnvFile(t, tmpDir, `DB_DRIVER=postgres
+DB_QUERY_TIMEOUT=250`)
+
+	config, err = LoadDbConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadDbConfig() error = %v, want nil", err)
+	}
+	if config.DBQueryTimeout != 250 {
+		t.Errorf("DBQueryTimeout = %v, want %v", config.DBQueryTimeout, 250)
+	}
+}
+
+// TestLoadDbConfig_Pool tests the connection pool settings and their defaults
+func TestLoadDbConfig_Pool(t *testing.T) {
+	cleanupViper()
+	defer cleanupViper()
+
+	tmpDir := t.TempDir()
+	createTestEnvFile(t, tmpDir, `DB_DRIVER=postgres
+DB_MAX_IDLE_CONNS=2`)
+
+	config, err := LoadDbConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadDbConfig() error = %v", err)
+	}
+	if config.DBMaxOpenConns != 25 {
+		t.Errorf("DBMaxOpenConns = %v, want 25", config.DBMaxOpenConns)
+	}
+	if config.DBMaxIdleConns != 2 {
+		t.Errorf("DBMaxIdleConns = %v, want 2", config.DBMaxIdleConns)
+	}
+	if config.DBConnMaxLifetime != 300 {
+		t.Errorf("DBConnMaxLifetime = %v, want 300", config.DBConnMaxLifetime)
+	}
+}
+
 // TestLoadDbConfigErrors tests error handling for database configuration
 // Note: The Unmarshal error path (line 49-51) is extremely difficult to trigger
 // because viper is very forgiving with type conversions and will use zero values
@@ -250,6 +339,272 @@ JWT_EXPIRATION=86400`,
 	}
 }
 
+// TestLoadWebConfig_RefreshExpiration tests that the refresh token lifetime
+// is read from the file and falls back to 7 days when it is not set
+func TestLoadWebConfig_RefreshExpiration(t *testing.T) {
+	tests := []struct {
+		name       string
+		envContent string
+		expected   int
+	}{
+		{
+			name: "explicit refresh expiration",
+			envContent: `WEB_PORT=8080
+JWT_SECRET=secret
+JWT_EXPIRATION=3600
+JWT_REFRESH_EXPIRATION=1209600`,
+			expected: 1209600,
+		},
+		{
+			name: "default refresh expiration",
+			envContent: `WEB_PORT=8080
+JWT_SECRET=secret
+JWT_EXPIRATION=3600`,
+			expected: 604800,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cleanupViper()
+			defer cleanupViper()
+
+			tmpDir := t.TempDir()
+			createTestEnvFile(t, tmpDir, tt.envContent)
+
+			config, err := LoadWebConfig(tmpDir)
+			if err != nil {
+				t.Fatalf("LoadWebConfig() error = %v, want nil", err)
+			}
+
+			if config.JWTRefreshExpiration != tt.expected {
+				t.Errorf("JWTRefreshExpiration = %v, want %v", config.JWTRefreshExpiration, tt.expected)
+			}
+		})
+	}
+}
+
+// TestLoadWebConfig_PasswordReset tests the password reset settings and
+// their defaults
+func TestLoadWebConfig_PasswordReset(t *testing.T) {
+	cleanupViper()
+	defer cleanupViper()
+
+	tmpDir := t.TempDir()
+	createTestEnvFile(t, tmpDir, `WEB_PORT=8080
+JWT_SECRET=secret
+JWT_EXPIRATION=3600`)
+
+	config, err := LoadWebConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadWebConfig() error = %v, want nil", err)
+	}
+	if config.PasswordResetExpiration != 1800 {
+		t.Errorf("PasswordResetExpiration = %v, want %v", config.PasswordResetExpiration, 1800)
+	}
+	if config.PasswordResetURL != "" {
+		t.Errorf("PasswordResetURL = %v, want empty", config.PasswordResetURL)
+	}
+
+	t.Setenv("PASSWORD_RESET_EXPIRATION", "600")
+	t.Setenv("PASSWORD_RESET_URL", "http://localhost:3000/reset?token={token}")
+	config, err = LoadWebConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadWebConfig() error = %v, want nil", err)
+	}
+	if config.PasswordResetExpiration != 600 {
+		t.Errorf("PasswordResetExpiration = %v, want %v", config.PasswordResetExpiration, 600)
+	}
+	if config.PasswordResetURL != "http://localhost:3000/reset?token={token}" {
+		t.Errorf("PasswordResetURL = %v, want override", config.PasswordResetURL)
+	}
+}
+
+// TestLoadWebConfig_TokenPurge tests the purge interval of the expired tokens
+func TestLoadWebConfig_TokenPurge(t *testing.T) {
+	cleanupViper()
+	defer cleanupViper()
+
+	tmpDir := t.TempDir()
+	createTestEnvFile(t, tmpDir, `WEB_PORT=8080
+JWT_SECRET=secret
+JWT_EXPIRATION=3600`)
+
+	config, err := LoadWebConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadWebConfig() error = %v, want nil", err)
+	}
+	if config.TokenPurgeInterval != 3600 {
+		t.Errorf("TokenPurgeInterval = %v, want %v", config.TokenPurgeInterval, 3600)
+	}
+
+	t.Setenv("TOKEN_PURGE_INTERVAL", "0")
+	config, err = LoadWebConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadWebConfig() error = %v, want nil", err)
+	}
+	if config.TokenPurgeInterval != 0 {
+		t.Errorf("TokenPurgeInterval = %v, want %v", config.TokenPurgeInterval, 0)
+	}
+}
+
+// TestLoadWebConfig_LoginThrottle tests the login lockout defaults and overrides
+func TestLoadWebConfig_LoginThrottle(t *testing.T) {
+	cleanupViper()
+	defer cleanupViper()
+
+	tmpDir := t.TempDir()
+	createTestEnvFile(t, tmpDir, `WEB_PORT=8080
+JWT_SECRET=secret
+JWT_EXPIRATION=3600
+LOGIN_MAX_ATTEMPTS=3`)
+
+	config, err := LoadWebConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadWebConfig() error = %v, want nil", err)
+	}
+	if config.LoginMaxAttempts != 3 {
+		t.Errorf("LoginMaxAttempts = %v, want %v", config.LoginMaxAttempts, 3)
+	}
+	if config.LoginMaxAttemptsPerIP != 20 {
+		t.Errorf("LoginMaxAttemptsPerIP = %v, want %v", config.LoginMaxAttemptsPerIP, 20)
+	}
+	if config.LoginLockout != 30 {
+		t.Errorf("LoginLockout = %v, want %v", config.LoginLockout, 30)
+	}
+	if config.LoginMaxLockout != 900 {
+		t.Errorf("LoginMaxLockout = %v, want %v", config.LoginMaxLockout, 900)
+	}
+}
+
+// TestLoadWebConfig_Server tests the port and timeouts defaults and overrides
+func TestLoadWebConfig_Server(t *testing.T) {
+	cleanupViper()
+	defer cleanupViper()
+
+	tmpDir := t.TempDir()
+	createTestEnvFile(t, tmpDir, `JWT_SECRET=secret
+JWT_EXPIRATION=3600
+WEB_WRITE_TIMEOUT=120`)
+
+	config, err := LoadWebConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadWebConfig() error = %v, want nil", err)
+	}
+	if config.WebServerPort != "8000" {
+		t.Errorf("WebServerPort = %v, want %v", config.WebServerPort, "8000")
+	}
+	if config.WebReadTimeout != 15 {
+		t.Errorf("WebReadTimeout = %v, want %v", config.WebReadTimeout, 15)
+	}
+	if config.WebWriteTimeout != 120 {
+		t.Errorf("WebWriteTimeout = %v, want %v", config.WebWriteTimeout, 120)
+	}
+	if config.WebIdleTimeout != 60 {
+		t.Errorf("WebIdleTimeout = %v, want %v", config.WebIdleTimeout, 60)
+	}
+	if config.WebShutdownTimeout != 20 {
+		t.Errorf("WebShutdownTimeout = %v, want %v", config.WebShutdownTimeout, 20)
+	}
+	if config.TracingExporter != "none" {
+		t.Errorf("TracingExporter = %v, want %v", config.TracingExporter, "none")
+	}
+	if config.ImportMaxSize != 50<<20 {
+		t.Errorf("ImportMaxSize = %v, want %v", config.ImportMaxSize, 50<<20)
+	}
+	if config.ImportTimeout != 300 {
+		t.Errorf("ImportTimeout = %v, want %v", config.ImportTimeout, 300)
+	}
+}
+
+func TestLoadWebConfig_Trash(t *testing.T) {
+	cleanupViper()
+	defer cleanupViper()
+
+	tmpDir := t.TempDir()
+	createTestEnvFile(t, tmpDir, `WEB_PORT=8080
+JWT_SECRET=secret
+JWT_EXPIRATION=3600
+TRASH_RETENTION=7`)
+
+	config, err := LoadWebConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadWebConfig() error = %v, want nil", err)
+	}
+	if config.TrashRetention != 7 {
+		t.Errorf("TrashRetention = %v, want %v", config.TrashRetention, 7)
+	}
+	if config.TrashPurgeInterval != 3600 {
+		t.Errorf("TrashPurgeInterval = %v, want %v", config.TrashPurgeInterval, 3600)
+	}
+	if config.IdempotencyExpiration != 86400 {
+		t.Errorf("IdempotencyExpiration = %v, want %v", config.IdempotencyExpiration, 86400)
+	}
+	if config.IdempotencyPurgeInterval != 3600 {
+		t.Errorf("IdempotencyPurgeInterval = %v, want %v", config.IdempotencyPurgeInterval, 3600)
+	}
+}
+
+// TestLoadWebConfig_RateLimit tests the limits of the route groups
+func TestLoadWebConfig_RateLimit(t *testing.T) {
+	cleanupViper()
+	defer cleanupViper()
+
+	tmpDir := t.TempDir()
+	createTestEnvFile(t, tmpDir, `JWT_SECRET=secret
+JWT_EXPIRATION=3600
+RATE_LIMIT_PUBLIC=5
+RATE_LIMIT_PRODUCTS=0`)
+
+	config, err := LoadWebConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadWebConfig() error = %v, want nil", err)
+	}
+	if config.RateLimitPublic != 5 {
+		t.Errorf("RateLimitPublic = %v, want %v", config.RateLimitPublic, 5)
+	}
+	if config.RateLimitUsers != 120 {
+		t.Errorf("RateLimitUsers = %v, want %v", config.RateLimitUsers, 120)
+	}
+	if config.RateLimitProducts != 0 {
+		t.Errorf("RateLimitProducts = %v, want %v", config.RateLimitProducts, 0)
+	}
+	if config.RateLimitPeriod != 60 {
+		t.Errorf("RateLimitPeriod = %v, want %v", config.RateLimitPeriod, 60)
+	}
+}
+
+// TestLoadWebConfig_Storage tests the blob store of the product images
+func TestLoadWebConfig_Storage(t *testing.T) {
+	cleanupViper()
+	defer cleanupViper()
+
+	tmpDir := t.TempDir()
+	createTestEnvFile(t, tmpDir, `JWT_SECRET=secret
+STORAGE_DRIVER=s3
+STORAGE_S3_BUCKET=images`)
+
+	config, err := LoadWebConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadWebConfig() error = %v, want nil", err)
+	}
+	if config.StorageDriver != "s3" {
+		t.Errorf("StorageDriver = %v, want %v", config.StorageDriver, "s3")
+	}
+	if config.StorageS3Bucket != "images" {
+		t.Errorf("StorageS3Bucket = %v, want %v", config.StorageS3Bucket, "images")
+	}
+	if config.StorageS3Region != "sa-east-1" {
+		t.Errorf("StorageS3Region = %v, want %v", config.StorageS3Region, "sa-east-1")
+	}
+	if config.StorageLocalDir != "uploads" {
+		t.Errorf("StorageLocalDir = %v, want %v", config.StorageLocalDir, "uploads")
+	}
+	if config.ImageMaxSize != 5<<20 {
+		t.Errorf("ImageMaxSize = %v, want %v", config.ImageMaxSize, 5<<20)
+	}
+}
+
 // TestJWTInitialization tests that JWT authenticator is properly initialized
 func TestJWTInitialization(t *testing.T) {
 	tests := []struct {
@@ -583,7 +938,7 @@ DB_PORT=3306
 DB_USER=root
 DB_PASSWORD=secret
 DB_NAME=mydb`,
-			expectedDSN: "root:secret@tcp(localhost:3306)/mydb?charset=utf8mb4&parseTime=True&loc=Local",
+			expectedDSN: "root:secret@tcp(localhost:3306)/mydb?charset=utf8mb4&parseTime=True&loc=Local&multiStatements=true",
 			expectError: false,
 		},
 		{
@@ -711,16 +1066,15 @@ DB_NAME=testdb`)
 	}
 }
 
-// TestInitGorm_DriversNotInstalled tests that appropriate errors are returned
-// when GORM drivers are not installed (or database connection fails)
-func TestInitGorm_DriversNotInstalled(t *testing.T) {
+// TestInitGorm_ConnectionFails tests that the drivers of database servers
+// report the failed connection (no test DB running)
+func TestInitGorm_ConnectionFails(t *testing.T) {
 	tests := []struct {
 		driver      string
 		expectError bool
 	}{
-		{"postgres", true}, // Will fail to connect (no test DB running)
-		{"mysql", true},    // Driver not installed
-		{"sqlite", true},   // Driver not installed
+		{"postgres", true},
+		{"mysql", true},
 	}
 
 	for _, tt := range tests {
@@ -750,6 +1104,41 @@ DB_NAME=testdb`, tt.driver)
 	}
 }
 
+// TestInitGorm_SQLite tests that a local SQLite file needs no database
+// server and gets a single connection
+func TestInitGorm_SQLite(t *testing.T) {
+	cleanupViper()
+	defer cleanupViper()
+
+	tmpDir := t.TempDir()
+	createTestEnvFile(t, tmpDir, `DB_DRIVER=sqlite
+DB_NAME=`+filepath.Join(tmpDir, "test.db")+`
+DB_MAX_OPEN_CONNS=10`)
+
+	_, err := LoadDbConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadDbConfig() error = %v", err)
+	}
+	if err := InitGorm(); err != nil {
+		t.Fatalf("InitGorm() error = %v", err)
+	}
+	sqlDB, err := GetDB().DB()
+	if err != nil {
+		t.Fatalf("DB() error = %v", err)
+	}
+	defer sqlDB.Close()
+
+	if name := GetDB().Dialector.Name(); name != "sqlite" {
+		t.Errorf("Dialector.Name() = %v, want sqlite", name)
+	}
+	if got := sqlDB.Stats().MaxOpenConnections; got != 1 {
+		t.Errorf("MaxOpenConnections = %v, want 1", got)
+	}
+	if err := GetDB().Exec("SELECT 1").Error; err != nil {
+		t.Errorf("query error = %v", err)
+	}
+}
+
 // TestGetDbConfig_ReturnsLoadedConfig tests that GetDbConfig returns the loaded config
 func TestGetDbConfig_ReturnsLoadedConfig(t *testing.T) {
 	cleanupViper()
@@ -5,4 +5,7 @@
 
 # Initialization
 # swag init --parseDependency --dir ./ --output ./docs # generated by kiro
-swag init -g cmd/server/main.go # course
\ No newline at end of file
+# swag init -g cmd/server/main.go # course
+# The module root has no Go files, so every package with documented types
+# is listed: the aliased imports (like entityPkg) only resolve this way
+swag init -d ./cmd/server,./internal/infra/webserver/handlers,./internal/dto,./internal/entity,./pkg/entity -g main.go
\ No newline at end of file
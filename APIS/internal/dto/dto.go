@@ -1,32 +1,125 @@
 package dto
 
+import (
+	"time"
+
+	"github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
+)
+
 type ErrorResponse struct {
-	Messages []string `json:"messages"`
-	Code     int      `json:"code"`
+	Messages []string          `json:"messages"`
+	Fields   map[string]string `json:"fields,omitempty"`
+	Code     int               `json:"code"`
 }
 
 type AuthResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+type RefreshTokenInput struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
 // CreateProductInput
-// using binding for when migrating to gin
+// the binding tags are checked by handlers.DecodeJSON, gt applies to the
+// amount of Price. Price is also accepted as a plain number in the default
+// currency
 type CreateProductInput struct {
-	Name  string  `json:"name" binding:"required"`
-	Price float64 `json:"price" binding:"required,gt=0"`
+	Name  string       `json:"name" binding:"required"`
+	Price entity.Money `json:"price" binding:"required,gt=0"`
 }
 
 // UpdateProductInput
 type UpdateProductInput struct {
-	Name  string  `json:"name" binding:"required"`
-	Price float64 `json:"price" binding:"required,gt=0"`
+	Name  string       `json:"name" binding:"required"`
+	Price entity.Money `json:"price" binding:"required,gt=0"`
 }
 
 // ProductOutput
+// Categories and Images are only sent for a single product, not in the lists
 type ProductOutput struct {
-	ID    string  `json:"id"`
-	Name  string  `json:"name"`
-	Price float64 `json:"price"`
+	ID         string               `json:"id"`
+	Name       string               `json:"name"`
+	Price      entity.Money         `json:"price"`
+	Categories []CategoryOutput     `json:"categories,omitempty"`
+	Images     []ProductImageOutput `json:"images,omitempty"`
+}
+
+// ProductImageOutput
+// URL is where the image is downloaded from
+type ProductImageOutput struct {
+	ID          string    `json:"id"`
+	URL         string    `json:"url"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ProductCategoriesInput replaces the categories of a product, an empty
+// list removes them all
+type ProductCategoriesInput struct {
+	CategoryIDs []string `json:"category_ids" binding:"required"`
+}
+
+// DeletedProductOutput is a product in the trash
+type DeletedProductOutput struct {
+	ID        string       `json:"id"`
+	Name      string       `json:"name"`
+	Price     entity.Money `json:"price"`
+	DeletedAt time.Time    `json:"deleted_at"`
+}
+
+// ProductAuditOutput is a change in the history of a product
+// Before is null for a creation or restore, After for a deletion
+type ProductAuditOutput struct {
+	ID        string              `json:"id"`
+	Action    string              `json:"action"`
+	UserID    string              `json:"user_id"`
+	Before    *ProductStateOutput `json:"before"`
+	After     *ProductStateOutput `json:"after"`
+	CreatedAt time.Time           `json:"created_at"`
+}
+
+// ProductStateOutput
+// Categories and Images are the ids, only in the audits that change them
+type ProductStateOutput struct {
+	Name       string       `json:"name"`
+	Price      entity.Money `json:"price"`
+	Version    int64        `json:"version"`
+	Categories []string     `json:"categories,omitempty"`
+	Images     []string     `json:"images,omitempty"`
+}
+
+// ImportProductsOutput is the report of POST /products/import
+type ImportProductsOutput struct {
+	Imported int              `json:"imported"`
+	Failed   int              `json:"failed"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+// ImportRowError
+// Line is the line of the row in the uploaded file
+type ImportRowError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// CreateCategoryInput
+type CreateCategoryInput struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// UpdateCategoryInput
+type UpdateCategoryInput struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// CategoryOutput
+type CategoryOutput struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
 }
 
 // ProductListOutput
@@ -48,9 +141,40 @@ type UserOutput struct {
 	ID    string `json:"id"`
 	Name  string `json:"name"`
 	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+type UpdateUserInput struct {
+	Name  string `json:"name" binding:"required"`
+	Email string `json:"email" binding:"required"`
+}
+
+type ChangePasswordInput struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+type ForgotPasswordInput struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type ResetPasswordInput struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+type UpdateUserRoleInput struct {
+	Role string `json:"role" binding:"required,oneof=admin editor viewer"`
 }
 
 type LoginInput struct {
 	Email    string `json:"email" binding:"required"`
 	Password string `json:"password" binding:"required"`
 }
+
+// HealthOutput
+// Checks has the state of every dependency of the readiness probe
+type HealthOutput struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -12,13 +13,13 @@ func TestCreateProductInput_JSONMarshaling(t *testing.T) {
 	t.Run("should marshal to JSON correctly", func(t *testing.T) {
 		input := CreateProductInput{
 			Name:  "Laptop",
-			Price: 999.99,
+			Price: entity.NewMoney(99999, "BRL"),
 		}
 
 		jsonData, err := json.Marshal(input)
 		require.NoError(t, err)
 
-		expected := `{"name":"Laptop","price":999.99}`
+		expected := `{"name":"Laptop","price":{"amount":"999.99","currency":"BRL"}}`
 		assert.JSONEq(t, expected, string(jsonData))
 	})
 
@@ -30,13 +31,23 @@ func TestCreateProductInput_JSONMarshaling(t *testing.T) {
 		require.NoError(t, err)
 
 		assert.Equal(t, "Mouse", input.Name)
-		assert.Equal(t, 29.99, input.Price)
+		assert.Equal(t, entity.NewMoney(2999, entity.DefaultCurrency), input.Price, "A plain number is still accepted")
+	})
+
+	t.Run("should unmarshal a price with its currency", func(t *testing.T) {
+		jsonData := `{"name":"Mouse","price":{"amount":"29.99","currency":"USD"}}`
+
+		var input CreateProductInput
+		err := json.Unmarshal([]byte(jsonData), &input)
+		require.NoError(t, err)
+
+		assert.Equal(t, entity.NewMoney(2999, "USD"), input.Price)
 	})
 
 	t.Run("should handle special characters in name", func(t *testing.T) {
 		input := CreateProductInput{
 			Name:  "Product @#$% Special!",
-			Price: 49.99,
+			Price: entity.NewMoney(4999, "BRL"),
 		}
 
 		jsonData, err := json.Marshal(input)
@@ -55,13 +66,13 @@ func TestUpdateProductInput_JSONMarshaling(t *testing.T) {
 	t.Run("should marshal to JSON correctly", func(t *testing.T) {
 		input := UpdateProductInput{
 			Name:  "Updated Product",
-			Price: 149.99,
+			Price: entity.NewMoney(14999, "BRL"),
 		}
 
 		jsonData, err := json.Marshal(input)
 		require.NoError(t, err)
 
-		expected := `{"name":"Updated Product","price":149.99}`
+		expected := `{"name":"Updated Product","price":{"amount":"149.99","currency":"BRL"}}`
 		assert.JSONEq(t, expected, string(jsonData))
 	})
 
@@ -73,7 +84,7 @@ func TestUpdateProductInput_JSONMarshaling(t *testing.T) {
 		require.NoError(t, err)
 
 		assert.Equal(t, "Keyboard", input.Name)
-		assert.Equal(t, 79.99, input.Price)
+		assert.Equal(t, entity.NewMoney(7999, entity.DefaultCurrency), input.Price)
 	})
 }
 
@@ -82,18 +93,18 @@ func TestProductOutput_JSONMarshaling(t *testing.T) {
 		output := ProductOutput{
 			ID:    "019ab24a-dc97-72a4-9056-cc09f4c13bef",
 			Name:  "Monitor",
-			Price: 299.99,
+			Price: entity.NewMoney(29999, "BRL"),
 		}
 
 		jsonData, err := json.Marshal(output)
 		require.NoError(t, err)
 
-		expected := `{"id":"019ab24a-dc97-72a4-9056-cc09f4c13bef","name":"Monitor","price":299.99}`
+		expected := `{"id":"019ab24a-dc97-72a4-9056-cc09f4c13bef","name":"Monitor","price":{"amount":"299.99","currency":"BRL"}}`
 		assert.JSONEq(t, expected, string(jsonData))
 	})
 
 	t.Run("should unmarshal from JSON correctly", func(t *testing.T) {
-		jsonData := `{"id":"019ab24a-dc97-72a4-9056-cc09f4c13bef","name":"Headphones","price":89.99}`
+		jsonData := `{"id":"019ab24a-dc97-72a4-9056-cc09f4c13bef","name":"Headphones","price":{"amount":"89.99","currency":"BRL"}}`
 
 		var output ProductOutput
 		err := json.Unmarshal([]byte(jsonData), &output)
@@ -101,7 +112,7 @@ func TestProductOutput_JSONMarshaling(t *testing.T) {
 
 		assert.Equal(t, "019ab24a-dc97-72a4-9056-cc09f4c13bef", output.ID)
 		assert.Equal(t, "Headphones", output.Name)
-		assert.Equal(t, 89.99, output.Price)
+		assert.Equal(t, entity.NewMoney(8999, "BRL"), output.Price)
 	})
 }
 
@@ -112,12 +123,12 @@ func TestProductListOutput_JSONMarshaling(t *testing.T) {
 				{
 					ID:    "019ab24a-dc97-72a4-9056-cc09f4c13bef",
 					Name:  "Product 1",
-					Price: 10.00,
+					Price: entity.NewMoney(1000, "BRL"),
 				},
 				{
 					ID:    "019ab24a-dc97-72a4-9056-cc09f4c13bf0",
 					Name:  "Product 2",
-					Price: 20.00,
+					Price: entity.NewMoney(2000, "BRL"),
 				},
 			},
 			Page:  1,
@@ -165,7 +176,7 @@ func TestCreateProductInput_ZeroValues(t *testing.T) {
 		input := CreateProductInput{}
 
 		assert.Equal(t, "", input.Name)
-		assert.Equal(t, 0.0, input.Price)
+		assert.Equal(t, entity.Money{}, input.Price)
 	})
 }
 
@@ -174,7 +185,7 @@ func TestUpdateProductInput_ZeroValues(t *testing.T) {
 		input := UpdateProductInput{}
 
 		assert.Equal(t, "", input.Name)
-		assert.Equal(t, 0.0, input.Price)
+		assert.Equal(t, entity.Money{}, input.Price)
 	})
 }
 
@@ -184,7 +195,7 @@ func TestProductOutput_ZeroValues(t *testing.T) {
 
 		assert.Equal(t, "", output.ID)
 		assert.Equal(t, "", output.Name)
-		assert.Equal(t, 0.0, output.Price)
+		assert.Equal(t, entity.Money{}, output.Price)
 	})
 }
 
@@ -192,7 +203,7 @@ func TestCreateProductInput_LargePrice(t *testing.T) {
 	t.Run("should handle large price values", func(t *testing.T) {
 		input := CreateProductInput{
 			Name:  "Expensive Item",
-			Price: 99999999.99,
+			Price: entity.NewMoney(9999999999, "BRL"),
 		}
 
 		jsonData, err := json.Marshal(input)
@@ -211,7 +222,7 @@ func TestCreateProductInput_SmallPrice(t *testing.T) {
 	t.Run("should handle small price values", func(t *testing.T) {
 		input := CreateProductInput{
 			Name:  "Cheap Item",
-			Price: 0.01,
+			Price: entity.NewMoney(1, "BRL"),
 		}
 
 		jsonData, err := json.Marshal(input)
@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
+)
+
+// Category groups products, a product can be in many categories and a
+// category has many products.
+type Category struct {
+	ID   entity.ID `json:"id" gorm:"column:cat_id;type:uuid;primarykey"`
+	Name string    `json:"name" gorm:"column:cat_name;size:255;unique"`
+	entity.BaseModel
+}
+
+func (Category) TableName() string {
+	return "categories"
+}
+
+func (c *Category) Validate() error {
+	if c.ID.String() == "" {
+		return ErrIDRequired
+	}
+	if _, err := entity.ParseID(c.ID.String()); err != nil {
+		return ErrIDRequired
+	}
+	if c.Name == "" {
+		return ErrNameRequired
+	}
+	if len(c.Name) > 255 {
+		return ErrNameTooLong
+	}
+	return nil
+}
+
+func NewCategory(name string) (*Category, error) {
+	category := &Category{
+		ID:   entity.NewID(),
+		Name: name,
+	}
+	if err := category.Validate(); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCategory(t *testing.T) {
+	category, err := NewCategory("Electronics")
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, category.ID)
+	assert.Equal(t, "Electronics", category.Name)
+}
+
+func TestNewCategory_ValidatesName(t *testing.T) {
+	_, err := NewCategory("")
+	assert.ErrorIs(t, err, ErrNameRequired)
+
+	_, err = NewCategory(strings.Repeat("a", 256))
+	assert.ErrorIs(t, err, ErrNameTooLong)
+}
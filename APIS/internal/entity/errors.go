@@ -2,19 +2,106 @@ package entity
 
 import "errors"
 
+// Error kinds. Every domain error wraps one of them, errors.Is(err, ErrNotFound)
+// works no matter which repository or entity returned it.
 var (
-	ErrIDRequired   = errors.New("ID is required and must be valid")
-	ErrNameRequired = errors.New("name is required")
-	ErrNameTooLong  = errors.New("name cannot exceed 255 characters")
+	ErrNotFound      = errors.New("not found")
+	ErrConflict      = errors.New("conflict")
+	ErrValidation    = errors.New("validation failed")
+	ErrUnavailable   = errors.New("service unavailable")
+	ErrPrecondition  = errors.New("precondition failed")
+	ErrUnprocessable = errors.New("unprocessable")
+)
+
+// Error is a domain error of a given kind. Its message is meant for the
+// client, the cause (if any) is kept for the logs.
+type Error struct {
+	Kind    error
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+// Is matches another Error with the same kind and message, so a sentinel
+// still matches after a cause is attached with Wrap.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Kind == e.Kind && t.Message == e.Message
+}
+
+// Wrap returns a copy of the sentinel with cause attached. The sentinel can
+// also be one of the kinds.
+func Wrap(sentinel error, cause error) error {
+	var e *Error
+	if !errors.As(sentinel, &e) {
+		return &Error{Kind: sentinel, Message: sentinel.Error(), Err: cause}
+	}
+	return &Error{Kind: e.Kind, Message: e.Message, Err: cause}
+}
+
+func newError(kind error, message string) error {
+	return &Error{Kind: kind, Message: message}
+}
+
+var (
+	ErrIDRequired   = newError(ErrValidation, "ID is required and must be valid")
+	ErrNameRequired = newError(ErrValidation, "name is required")
+	ErrNameTooLong  = newError(ErrValidation, "name cannot exceed 255 characters")
+	ErrInvalidLimit = newError(ErrValidation, "limit must be at least 1")
+)
+
+var (
+	ErrInvalidPrice     = newError(ErrValidation, "product price must be greater than zero")
+	ErrInvalidCurrency  = newError(ErrValidation, "currency must be a 3 letter ISO 4217 code")
+	ErrProductNotFound  = newError(ErrNotFound, "product not found")
+	ErrProductDuplicate = newError(ErrConflict, "product already exists")
+	ErrProductModified  = newError(ErrPrecondition, "product was modified by another request")
+)
+
+var (
+	ErrCategoryNotFound  = newError(ErrNotFound, "category not found")
+	ErrCategoryDuplicate = newError(ErrConflict, "category name is already in use")
+	ErrUnknownCategory   = newError(ErrValidation, "one of the categories does not exist")
+)
+
+var (
+	ErrInvalidImageType = newError(ErrValidation, "image must be a JPEG, PNG, GIF or WebP file")
+	ErrEmptyImage       = newError(ErrValidation, "image is empty")
+)
+
+var (
+	ErrInvalidIdempotencyKey = newError(ErrValidation, "Idempotency-Key must have between 1 and 255 characters")
+	ErrIdempotencyKeyInUse   = newError(ErrConflict, "a request with this Idempotency-Key is still in progress")
+	ErrIdempotencyKeyReused  = newError(ErrUnprocessable, "Idempotency-Key was already used for a different request")
 )
 
 var (
-	ErrInvalidPrice = errors.New("product price must be greater than zero")
+	ErrEmailRequired    = newError(ErrValidation, "email is required")
+	ErrEmailTooLong     = newError(ErrValidation, "email cannot exceed 255 characters")
+	ErrPasswordRequired = newError(ErrValidation, "password is required")
+	ErrPasswordTooLong  = newError(ErrValidation, "password cannot exceed 255 characters")
+	ErrInvalidRole      = newError(ErrValidation, "role must be one of admin, editor or viewer")
+	ErrWrongPassword    = newError(ErrValidation, "current password is incorrect")
+	ErrUserNotFound     = newError(ErrNotFound, "user not found")
+	ErrEmailTaken       = newError(ErrConflict, "email is already in use")
 )
 
 var (
-	ErrEmailRequired    = errors.New("email is required")
-	ErrEmailTooLong     = errors.New("email cannot exceed 255 characters")
-	ErrPasswordRequired = errors.New("password is required")
-	ErrPasswordTooLong  = errors.New("password cannot exceed 255 characters")
+	ErrTokenInvalid = errors.New("token is invalid")
+	ErrTokenExpired = errors.New("token has expired")
+	ErrTokenRevoked = errors.New("token has been revoked")
+	ErrForbidden    = errors.New("insufficient permissions")
 )
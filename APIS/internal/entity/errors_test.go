@@ -0,0 +1,42 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWrap verifies that a wrapped sentinel still matches the sentinel, its
+// kind and the cause, and keeps the client message.
+func TestWrap(t *testing.T) {
+	cause := errors.New("record not found")
+	err := Wrap(ErrUserNotFound, cause)
+
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, err, cause)
+	assert.NotErrorIs(t, err, ErrProductNotFound)
+
+	var domainErr *Error
+	assert.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, "user not found", domainErr.Message)
+}
+
+// TestWrap_Kind verifies that a kind can be wrapped directly.
+func TestWrap_Kind(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := Wrap(ErrUnavailable, cause)
+
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.ErrorIs(t, err, cause)
+}
+
+// TestValidationErrors_Kind verifies that the entity validation errors keep
+// their message and are of the validation kind.
+func TestValidationErrors_Kind(t *testing.T) {
+	assert.Equal(t, "name is required", ErrNameRequired.Error())
+	assert.ErrorIs(t, ErrNameRequired, ErrValidation)
+	assert.ErrorIs(t, ErrInvalidPrice, ErrValidation)
+	assert.ErrorIs(t, ErrEmailTaken, ErrConflict)
+}
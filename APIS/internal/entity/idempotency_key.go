@@ -0,0 +1,55 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
+)
+
+// maxIdempotencyKeyLength is the size of the idk_key column.
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyKey keeps the first response to a request sent with an
+// Idempotency-Key header, a retry with the same key gets it back instead of
+// running the request again. Scope keeps the keys of every client and route
+// apart, RequestHash tells when a key is reused for a different request.
+type IdempotencyKey struct {
+	ID          entity.ID         `json:"id" gorm:"column:idk_id;type:uuid;primarykey"`
+	Scope       string            `json:"scope" gorm:"column:idk_scope;size:255;uniqueIndex:idx_idempotency_keys_scope_key"`
+	Key         string            `json:"key" gorm:"column:idk_key;size:255;uniqueIndex:idx_idempotency_keys_scope_key"`
+	RequestHash string            `json:"-" gorm:"column:idk_request_hash;size:64"`
+	Status      int               `json:"status" gorm:"column:idk_status"` // 0 while the request is running
+	Header      map[string]string `json:"header" gorm:"column:idk_header;type:text;serializer:json"`
+	Body        []byte            `json:"-" gorm:"column:idk_body"`
+	ExpiresAt   time.Time         `json:"expires_at" gorm:"column:idk_expires_at;index"`
+	CreatedAt   time.Time         `json:"created_at" gorm:"column:idk_created_at"`
+}
+
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
+
+func (k *IdempotencyKey) IsExpired(now time.Time) bool {
+	return !now.Before(k.ExpiresAt)
+}
+
+// IsCompleted tells if the response is stored. Until then the first
+// request is still running.
+func (k *IdempotencyKey) IsCompleted() bool {
+	return k.Status != 0
+}
+
+// NewIdempotencyKey reserves key in scope for the request with the given
+// hash. The response is added once the request completes.
+func NewIdempotencyKey(scope, key, requestHash string, ttl time.Duration) (*IdempotencyKey, error) {
+	if key == "" || len(key) > maxIdempotencyKeyLength {
+		return nil, ErrInvalidIdempotencyKey
+	}
+	return &IdempotencyKey{
+		ID:          entity.NewID(),
+		Scope:       scope,
+		Key:         key,
+		RequestHash: requestHash,
+		ExpiresAt:   time.Now().Add(ttl),
+	}, nil
+}
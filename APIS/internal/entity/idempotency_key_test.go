@@ -0,0 +1,30 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewIdempotencyKey(t *testing.T) {
+	key, err := NewIdempotencyKey("sub:1 POST /products", "retry-1", "hash", 24*time.Hour)
+
+	require.NoError(t, err)
+	assert.NotEmpty(t, key.ID)
+	assert.Equal(t, "retry-1", key.Key)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), key.ExpiresAt, time.Second)
+	assert.False(t, key.IsCompleted(), "There is no response yet")
+	assert.False(t, key.IsExpired(time.Now()))
+	assert.True(t, key.IsExpired(key.ExpiresAt))
+}
+
+func TestNewIdempotencyKey_Validates(t *testing.T) {
+	_, err := NewIdempotencyKey("scope", "", "hash", time.Hour)
+	assert.ErrorIs(t, err, ErrInvalidIdempotencyKey)
+
+	_, err = NewIdempotencyKey("scope", strings.Repeat("k", 256), "hash", time.Hour)
+	assert.ErrorIs(t, err, ErrInvalidIdempotencyKey)
+}
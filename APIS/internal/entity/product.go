@@ -1,13 +1,22 @@
 package entity
 
 import (
+	"gorm.io/plugin/optimisticlock"
+
 	"github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
 )
 
 type Product struct {
-	ID    entity.ID `json:"id" gorm:"column:prd_id;type:uuid;primarykey"`
-	Name  string    `json:"name" gorm:"column:prd_name;size:255"`
-	Price float64   `json:"price" gorm:"column:prd_price;type:decimal(10,2)"`
+	ID    entity.ID    `json:"id" gorm:"column:prd_id;type:uuid;primarykey"`
+	Name  string       `json:"name" gorm:"column:prd_name;size:255"`
+	Price entity.Money `json:"price" gorm:"embedded;embeddedPrefix:prd_price_"`
+	// Version starts at 1 and is bumped by every update, see database.Product.Update
+	Version optimisticlock.Version `json:"version" gorm:"column:prd_version;not null;default:1"`
+	// Categories is only loaded by FindByID and changed by SetCategories,
+	// tagging a product doesn't bump its version
+	Categories []Category `json:"categories,omitempty" gorm:"many2many:product_categories;joinForeignKey:pct_prd_id;joinReferences:pct_cat_id"`
+	// Images is only loaded by FindByID and added by AddImage, in upload order
+	Images []ProductImage `json:"images,omitempty" gorm:"foreignKey:ProductID"`
 	entity.BaseModel
 }
 
@@ -28,13 +37,16 @@ func (p *Product) Validate() error {
 	if len(p.Name) > 255 {
 		return ErrNameTooLong
 	}
-	if p.Price <= 0 {
+	if p.Price.Amount <= 0 {
 		return ErrInvalidPrice
 	}
+	if err := p.Price.Validate(); err != nil {
+		return ErrInvalidCurrency
+	}
 	return nil
 }
 
-func NewProduct(name string, price float64) (*Product, error) {
+func NewProduct(name string, price entity.Money) (*Product, error) {
 	product := &Product{
 		ID:    entity.NewID(),
 		Name:  name,
@@ -0,0 +1,91 @@
+package entity
+
+import (
+	"context"
+	"time"
+
+	"github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
+)
+
+// Audit actions
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	// AuditCategories is a SetCategories or a category delete, AuditImage
+	// an AddImage
+	AuditCategories = "categories"
+	AuditImage      = "image"
+)
+
+// ProductState is the audited part of a product. Categories and Images
+// are only set by the audits that change them.
+type ProductState struct {
+	Name       string       `json:"name"`
+	Price      entity.Money `json:"price"`
+	Version    int64        `json:"version"`
+	Categories []string     `json:"categories,omitempty"` // ids
+	Images     []string     `json:"images,omitempty"`     // ids
+}
+
+// ProductAudit records a change to a product: who made it and the values
+// before and after. Before is nil for a creation and After for a deletion.
+// Audits are only appended, never changed.
+type ProductAudit struct {
+	ID        entity.ID     `json:"id" gorm:"column:aud_id;type:uuid;primarykey"`
+	ProductID entity.ID     `json:"product_id" gorm:"column:aud_prd_id;type:uuid;index"`
+	UserID    string        `json:"user_id" gorm:"column:aud_usr_id;size:64"`
+	Action    string        `json:"action" gorm:"column:aud_action;size:16"`
+	Before    *ProductState `json:"before" gorm:"column:aud_before;type:text;serializer:json"`
+	After     *ProductState `json:"after" gorm:"column:aud_after;type:text;serializer:json"`
+	CreatedAt time.Time     `json:"created_at" gorm:"column:aud_created_at"`
+}
+
+func (ProductAudit) TableName() string {
+	return "product_audits"
+}
+
+// NewProductAudit records action on the product by the actor of ctx.
+// before and after may be nil.
+func NewProductAudit(ctx context.Context, action string, productID entity.ID, before, after *Product) *ProductAudit {
+	return &ProductAudit{
+		ID:        entity.NewID(),
+		ProductID: productID,
+		UserID:    ActorFromContext(ctx),
+		Action:    action,
+		Before:    before.State(),
+		After:     after.State(),
+	}
+}
+
+// State returns the audited values of the product, nil for a nil product.
+// The categories and images are the loaded ones.
+func (p *Product) State() *ProductState {
+	if p == nil {
+		return nil
+	}
+	state := &ProductState{Name: p.Name, Price: p.Price, Version: p.Version.Int64}
+	for _, category := range p.Categories {
+		state.Categories = append(state.Categories, category.ID.String())
+	}
+	for _, image := range p.Images {
+		state.Images = append(state.Images, image.ID.String())
+	}
+	return state
+}
+
+type actorKey struct{}
+
+// ContextWithActor returns a copy of ctx carrying the id of the user making
+// the request, the repositories record it in the audits.
+func ContextWithActor(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, actorKey{}, userID)
+}
+
+// ActorFromContext returns the user set by ContextWithActor, empty when the
+// change doesn't come from a user.
+func ActorFromContext(ctx context.Context) string {
+	userID, _ := ctx.Value(actorKey{}).(string)
+	return userID
+}
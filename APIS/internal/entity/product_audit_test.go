@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProductAudit(t *testing.T) {
+	product, err := NewProduct("Product", entity.NewMoney(1000, "BRL"))
+	require.NoError(t, err)
+	product.Version.Int64 = 3
+
+	ctx := ContextWithActor(context.Background(), "user-1")
+	audit := NewProductAudit(ctx, AuditDelete, product.ID, product, nil)
+
+	assert.NotEmpty(t, audit.ID.String())
+	assert.Equal(t, product.ID, audit.ProductID)
+	assert.Equal(t, "user-1", audit.UserID)
+	assert.Equal(t, AuditDelete, audit.Action)
+	assert.Equal(t, &ProductState{Name: "Product", Price: entity.NewMoney(1000, "BRL"), Version: 3}, audit.Before)
+	assert.Nil(t, audit.After)
+}
+
+// The audits written before prices had a currency must still decode
+func TestProductState_NumericPrice(t *testing.T) {
+	var state ProductState
+	require.NoError(t, json.Unmarshal([]byte(`{"name":"Product","price":10.5,"version":1}`), &state))
+	assert.Equal(t, entity.NewMoney(1050, entity.DefaultCurrency), state.Price)
+}
+
+func TestActorFromContext(t *testing.T) {
+	assert.Equal(t, "", ActorFromContext(context.Background()))
+	assert.Equal(t, "user-1", ActorFromContext(ContextWithActor(context.Background(), "user-1")))
+}
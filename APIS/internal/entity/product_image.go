@@ -0,0 +1,60 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
+)
+
+// imageExtensions are the accepted image types and the extension of their
+// files.
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// ProductImage is the metadata of an image of a product, the file itself
+// is in the blob store under Key.
+type ProductImage struct {
+	ID          entity.ID `json:"id" gorm:"column:pim_id;type:uuid;primarykey"`
+	ProductID   entity.ID `json:"product_id" gorm:"column:pim_prd_id;type:uuid;index"`
+	Key         string    `json:"key" gorm:"column:pim_key;size:255"`
+	ContentType string    `json:"content_type" gorm:"column:pim_content_type;size:100"`
+	Size        int64     `json:"size" gorm:"column:pim_size"`
+	CreatedAt   time.Time `json:"created_at" gorm:"column:pim_created_at"`
+}
+
+func (ProductImage) TableName() string {
+	return "product_images"
+}
+
+func (i *ProductImage) Validate() error {
+	if _, err := entity.ParseID(i.ID.String()); err != nil {
+		return ErrIDRequired
+	}
+	if _, ok := imageExtensions[i.ContentType]; !ok {
+		return ErrInvalidImageType
+	}
+	if i.Size <= 0 {
+		return ErrEmptyImage
+	}
+	return nil
+}
+
+// NewProductImage describes an image of contentType for the product. Its
+// key is products/{product id}/{image id} with the extension of the type.
+func NewProductImage(productID entity.ID, contentType string, size int64) (*ProductImage, error) {
+	image := &ProductImage{
+		ID:          entity.NewID(),
+		ProductID:   productID,
+		ContentType: contentType,
+		Size:        size,
+	}
+	if err := image.Validate(); err != nil {
+		return nil, err
+	}
+	image.Key = "products/" + productID.String() + "/" + image.ID.String() + imageExtensions[contentType]
+	return image, nil
+}
@@ -0,0 +1,28 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProductImage(t *testing.T) {
+	productID := entity.NewID()
+	image, err := NewProductImage(productID, "image/png", 1024)
+
+	require.NoError(t, err)
+	assert.NotEmpty(t, image.ID)
+	assert.Equal(t, productID, image.ProductID)
+	assert.Equal(t, "products/"+productID.String()+"/"+image.ID.String()+".png", image.Key)
+	assert.Equal(t, int64(1024), image.Size)
+}
+
+func TestNewProductImage_Validates(t *testing.T) {
+	_, err := NewProductImage(entity.NewID(), "application/pdf", 1024)
+	assert.ErrorIs(t, err, ErrInvalidImageType)
+
+	_, err = NewProductImage(entity.NewID(), "image/jpeg", 0)
+	assert.ErrorIs(t, err, ErrEmptyImage)
+}
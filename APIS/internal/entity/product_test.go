@@ -10,13 +10,13 @@ import (
 // TestNewProduct tests the creation of a new product with valid data.
 // It verifies that a product is created successfully with all fields populated correctly.
 func TestNewProduct(t *testing.T) {
-	product, err := NewProduct("Laptop", 999.99)
+	product, err := NewProduct("Laptop", entity.NewMoney(99999, "BRL"))
 
 	assert.Nil(t, err)
 	assert.NotNil(t, product)
 	assert.NotEmpty(t, product.ID)
 	assert.Equal(t, "Laptop", product.Name)
-	assert.Equal(t, 999.99, product.Price)
+	assert.Equal(t, entity.NewMoney(99999, "BRL"), product.Price)
 }
 
 // TestNewProduct_ValidatesFields tests the validation logic in NewProduct.
@@ -26,31 +26,31 @@ func TestNewProduct_ValidatesFields(t *testing.T) {
 	tests := []struct {
 		name        string
 		productName string
-		price       float64
+		price       entity.Money
 		expectError error
 	}{
 		{
 			name:        "valid product",
 			productName: "Mouse",
-			price:       29.99,
+			price:       entity.NewMoney(2999, "BRL"),
 			expectError: nil,
 		},
 		{
 			name:        "empty name",
 			productName: "",
-			price:       50.0,
+			price:       entity.NewMoney(5000, "BRL"),
 			expectError: ErrNameRequired,
 		},
 		{
 			name:        "zero price",
 			productName: "Keyboard",
-			price:       0,
+			price:       entity.NewMoney(0, "BRL"),
 			expectError: ErrInvalidPrice,
 		},
 		{
 			name:        "negative price",
 			productName: "Monitor",
-			price:       -100.0,
+			price:       entity.NewMoney(-10000, "BRL"),
 			expectError: ErrInvalidPrice,
 		},
 	}
@@ -75,8 +75,8 @@ func TestNewProduct_ValidatesFields(t *testing.T) {
 // TestNewProduct_GeneratesUniqueIDs verifies that each product created
 // receives a unique ID, ensuring no ID collisions occur.
 func TestNewProduct_GeneratesUniqueIDs(t *testing.T) {
-	product1, err1 := NewProduct("Product One", 10.0)
-	product2, err2 := NewProduct("Product Two", 20.0)
+	product1, err1 := NewProduct("Product One", entity.NewMoney(1000, "BRL"))
+	product2, err2 := NewProduct("Product Two", entity.NewMoney(2000, "BRL"))
 
 	assert.Nil(t, err1)
 	assert.Nil(t, err2)
@@ -102,7 +102,7 @@ func TestProduct_Validate(t *testing.T) {
 			product: &Product{
 				ID:    entity.NewID(),
 				Name:  "Valid Product",
-				Price: 100.0,
+				Price: entity.NewMoney(10000, "BRL"),
 			},
 			expectError: nil,
 		},
@@ -111,7 +111,7 @@ func TestProduct_Validate(t *testing.T) {
 			product: &Product{
 				ID:    entity.NewID(),
 				Name:  "",
-				Price: 50.0,
+				Price: entity.NewMoney(5000, "BRL"),
 			},
 			expectError: ErrNameRequired,
 		},
@@ -120,7 +120,7 @@ func TestProduct_Validate(t *testing.T) {
 			product: &Product{
 				ID:    entity.NewID(),
 				Name:  name256,
-				Price: 50.0,
+				Price: entity.NewMoney(5000, "BRL"),
 			},
 			expectError: ErrNameTooLong,
 		},
@@ -129,7 +129,7 @@ func TestProduct_Validate(t *testing.T) {
 			product: &Product{
 				ID:    entity.NewID(),
 				Name:  "Product",
-				Price: 0,
+				Price: entity.NewMoney(0, "BRL"),
 			},
 			expectError: ErrInvalidPrice,
 		},
@@ -138,7 +138,7 @@ func TestProduct_Validate(t *testing.T) {
 			product: &Product{
 				ID:    entity.NewID(),
 				Name:  "Product",
-				Price: -10.0,
+				Price: entity.NewMoney(-1000, "BRL"),
 			},
 			expectError: ErrInvalidPrice,
 		},
@@ -160,7 +160,7 @@ func TestProduct_Validate(t *testing.T) {
 // TestNewProduct_WithSpecialCharactersInName verifies that product names
 // can contain special characters without causing validation errors.
 func TestNewProduct_WithSpecialCharactersInName(t *testing.T) {
-	product, err := NewProduct("Product @#$% Special!", 99.99)
+	product, err := NewProduct("Product @#$% Special!", entity.NewMoney(9999, "BRL"))
 
 	assert.Nil(t, err)
 	assert.NotNil(t, product)
@@ -170,28 +170,28 @@ func TestNewProduct_WithSpecialCharactersInName(t *testing.T) {
 // TestNewProduct_WithVerySmallPrice tests that very small prices (like 0.01)
 // are accepted as valid, ensuring the validation allows minimum price values.
 func TestNewProduct_WithVerySmallPrice(t *testing.T) {
-	product, err := NewProduct("Cheap Item", 0.01)
+	product, err := NewProduct("Cheap Item", entity.NewMoney(1, "BRL"))
 
 	assert.Nil(t, err)
 	assert.NotNil(t, product)
-	assert.Equal(t, 0.01, product.Price)
+	assert.Equal(t, "0.01", product.Price.Decimal())
 }
 
 // TestNewProduct_WithVeryLargePrice tests that very large prices are accepted,
 // ensuring there's no upper limit validation that would reject valid high prices.
 func TestNewProduct_WithVeryLargePrice(t *testing.T) {
-	product, err := NewProduct("Expensive Item", 999999.99)
+	product, err := NewProduct("Expensive Item", entity.NewMoney(99999999, "BRL"))
 
 	assert.Nil(t, err)
 	assert.NotNil(t, product)
-	assert.Equal(t, 999999.99, product.Price)
+	assert.Equal(t, "999999.99", product.Price.Decimal())
 }
 
 // TestNewProduct_WithLongName verifies that product names can be very long
 // without causing validation errors or truncation.
 func TestNewProduct_WithLongName(t *testing.T) {
 	longName := "This is a very long product name that contains many characters and should still be valid"
-	product, err := NewProduct(longName, 50.0)
+	product, err := NewProduct(longName, entity.NewMoney(5000, "BRL"))
 
 	assert.Nil(t, err)
 	assert.NotNil(t, product)
@@ -204,7 +204,7 @@ func TestProduct_Validate_WithZeroUUID(t *testing.T) {
 	product := &Product{
 		ID:    entity.ID{},
 		Name:  "Product",
-		Price: 100.0,
+		Price: entity.NewMoney(10000, "BRL"),
 	}
 
 	err := product.Validate()
@@ -219,7 +219,7 @@ func TestNewProduct_WithNameAt255Characters(t *testing.T) {
 		name255 = name255[:i] + "A" + name255[i+1:]
 	}
 
-	product, err := NewProduct(name255, 50.0)
+	product, err := NewProduct(name255, entity.NewMoney(5000, "BRL"))
 
 	assert.Nil(t, err)
 	assert.NotNil(t, product)
@@ -235,28 +235,29 @@ func TestNewProduct_WithNameOver255Characters(t *testing.T) {
 		name256 = name256[:i] + "B" + name256[i+1:]
 	}
 
-	product, err := NewProduct(name256, 50.0)
+	product, err := NewProduct(name256, entity.NewMoney(5000, "BRL"))
 
 	assert.ErrorIs(t, err, ErrNameTooLong)
 	assert.Nil(t, product)
 }
 
-// TestNewProduct_WithMaxDecimalPrice tests that prices with 2 decimal places
-// are handled correctly, matching the DECIMAL(10,2) database constraint.
+// TestNewProduct_WithMaxDecimalPrice tests that the largest price of the
+// old DECIMAL(10,2) column is still kept exactly.
 func TestNewProduct_WithMaxDecimalPrice(t *testing.T) {
-	product, err := NewProduct("Max Price Product", 99999999.99)
+	product, err := NewProduct("Max Price Product", entity.NewMoney(9999999999, "BRL"))
 
 	assert.Nil(t, err)
 	assert.NotNil(t, product)
-	assert.Equal(t, 99999999.99, product.Price)
+	assert.Equal(t, "99999999.99", product.Price.Decimal())
 }
 
-// TestNewProduct_WithThreeDecimalPlaces tests that prices with more than
-// 2 decimal places are accepted by the application (database will round).
-func TestNewProduct_WithThreeDecimalPlaces(t *testing.T) {
-	product, err := NewProduct("Product", 19.999)
+// TestNewProduct_WithInvalidCurrency tests that the currency of the price
+// must be an ISO 4217 code.
+func TestNewProduct_WithInvalidCurrency(t *testing.T) {
+	for _, currency := range []string{"", "usd", "REAL"} {
+		product, err := NewProduct("Product", entity.NewMoney(1999, currency))
 
-	assert.Nil(t, err)
-	assert.NotNil(t, product)
-	assert.Equal(t, 19.999, product.Price)
+		assert.ErrorIs(t, err, ErrInvalidCurrency, currency)
+		assert.Nil(t, product)
+	}
 }
@@ -0,0 +1,123 @@
+package entity
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
+)
+
+// RefreshToken is the server side record of an opaque refresh token.
+// Only the SHA-256 hash of the token is stored, the plain value is
+// returned to the client once and never persisted.
+type RefreshToken struct {
+	ID         entity.ID  `json:"id" gorm:"column:rtk_id;type:uuid;primarykey"`
+	UserID     entity.ID  `json:"user_id" gorm:"column:rtk_usr_id;type:uuid;index"`
+	TokenHash  string     `json:"-" gorm:"column:rtk_hash;size:64;uniqueIndex"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"column:rtk_expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at" gorm:"column:rtk_revoked_at"`
+	ReplacedBy *entity.ID `json:"replaced_by" gorm:"column:rtk_replaced_by;type:uuid"`
+	entity.BaseModel
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+func (t *RefreshToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+func (t *RefreshToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+// NewRefreshToken generates a random refresh token for the user and
+// returns the entity to be stored together with the plain token.
+func NewRefreshToken(userID entity.ID, ttl time.Duration) (*RefreshToken, string, error) {
+	if userID == (entity.ID{}) {
+		return nil, "", ErrIDRequired
+	}
+	plain, err := newOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+	token := &RefreshToken{
+		ID:        entity.NewID(),
+		UserID:    userID,
+		TokenHash: HashToken(plain),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	return token, plain, nil
+}
+
+// RevokedToken keeps the jti of access tokens that were revoked before
+// they expired, so the verifier can reject them.
+type RevokedToken struct {
+	JTI       string    `json:"jti" gorm:"column:rvk_jti;size:64;primarykey"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"column:rvk_expires_at;index"`
+	entity.BaseModel
+}
+
+func (RevokedToken) TableName() string {
+	return "revoked_tokens"
+}
+
+// PasswordResetToken is a single use token sent by email to recover an
+// account. Like refresh tokens, only its hash is stored.
+type PasswordResetToken struct {
+	ID        entity.ID  `json:"id" gorm:"column:prt_id;type:uuid;primarykey"`
+	UserID    entity.ID  `json:"user_id" gorm:"column:prt_usr_id;type:uuid;index"`
+	TokenHash string     `json:"-" gorm:"column:prt_hash;size:64;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"column:prt_expires_at"`
+	UsedAt    *time.Time `json:"used_at" gorm:"column:prt_used_at"`
+	entity.BaseModel
+}
+
+func (PasswordResetToken) TableName() string {
+	return "password_reset_tokens"
+}
+
+func (t *PasswordResetToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+func (t *PasswordResetToken) IsUsed() bool {
+	return t.UsedAt != nil
+}
+
+// NewPasswordResetToken generates a random reset token for the user and
+// returns the entity to be stored together with the plain token.
+func NewPasswordResetToken(userID entity.ID, ttl time.Duration) (*PasswordResetToken, string, error) {
+	if userID == (entity.ID{}) {
+		return nil, "", ErrIDRequired
+	}
+	plain, err := newOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+	token := &PasswordResetToken{
+		ID:        entity.NewID(),
+		UserID:    userID,
+		TokenHash: HashToken(plain),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	return token, plain, nil
+}
+
+// HashToken returns the hex encoded SHA-256 of an opaque token.
+func HashToken(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+func newOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
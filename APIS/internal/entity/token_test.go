@@ -0,0 +1,88 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewRefreshToken verifies that a refresh token is created for the user,
+// that only the hash of the plain token is kept and that it expires after the ttl.
+func TestNewRefreshToken(t *testing.T) {
+	userID := entity.NewID()
+	token, plain, err := NewRefreshToken(userID, time.Hour)
+
+	assert.Nil(t, err)
+	assert.NotNil(t, token)
+	assert.NotEmpty(t, plain)
+	assert.Equal(t, userID, token.UserID)
+	assert.NotEqual(t, plain, token.TokenHash, "Plain token should not be stored")
+	assert.Equal(t, HashToken(plain), token.TokenHash)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), token.ExpiresAt, time.Second)
+	assert.False(t, token.IsRevoked())
+	assert.False(t, token.IsExpired(time.Now()))
+}
+
+// TestNewRefreshToken_RequiresUser verifies that a token can't be issued without a user.
+func TestNewRefreshToken_RequiresUser(t *testing.T) {
+	token, plain, err := NewRefreshToken(entity.ID{}, time.Hour)
+
+	assert.ErrorIs(t, err, ErrIDRequired)
+	assert.Nil(t, token)
+	assert.Empty(t, plain)
+}
+
+// TestNewRefreshToken_GeneratesUniqueTokens verifies that two tokens
+// for the same user never share the plain value.
+func TestNewRefreshToken_GeneratesUniqueTokens(t *testing.T) {
+	userID := entity.NewID()
+	_, plain1, err1 := NewRefreshToken(userID, time.Hour)
+	_, plain2, err2 := NewRefreshToken(userID, time.Hour)
+
+	assert.Nil(t, err1)
+	assert.Nil(t, err2)
+	assert.NotEqual(t, plain1, plain2, "Each refresh token should be unique")
+}
+
+// TestRefreshToken_IsExpired tests the expiration check at the boundaries.
+func TestRefreshToken_IsExpired(t *testing.T) {
+	now := time.Now()
+	token := &RefreshToken{ExpiresAt: now}
+
+	assert.True(t, token.IsExpired(now))
+	assert.True(t, token.IsExpired(now.Add(time.Second)))
+	assert.False(t, token.IsExpired(now.Add(-time.Second)))
+}
+
+// TestHashToken verifies the hash is deterministic and hex encoded.
+func TestHashToken(t *testing.T) {
+	assert.Equal(t, HashToken("token"), HashToken("token"))
+	assert.NotEqual(t, HashToken("token"), HashToken("other"))
+	assert.Len(t, HashToken("token"), 64)
+}
+
+// TestNewPasswordResetToken verifies that a reset token is created unused,
+// that only the hash is kept and that it expires after the ttl.
+func TestNewPasswordResetToken(t *testing.T) {
+	userID := entity.NewID()
+	token, plain, err := NewPasswordResetToken(userID, 15*time.Minute)
+
+	assert.Nil(t, err)
+	assert.NotNil(t, token)
+	assert.Equal(t, userID, token.UserID)
+	assert.Equal(t, HashToken(plain), token.TokenHash)
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), token.ExpiresAt, time.Second)
+	assert.False(t, token.IsUsed())
+	assert.False(t, token.IsExpired(time.Now()))
+	assert.True(t, token.IsExpired(time.Now().Add(16*time.Minute)))
+}
+
+// TestNewPasswordResetToken_RequiresUser verifies that a token can't be issued without a user.
+func TestNewPasswordResetToken_RequiresUser(t *testing.T) {
+	token, _, err := NewPasswordResetToken(entity.ID{}, time.Hour)
+
+	assert.ErrorIs(t, err, ErrIDRequired)
+	assert.Nil(t, token)
+}
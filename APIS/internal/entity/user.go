@@ -5,14 +5,29 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
 type User struct {
 	ID       entity.ID `json:"id" gorm:"column:usr_id;type:uuid;primarykey"`
 	Name     string    `json:"name" gorm:"column:usr_name;size:255"`
 	Email    string    `json:"email" gorm:"column:usr_email;size:255;unique"`
 	Password string    `json:"-" gorm:"column:usr_password;size:255"`
+	Role     string    `json:"role" gorm:"column:usr_role;size:20;default:viewer"`
 	entity.BaseModel
 }
 
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleAdmin, RoleEditor, RoleViewer:
+		return true
+	}
+	return false
+}
+
 func (User) TableName() string {
 	return "users"
 }
@@ -42,6 +57,17 @@ func (u *User) Validate() error {
 	if len(u.Password) > 255 {
 		return ErrPasswordTooLong
 	}
+	if !IsValidRole(u.Role) {
+		return ErrInvalidRole
+	}
+	return nil
+}
+
+func (u *User) SetRole(role string) error {
+	if !IsValidRole(role) {
+		return ErrInvalidRole
+	}
+	u.Role = role
 	return nil
 }
 
@@ -50,16 +76,18 @@ func (u *User) ValidatePassword(password string) bool {
 	return err == nil
 }
 
-func NewUser(name, email, password string) (*User, error) {
-	// Validate the password before hashing
-	if password == "" {
-		return nil, ErrPasswordRequired
-	}
-	if len(password) > 255 {
-		return nil, ErrPasswordTooLong
+// ChangePassword hashes and replaces the user password.
+func (u *User) ChangePassword(password string) error {
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
 	}
+	u.Password = hash
+	return nil
+}
 
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+func NewUser(name, email, password string) (*User, error) {
+	hash, err := hashPassword(password)
 	if err != nil {
 		return nil, err
 	}
@@ -67,10 +95,27 @@ func NewUser(name, email, password string) (*User, error) {
 		ID:       entity.NewID(),
 		Name:     name,
 		Email:    email,
-		Password: string(hash),
+		Password: hash,
+		Role:     RoleViewer,
 	}
 	if err := user.Validate(); err != nil {
 		return nil, err
 	}
 	return user, nil
 }
+
+func hashPassword(password string) (string, error) {
+	// Validate the password before hashing
+	if password == "" {
+		return "", ErrPasswordRequired
+	}
+	if len(password) > 255 {
+		return "", ErrPasswordTooLong
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
@@ -217,9 +217,31 @@ func TestUser_Validate(t *testing.T) {
 				Name:     "Valid User",
 				Email:    "valid@example.com",
 				Password: "hashedpassword",
+				Role:     RoleViewer,
 			},
 			expectError: nil,
 		},
+		{
+			name: "invalid role",
+			user: &User{
+				ID:       entity.NewID(),
+				Name:     "User Name",
+				Email:    "user@example.com",
+				Password: "hashedpassword",
+				Role:     "owner",
+			},
+			expectError: ErrInvalidRole,
+		},
+		{
+			name: "empty role",
+			user: &User{
+				ID:       entity.NewID(),
+				Name:     "User Name",
+				Email:    "user@example.com",
+				Password: "hashedpassword",
+			},
+			expectError: ErrInvalidRole,
+		},
 		{
 			name: "empty name",
 			user: &User{
@@ -227,6 +249,7 @@ func TestUser_Validate(t *testing.T) {
 				Name:     "",
 				Email:    "user@example.com",
 				Password: "hashedpassword",
+				Role:     RoleViewer,
 			},
 			expectError: ErrNameRequired,
 		},
@@ -237,6 +260,7 @@ func TestUser_Validate(t *testing.T) {
 				Name:     name256,
 				Email:    "user@example.com",
 				Password: "hashedpassword",
+				Role:     RoleViewer,
 			},
 			expectError: ErrNameTooLong,
 		},
@@ -247,6 +271,7 @@ func TestUser_Validate(t *testing.T) {
 				Name:     "User Name",
 				Email:    "",
 				Password: "hashedpassword",
+				Role:     RoleViewer,
 			},
 			expectError: ErrEmailRequired,
 		},
@@ -257,6 +282,7 @@ func TestUser_Validate(t *testing.T) {
 				Name:     "User Name",
 				Email:    email256,
 				Password: "hashedpassword",
+				Role:     RoleViewer,
 			},
 			expectError: ErrEmailTooLong,
 		},
@@ -267,6 +293,7 @@ func TestUser_Validate(t *testing.T) {
 				Name:     "User Name",
 				Email:    "user@example.com",
 				Password: "",
+				Role:     RoleViewer,
 			},
 			expectError: ErrPasswordRequired,
 		},
@@ -277,6 +304,7 @@ func TestUser_Validate(t *testing.T) {
 				Name:     "User Name",
 				Email:    "user@example.com",
 				Password: password256,
+				Role:     RoleViewer,
 			},
 			expectError: ErrPasswordTooLong,
 		},
@@ -326,3 +354,50 @@ func TestNewUser_WithEmailAt255Characters(t *testing.T) {
 	assert.Equal(t, 255, len(user.Email))
 	assert.Equal(t, email255, user.Email)
 }
+
+// TestNewUser_DefaultRole verifies that new users are created as viewers,
+// so signing up never grants write access to the catalog.
+func TestNewUser_DefaultRole(t *testing.T) {
+	user, err := NewUser("John Doe", "john@example.com", "password123")
+
+	assert.Nil(t, err)
+	assert.Equal(t, RoleViewer, user.Role)
+}
+
+// TestUser_SetRole tests that only known roles can be assigned.
+func TestUser_SetRole(t *testing.T) {
+	user, err := NewUser("John Doe", "john@example.com", "password123")
+	assert.Nil(t, err)
+
+	for _, role := range []string{RoleAdmin, RoleEditor, RoleViewer} {
+		assert.Nil(t, user.SetRole(role))
+		assert.Equal(t, role, user.Role)
+	}
+
+	assert.ErrorIs(t, user.SetRole("owner"), ErrInvalidRole)
+	assert.Equal(t, RoleViewer, user.Role, "Role should not change on error")
+}
+
+// TestUser_ChangePassword verifies that the new password is hashed and
+// replaces the old one.
+func TestUser_ChangePassword(t *testing.T) {
+	user, err := NewUser("John Doe", "john@example.com", "oldPassword")
+	assert.Nil(t, err)
+
+	err = user.ChangePassword("newPassword")
+	assert.Nil(t, err)
+	assert.NotEqual(t, "newPassword", user.Password, "Password should be hashed, not stored in plain text")
+	assert.True(t, user.ValidatePassword("newPassword"))
+	assert.False(t, user.ValidatePassword("oldPassword"))
+}
+
+// TestUser_ChangePassword_Invalid verifies that invalid passwords are
+// rejected and the current one is kept.
+func TestUser_ChangePassword_Invalid(t *testing.T) {
+	user, err := NewUser("John Doe", "john@example.com", "oldPassword")
+	assert.Nil(t, err)
+
+	assert.ErrorIs(t, user.ChangePassword(""), ErrPasswordRequired)
+	assert.ErrorIs(t, user.ChangePassword(string(make([]byte, 256))), ErrPasswordTooLong)
+	assert.True(t, user.ValidatePassword("oldPassword"))
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+)
+
+type Category struct {
+	repository
+}
+
+// NewCategoryDB returns the repository. Every query is bounded by queryTimeout,
+// zero leaves only the deadline of the caller's context.
+func NewCategoryDB(db *gorm.DB, queryTimeout time.Duration) *Category {
+	return &Category{repository{db: db, timeout: queryTimeout}}
+}
+
+func (c *Category) Create(ctx context.Context, category *entity.Category) error {
+	ctx, cancel := c.queryContext(ctx, "Category.Create")
+	defer cancel()
+	err := gorm.G[entity.Category](c.db).Create(ctx, category)
+	return translateError(err, entity.ErrCategoryNotFound, entity.ErrCategoryDuplicate)
+}
+
+func (c *Category) FindByID(ctx context.Context, id string) (*entity.Category, error) {
+	ctx, cancel := c.queryContext(ctx, "Category.FindByID")
+	defer cancel()
+
+	categoryID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	category, err := gorm.G[entity.Category](c.db).Where("cat_id = ?", categoryID).First(ctx)
+	return &category, translateError(err, entity.ErrCategoryNotFound, entity.ErrCategoryDuplicate)
+}
+
+func (c *Category) FindAll(ctx context.Context, page, limit int, sort string) ([]entity.Category, error) {
+	ctx, cancel := c.queryContext(ctx, "Category.FindAll")
+	defer cancel()
+	offset := (page - 1) * limit
+	items, err := gorm.G[entity.Category](c.db).
+		Order(sort).
+		Limit(limit).
+		Offset(offset).
+		Find(ctx)
+	return items, translateError(err, entity.ErrCategoryNotFound, entity.ErrCategoryDuplicate)
+}
+
+func (c *Category) Count(ctx context.Context) (int64, error) {
+	ctx, cancel := c.queryContext(ctx, "Category.Count")
+	defer cancel()
+
+	count, err := gorm.G[entity.Category](c.db).Count(ctx, "cat_id")
+	return count, translateError(err, entity.ErrCategoryNotFound, entity.ErrCategoryDuplicate)
+}
+
+// Update renames the category.
+func (c *Category) Update(ctx context.Context, category *entity.Category) error {
+	ctx, cancel := c.queryContext(ctx, "Category.Update")
+	defer cancel()
+	rows, err := gorm.G[entity.Category](c.db).Where("cat_id = ?", category.ID).Updates(ctx, *category)
+	if err == nil && rows == 0 {
+		err = gorm.ErrRecordNotFound
+	}
+	return translateError(err, entity.ErrCategoryNotFound, entity.ErrCategoryDuplicate)
+}
+
+// Delete soft deletes the category and untags its products, trashed ones
+// included, auditing each of them like SetCategories. Its name stays
+// taken, like the email of a deleted user.
+func (c *Category) Delete(ctx context.Context, id string) error {
+	ctx, cancel := c.queryContext(ctx, "Category.Delete")
+	defer cancel()
+
+	categoryID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+
+	err = c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		tagged, err := gorm.G[entity.Product](tx).
+			Scopes(unscoped).
+			Preload("Categories", func(db gorm.PreloadBuilder) error {
+				db.Where("deleted_at IS NULL").Order("cat_name")
+				return nil
+			}).
+			Where("prd_id IN (SELECT pct_prd_id FROM product_categories WHERE pct_cat_id = ?)", categoryID).
+			Find(ctx)
+		if err != nil {
+			return err
+		}
+		rows, err := gorm.G[entity.Category](tx).Where("cat_id = ?", categoryID).Delete(ctx)
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Exec("DELETE FROM product_categories WHERE pct_cat_id = ?", categoryID).Error; err != nil {
+			return err
+		}
+		if len(tagged) == 0 {
+			return nil
+		}
+		audits := make([]entity.ProductAudit, 0, len(tagged))
+		for _, before := range tagged {
+			product := before
+			product.Categories = slices.DeleteFunc(slices.Clone(before.Categories), func(category entity.Category) bool {
+				return category.ID == categoryID
+			})
+			audits = append(audits, *entity.NewProductAudit(ctx, entity.AuditCategories, before.ID, &before, &product))
+		}
+		return createAudits(ctx, tx, audits...)
+	})
+	return translateError(err, entity.ErrCategoryNotFound, entity.ErrCategoryDuplicate)
+}
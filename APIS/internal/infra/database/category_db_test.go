@@ -0,0 +1,158 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	pkgEntity "github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCategory_CRUD(t *testing.T) {
+	db := setupProductTestDB(t)
+	categoryDB := NewCategoryDB(db, 0)
+	ctx := context.Background()
+
+	category, err := entity.NewCategory("Electronics")
+	require.NoError(t, err)
+	require.NoError(t, categoryDB.Create(ctx, category))
+
+	duplicate, err := entity.NewCategory("Electronics")
+	require.NoError(t, err)
+	assert.ErrorIs(t, categoryDB.Create(ctx, duplicate), entity.ErrCategoryDuplicate)
+
+	category.Name = "Gadgets"
+	require.NoError(t, categoryDB.Update(ctx, category))
+	found, err := categoryDB.FindByID(ctx, category.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "Gadgets", found.Name)
+
+	other, err := entity.NewCategory("Books")
+	require.NoError(t, err)
+	require.NoError(t, categoryDB.Create(ctx, other))
+	items, err := categoryDB.FindAll(ctx, 1, 10, "cat_name asc")
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, "Books", items[0].Name)
+	count, err := categoryDB.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	require.NoError(t, categoryDB.Delete(ctx, category.ID.String()))
+	_, err = categoryDB.FindByID(ctx, category.ID.String())
+	assert.ErrorIs(t, err, entity.ErrCategoryNotFound)
+	assert.ErrorIs(t, categoryDB.Delete(ctx, category.ID.String()), entity.ErrCategoryNotFound)
+	assert.ErrorIs(t, categoryDB.Update(ctx, category), entity.ErrCategoryNotFound)
+}
+
+func TestProduct_SetCategories(t *testing.T) {
+	t.Run("should replace the categories of the product", func(t *testing.T) {
+		db := setupProductTestDB(t)
+		productDB := NewProductDB(db, 0)
+		categoryDB := NewCategoryDB(db, 0)
+		ctx := context.Background()
+
+		product, _ := entity.NewProduct("Laptop", pkgEntity.NewMoney(99999, "BRL"))
+		require.NoError(t, productDB.Create(ctx, product))
+		var categories []*entity.Category
+		for _, name := range []string{"Electronics", "Computers", "Office"} {
+			category, err := entity.NewCategory(name)
+			require.NoError(t, err)
+			require.NoError(t, categoryDB.Create(ctx, category))
+			categories = append(categories, category)
+		}
+
+		ids := []string{categories[0].ID.String(), categories[1].ID.String(), categories[0].ID.String()}
+		require.NoError(t, productDB.SetCategories(ctx, product.ID.String(), ids))
+		found, err := productDB.FindByID(ctx, product.ID.String())
+		require.NoError(t, err)
+		require.Len(t, found.Categories, 2)
+		assert.Equal(t, "Computers", found.Categories[0].Name, "Categories are sorted by name")
+		assert.Equal(t, "Electronics", found.Categories[1].Name)
+		assert.Equal(t, product.Version.Int64, found.Version.Int64, "Tagging doesn't bump the version")
+
+		require.NoError(t, productDB.SetCategories(ctx, product.ID.String(), []string{categories[2].ID.String()}))
+		found, err = productDB.FindByID(ctx, product.ID.String())
+		require.NoError(t, err)
+		require.Len(t, found.Categories, 1)
+		assert.Equal(t, "Office", found.Categories[0].Name)
+
+		// Saving the loaded product must not touch its categories
+		found.Price = pkgEntity.NewMoney(89999, "BRL")
+		require.NoError(t, productDB.Update(ctx, found))
+
+		require.NoError(t, productDB.SetCategories(ctx, product.ID.String(), nil))
+		found, err = productDB.FindByID(ctx, product.ID.String())
+		require.NoError(t, err)
+		assert.Empty(t, found.Categories)
+		var count int64
+		db.Model(&entity.Category{}).Count(&count)
+		assert.Equal(t, int64(3), count)
+	})
+
+	t.Run("should reject unknown categories and products", func(t *testing.T) {
+		db := setupProductTestDB(t)
+		productDB := NewProductDB(db, 0)
+		categoryDB := NewCategoryDB(db, 0)
+		ctx := context.Background()
+
+		product, _ := entity.NewProduct("Laptop", pkgEntity.NewMoney(99999, "BRL"))
+		require.NoError(t, productDB.Create(ctx, product))
+		category, _ := entity.NewCategory("Electronics")
+		require.NoError(t, categoryDB.Create(ctx, category))
+		require.NoError(t, productDB.SetCategories(ctx, product.ID.String(), []string{category.ID.String()}))
+
+		err := productDB.SetCategories(ctx, product.ID.String(),
+			[]string{category.ID.String(), "019ab24a-dc97-72a4-9056-cc09f4c13bef"})
+		assert.ErrorIs(t, err, entity.ErrUnknownCategory)
+		err = productDB.SetCategories(ctx, product.ID.String(), []string{"invalid"})
+		assert.ErrorIs(t, err, entity.ErrIDRequired)
+		err = productDB.SetCategories(ctx, "019ab24a-dc97-72a4-9056-cc09f4c13bef", []string{category.ID.String()})
+		assert.ErrorIs(t, err, entity.ErrProductNotFound)
+
+		found, err := productDB.FindByID(ctx, product.ID.String())
+		require.NoError(t, err)
+		assert.Len(t, found.Categories, 1, "A rejected change must keep the categories")
+	})
+
+	t.Run("should untag the products of a deleted category", func(t *testing.T) {
+		db := setupProductTestDB(t)
+		productDB := NewProductDB(db, 0)
+		categoryDB := NewCategoryDB(db, 0)
+		ctx := context.Background()
+
+		product, _ := entity.NewProduct("Laptop", pkgEntity.NewMoney(99999, "BRL"))
+		require.NoError(t, productDB.Create(ctx, product))
+		trashed, _ := entity.NewProduct("Mouse", pkgEntity.NewMoney(2999, "BRL"))
+		require.NoError(t, productDB.Create(ctx, trashed))
+		category, _ := entity.NewCategory("Electronics")
+		require.NoError(t, categoryDB.Create(ctx, category))
+		other, _ := entity.NewCategory("Office")
+		require.NoError(t, categoryDB.Create(ctx, other))
+		require.NoError(t, productDB.SetCategories(ctx, product.ID.String(), []string{category.ID.String(), other.ID.String()}))
+		require.NoError(t, productDB.SetCategories(ctx, trashed.ID.String(), []string{category.ID.String()}))
+		require.NoError(t, productDB.Delete(ctx, trashed.ID.String(), trashed.Version.Int64))
+
+		require.NoError(t, categoryDB.Delete(ctx, category.ID.String()))
+
+		count, err := productDB.Count(ctx, ProductFilter{Category: category.ID})
+		require.NoError(t, err)
+		assert.Zero(t, count)
+
+		// Every untagged product is audited, the trashed ones too
+		history, err := productDB.History(ctx, product.ID.String(), 1, 10, "aud_id asc")
+		require.NoError(t, err)
+		last := history[len(history)-1]
+		assert.Equal(t, entity.AuditCategories, last.Action)
+		assert.Equal(t, []string{category.ID.String(), other.ID.String()}, last.Before.Categories)
+		assert.Equal(t, []string{other.ID.String()}, last.After.Categories)
+		history, err = productDB.History(ctx, trashed.ID.String(), 1, 10, "aud_id asc")
+		require.NoError(t, err)
+		last = history[len(history)-1]
+		assert.Equal(t, entity.AuditCategories, last.Action)
+		assert.Equal(t, []string{category.ID.String()}, last.Before.Categories)
+		assert.Empty(t, last.After.Categories)
+	})
+}
@@ -0,0 +1,40 @@
+// Package dbtest opens the databases of the tests.
+package dbtest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+)
+
+// ProductTables are the tables of database.Product.
+var ProductTables = []any{&entity.Product{}, &entity.ProductAudit{}, &entity.Category{}, &entity.ProductImage{}}
+
+// UserTables are the tables of database.User and database.Token.
+var UserTables = []any{&entity.User{}, &entity.RefreshToken{}, &entity.RevokedToken{}, &entity.PasswordResetToken{}}
+
+// Open returns an empty SQLite database with the tables of models, created
+// by AutoMigrate, and closes it when the test ends. It is a file, an
+// in-memory one would be a different database on every connection of the
+// pool.
+func Open(t testing.TB, models ...any) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+		TranslateError:                           true,
+	})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if len(models) > 0 {
+		require.NoError(t, db.AutoMigrate(models...))
+	}
+	return db
+}
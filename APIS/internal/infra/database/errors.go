@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+
+	"gorm.io/gorm"
+
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	pkgEntity "github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
+)
+
+// translateError turns the GORM and driver errors into domain errors.
+// notFound and conflict are the sentinels of the repository, any kind
+// from the entity package works too. Unknown errors are returned as is.
+func translateError(err error, notFound, conflict error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return entity.Wrap(notFound, err)
+	case errors.Is(err, gorm.ErrDuplicatedKey), errors.Is(err, gorm.ErrForeignKeyViolated):
+		return entity.Wrap(conflict, err)
+	case isUnavailable(err):
+		return entity.Wrap(entity.ErrUnavailable, err)
+	}
+	return err
+}
+
+func isUnavailable(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.As(err, &netErr)
+}
+
+// parseID validates an ID received by a repository method.
+func parseID(id string) (pkgEntity.ID, error) {
+	parsed, err := pkgEntity.ParseID(id)
+	if err != nil {
+		return parsed, entity.Wrap(entity.ErrIDRequired, err)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+)
+
+func TestTranslateError(t *testing.T) {
+	other := errors.New("syntax error")
+
+	tests := []struct {
+		name string
+		err  error
+		kind error
+		want error
+	}{
+		{name: "not found", err: gorm.ErrRecordNotFound, kind: entity.ErrNotFound, want: entity.ErrUserNotFound},
+		{name: "duplicated key", err: gorm.ErrDuplicatedKey, kind: entity.ErrConflict, want: entity.ErrEmailTaken},
+		{name: "timeout", err: context.DeadlineExceeded, kind: entity.ErrUnavailable, want: entity.ErrUnavailable},
+		{name: "unknown", err: other, want: other},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := translateError(tt.err, entity.ErrUserNotFound, entity.ErrEmailTaken)
+			assert.ErrorIs(t, err, tt.want)
+			assert.ErrorIs(t, err, tt.err, "The cause must be kept")
+			if tt.kind != nil {
+				assert.ErrorIs(t, err, tt.kind)
+			}
+		})
+	}
+
+	assert.NoError(t, translateError(nil, entity.ErrUserNotFound, entity.ErrEmailTaken))
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+)
+
+type Idempotency struct {
+	repository
+}
+
+// NewIdempotencyDB returns the repository. Every query is bounded by
+// queryTimeout, zero leaves only the deadline of the caller's context.
+func NewIdempotencyDB(db *gorm.DB, queryTimeout time.Duration) *Idempotency {
+	return &Idempotency{repository{db: db, timeout: queryTimeout}}
+}
+
+// Reserve stores key unless its scope already has a live key with the same
+// value. It returns nil once key is reserved, or the key found otherwise.
+// An expired key is replaced. Two requests racing for the same key can't
+// both win, the loser gets entity.ErrIdempotencyKeyInUse.
+func (i *Idempotency) Reserve(ctx context.Context, key *entity.IdempotencyKey) (*entity.IdempotencyKey, error) {
+	ctx, cancel := i.queryContext(ctx, "Idempotency.Reserve")
+	defer cancel()
+
+	var existing *entity.IdempotencyKey
+	err := i.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		found, err := gorm.G[entity.IdempotencyKey](tx).
+			Where("idk_scope = ? AND idk_key = ?", key.Scope, key.Key).
+			First(ctx)
+		switch {
+		case err == nil && !found.IsExpired(time.Now()):
+			existing = &found
+			return nil
+		case err == nil:
+			if _, err := gorm.G[entity.IdempotencyKey](tx).Where("idk_id = ?", found.ID).Delete(ctx); err != nil {
+				return err
+			}
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+		return gorm.G[entity.IdempotencyKey](tx).Create(ctx, key)
+	})
+	err = translateError(err, entity.ErrNotFound, entity.ErrIdempotencyKeyInUse)
+	if err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// Complete stores the response of the request that reserved key.
+func (i *Idempotency) Complete(ctx context.Context, key *entity.IdempotencyKey) error {
+	ctx, cancel := i.queryContext(ctx, "Idempotency.Complete")
+	defer cancel()
+	_, err := gorm.G[entity.IdempotencyKey](i.db).
+		Where("idk_id = ?", key.ID).
+		Select("Status", "Header", "Body").
+		Updates(ctx, *key)
+	return translateError(err, entity.ErrNotFound, entity.ErrConflict)
+}
+
+// Release drops a reserved key, so a request that failed can be retried
+// with it.
+func (i *Idempotency) Release(ctx context.Context, key *entity.IdempotencyKey) error {
+	ctx, cancel := i.queryContext(ctx, "Idempotency.Release")
+	defer cancel()
+	_, err := gorm.G[entity.IdempotencyKey](i.db).Where("idk_id = ?", key.ID).Delete(ctx)
+	return translateError(err, entity.ErrNotFound, entity.ErrConflict)
+}
+
+// Purge deletes the keys that expired before expiredBefore and returns how
+// many were removed.
+func (i *Idempotency) Purge(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	ctx, cancel := i.queryContext(ctx, "Idempotency.Purge")
+	defer cancel()
+	rows, err := gorm.G[entity.IdempotencyKey](i.db).Where("idk_expires_at < ?", expiredBefore).Delete(ctx)
+	return int64(rows), translateError(err, entity.ErrNotFound, entity.ErrConflict)
+}
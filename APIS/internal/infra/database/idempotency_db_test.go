@@ -0,0 +1,107 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/database/dbtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupIdempotencyTestDB(t *testing.T) *gorm.DB {
+	return dbtest.Open(t, &entity.IdempotencyKey{})
+}
+
+func newTestIdempotencyKey(t *testing.T, key, requestHash string, ttl time.Duration) *entity.IdempotencyKey {
+	t.Helper()
+	idempotencyKey, err := entity.NewIdempotencyKey("sub:1 POST /products", key, requestHash, ttl)
+	require.NoError(t, err)
+	return idempotencyKey
+}
+
+func TestIdempotency_ReserveAndComplete(t *testing.T) {
+	idempotencyDB := NewIdempotencyDB(setupIdempotencyTestDB(t), 0)
+	ctx := context.Background()
+
+	key := newTestIdempotencyKey(t, "retry-1", "hash", time.Hour)
+	existing, err := idempotencyDB.Reserve(ctx, key)
+	require.NoError(t, err)
+	assert.Nil(t, existing, "The first request reserves the key")
+
+	existing, err = idempotencyDB.Reserve(ctx, newTestIdempotencyKey(t, "retry-1", "hash", time.Hour))
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.Equal(t, key.ID, existing.ID)
+	assert.False(t, existing.IsCompleted(), "The first request is still running")
+
+	key.Status = 201
+	key.Header = map[string]string{"Content-Type": "application/json"}
+	key.Body = []byte(`{"id":"1"}`)
+	require.NoError(t, idempotencyDB.Complete(ctx, key))
+	existing, err = idempotencyDB.Reserve(ctx, newTestIdempotencyKey(t, "retry-1", "other", time.Hour))
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.Equal(t, 201, existing.Status)
+	assert.Equal(t, key.Header, existing.Header)
+	assert.Equal(t, key.Body, existing.Body)
+	assert.Equal(t, "hash", existing.RequestHash, "The stored request is kept")
+
+	other, err := entity.NewIdempotencyKey("sub:2 POST /products", "retry-1", "hash", time.Hour)
+	require.NoError(t, err)
+	existing, err = idempotencyDB.Reserve(ctx, other)
+	require.NoError(t, err)
+	assert.Nil(t, existing, "Every scope has its own keys")
+}
+
+func TestIdempotency_ReserveReplacesExpiredKeys(t *testing.T) {
+	idempotencyDB := NewIdempotencyDB(setupIdempotencyTestDB(t), 0)
+	ctx := context.Background()
+
+	_, err := idempotencyDB.Reserve(ctx, newTestIdempotencyKey(t, "retry-1", "hash", -time.Minute))
+	require.NoError(t, err)
+	key := newTestIdempotencyKey(t, "retry-1", "other", time.Hour)
+	existing, err := idempotencyDB.Reserve(ctx, key)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	existing, err = idempotencyDB.Reserve(ctx, newTestIdempotencyKey(t, "retry-1", "other", time.Hour))
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.Equal(t, key.ID, existing.ID)
+}
+
+func TestIdempotency_Release(t *testing.T) {
+	idempotencyDB := NewIdempotencyDB(setupIdempotencyTestDB(t), 0)
+	ctx := context.Background()
+
+	key := newTestIdempotencyKey(t, "retry-1", "hash", time.Hour)
+	_, err := idempotencyDB.Reserve(ctx, key)
+	require.NoError(t, err)
+	require.NoError(t, idempotencyDB.Release(ctx, key))
+
+	existing, err := idempotencyDB.Reserve(ctx, newTestIdempotencyKey(t, "retry-1", "hash", time.Hour))
+	require.NoError(t, err)
+	assert.Nil(t, existing, "A released key can be used again")
+}
+
+func TestIdempotency_Purge(t *testing.T) {
+	db := setupIdempotencyTestDB(t)
+	idempotencyDB := NewIdempotencyDB(db, 0)
+	ctx := context.Background()
+
+	_, err := idempotencyDB.Reserve(ctx, newTestIdempotencyKey(t, "expired", "hash", -time.Minute))
+	require.NoError(t, err)
+	_, err = idempotencyDB.Reserve(ctx, newTestIdempotencyKey(t, "live", "hash", time.Hour))
+	require.NoError(t, err)
+
+	purged, err := idempotencyDB.Purge(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	var count int64
+	db.Model(&entity.IdempotencyKey{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
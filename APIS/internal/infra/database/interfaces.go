@@ -1,17 +1,67 @@
 package database
 
-import "github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+import (
+	"context"
+	"time"
+
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	pkgEntity "github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
+)
 
 type UserInterface interface {
-	Create(user *entity.User) error
-	FindByEmail(email string) (*entity.User, error)
+	Create(ctx context.Context, user *entity.User) error
+	FindByEmail(ctx context.Context, email string) (*entity.User, error)
+	FindByID(ctx context.Context, id string) (*entity.User, error)
+	Update(ctx context.Context, user *entity.User) error
+	Delete(ctx context.Context, id string) error
+	FindAll(ctx context.Context, page, limit int, sort string) ([]entity.User, error)
+	Count(ctx context.Context) (int64, error)
 }
 
 type ProductInterface interface {
-	Create(product *entity.Product) error
-	FindAll(page, limit int, sort string) ([]entity.Product, error)
-	FindByID(id string) (*entity.Product, error)
-	Update(product *entity.Product) error
-	Delete(id string) error
-	Count() (int64, error)
+	Create(ctx context.Context, product *entity.Product) error
+	CreateBatch(ctx context.Context, products []entity.Product) error
+	FindAll(ctx context.Context, filter ProductFilter, page, limit int, sort string) ([]entity.Product, error)
+	FindByCursor(ctx context.Context, filter ProductFilter, cursor *pkgEntity.Cursor, desc bool, limit int) ([]entity.Product, bool, error)
+	FindByID(ctx context.Context, id string) (*entity.Product, error)
+	Update(ctx context.Context, product *entity.Product, fields ...string) error
+	Delete(ctx context.Context, id string, version int64) error
+	Count(ctx context.Context, filter ProductFilter) (int64, error)
+	FindDeleted(ctx context.Context, page, limit int, sort string) ([]entity.Product, error)
+	CountDeleted(ctx context.Context) (int64, error)
+	Restore(ctx context.Context, id string) error
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, []string, error)
+	History(ctx context.Context, id string, page, limit int, sort string) ([]entity.ProductAudit, error)
+	CountHistory(ctx context.Context, id string) (int64, error)
+	SetCategories(ctx context.Context, id string, categoryIDs []string) error
+	AddImage(ctx context.Context, image *entity.ProductImage) error
+}
+
+type CategoryInterface interface {
+	Create(ctx context.Context, category *entity.Category) error
+	FindByID(ctx context.Context, id string) (*entity.Category, error)
+	FindAll(ctx context.Context, page, limit int, sort string) ([]entity.Category, error)
+	Count(ctx context.Context) (int64, error)
+	Update(ctx context.Context, category *entity.Category) error
+	Delete(ctx context.Context, id string) error
+}
+
+type IdempotencyInterface interface {
+	Reserve(ctx context.Context, key *entity.IdempotencyKey) (*entity.IdempotencyKey, error)
+	Complete(ctx context.Context, key *entity.IdempotencyKey) error
+	Release(ctx context.Context, key *entity.IdempotencyKey) error
+	Purge(ctx context.Context, expiredBefore time.Time) (int64, error)
+}
+
+type TokenInterface interface {
+	CreateRefreshToken(ctx context.Context, token *entity.RefreshToken) error
+	FindRefreshTokenByHash(ctx context.Context, hash string) (*entity.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, current, next *entity.RefreshToken) error
+	RevokeRefreshToken(ctx context.Context, id string) error
+	RevokeUserRefreshTokens(ctx context.Context, userID string) error
+	RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+	CreatePasswordResetToken(ctx context.Context, token *entity.PasswordResetToken) error
+	FindPasswordResetTokenByHash(ctx context.Context, hash string) (*entity.PasswordResetToken, error)
+	UsePasswordResetToken(ctx context.Context, token *entity.PasswordResetToken) error
 }
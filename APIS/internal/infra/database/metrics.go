@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/metrics"
+)
+
+// methodKey holds the repository method set by queryContext.
+type methodKey struct{}
+
+// unknownMethod labels the statements run outside a repository method.
+const unknownMethod = "unknown"
+
+const metricsStartKey = "metrics:start"
+
+// metricsPlugin times every statement and counts the failed ones, labelled
+// by the repository method that ran them.
+type metricsPlugin struct{}
+
+// RegisterMetrics adds the metrics callbacks to db.
+func RegisterMetrics(db *gorm.DB) error {
+	return db.Use(metricsPlugin{})
+}
+
+func (metricsPlugin) Name() string {
+	return "metrics"
+}
+
+func (metricsPlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	return errors.Join(
+		callback.Create().Before("gorm:create").Register("metrics:before_create", startTimer),
+		callback.Create().After("gorm:create").Register("metrics:after_create", observe),
+		callback.Query().Before("gorm:query").Register("metrics:before_query", startTimer),
+		callback.Query().After("gorm:query").Register("metrics:after_query", observe),
+		callback.Update().Before("gorm:update").Register("metrics:before_update", startTimer),
+		callback.Update().After("gorm:update").Register("metrics:after_update", observe),
+		callback.Delete().Before("gorm:delete").Register("metrics:before_delete", startTimer),
+		callback.Delete().After("gorm:delete").Register("metrics:after_delete", observe),
+		callback.Row().Before("gorm:row").Register("metrics:before_row", startTimer),
+		callback.Row().After("gorm:row").Register("metrics:after_row", observe),
+		callback.Raw().Before("gorm:raw").Register("metrics:before_raw", startTimer),
+		callback.Raw().After("gorm:raw").Register("metrics:after_raw", observe),
+	)
+}
+
+func startTimer(db *gorm.DB) {
+	db.InstanceSet(metricsStartKey, time.Now())
+}
+
+func observe(db *gorm.DB) {
+	value, ok := db.InstanceGet(metricsStartKey)
+	if !ok {
+		return
+	}
+	method := methodFromContext(db.Statement.Context)
+	metrics.DBQueryDuration.WithLabelValues(method).Observe(time.Since(value.(time.Time)).Seconds())
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		metrics.DBQueryErrors.WithLabelValues(method).Inc()
+	}
+}
+
+func methodFromContext(ctx context.Context) string {
+	if ctx != nil {
+		if method, ok := ctx.Value(methodKey{}).(string); ok {
+			return method
+		}
+	}
+	return unknownMethod
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/metrics"
+	pkgEntity "github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	clientModel "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statementCount is the number of statements timed for method.
+func statementCount(t *testing.T, method string) uint64 {
+	t.Helper()
+	var metric clientModel.Metric
+	require.NoError(t, metrics.DBQueryDuration.WithLabelValues(method).(prometheus.Histogram).Write(&metric))
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestRegisterMetrics(t *testing.T) {
+	db := setupProductTestDB(t)
+	require.NoError(t, RegisterMetrics(db))
+	productDB := NewProductDB(db, 0)
+	ctx := context.Background()
+
+	createBefore := statementCount(t, "Product.Create")
+	findBefore := statementCount(t, "Product.FindByID")
+	findErrorsBefore := testutil.ToFloat64(metrics.DBQueryErrors.WithLabelValues("Product.FindByID"))
+	countErrorsBefore := testutil.ToFloat64(metrics.DBQueryErrors.WithLabelValues("Product.Count"))
+
+	product, err := entity.NewProduct("Laptop", pkgEntity.NewMoney(99999, "BRL"))
+	require.NoError(t, err)
+	require.NoError(t, productDB.Create(ctx, product))
+	assert.Equal(t, createBefore+2, statementCount(t, "Product.Create"), "the product and its audit are two statements")
+
+	_, err = productDB.FindByID(ctx, pkgEntity.NewID().String())
+	assert.ErrorIs(t, err, entity.ErrProductNotFound)
+	assert.Equal(t, findBefore+1, statementCount(t, "Product.FindByID"))
+	assert.Equal(t, findErrorsBefore, testutil.ToFloat64(metrics.DBQueryErrors.WithLabelValues("Product.FindByID")),
+		"not found is not a failed statement")
+
+	require.NoError(t, db.Migrator().DropTable(&entity.Product{}))
+	_, err = productDB.Count(ctx, ProductFilter{})
+	assert.Error(t, err)
+	assert.Equal(t, countErrorsBefore+1, testutil.ToFloat64(metrics.DBQueryErrors.WithLabelValues("Product.Count")))
+
+	unknownBefore := statementCount(t, unknownMethod)
+	require.NoError(t, db.Exec("SELECT 1").Error)
+	assert.Equal(t, unknownBefore+1, statementCount(t, unknownMethod), "statements outside the repositories are labelled unknown")
+}
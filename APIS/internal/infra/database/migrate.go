@@ -0,0 +1,202 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/golang-migrate/migrate/v4"
+	migrateDatabase "github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/mysql"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"gorm.io/gorm"
+)
+
+// migrations holds one set of versioned up/down files per dialect, every
+// set must keep the same versions.
+//
+//go:embed migrations
+var migrations embed.FS
+
+// ErrSchemaVersion is returned by CheckSchema when the database isn't at the
+// version of the embedded migrations.
+var ErrSchemaVersion = errors.New("database schema version mismatch")
+
+// Migrator applies the embedded migrations to the database of a GORM
+// connection. It shares the GORM pool, Close releases only what the
+// migrations took from it.
+type Migrator struct {
+	db      *gorm.DB
+	migrate *migrate.Migrate
+	source  source.Driver
+	conn    *sql.Conn
+	latest  uint
+}
+
+// NewMigrator picks the migrations of the GORM dialect: postgres, mysql or
+// sqlite.
+func NewMigrator(ctx context.Context, db *gorm.DB) (*Migrator, error) {
+	dialect := db.Dialector.Name()
+	migrationsDir, err := fs.Sub(migrations, "migrations/"+dialect)
+	if err != nil {
+		return nil, err
+	}
+	src, err := iofs.New(migrationsDir, ".")
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for the %s database: %w", dialect, err)
+	}
+	latest, err := latestVersion(src)
+	if err != nil {
+		src.Close()
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		src.Close()
+		return nil, err
+	}
+	m := &Migrator{db: db, source: src, latest: latest}
+	var driver migrateDatabase.Driver
+	// The postgres and mysql WithInstance would close the whole pool on
+	// Close, they get a connection of their own instead
+	switch dialect {
+	case "postgres":
+		if m.conn, err = sqlDB.Conn(ctx); err == nil {
+			driver, err = postgres.WithConnection(ctx, m.conn, &postgres.Config{})
+		}
+	case "mysql":
+		// Needs multiStatements in the DSN, configs sets it
+		if m.conn, err = sqlDB.Conn(ctx); err == nil {
+			driver, err = mysql.WithConnection(ctx, m.conn, &mysql.Config{})
+		}
+	case "sqlite":
+		driver, err = sqlite3.WithInstance(sqlDB, &sqlite3.Config{})
+	}
+	if err == nil {
+		m.migrate, err = migrate.NewWithInstance("iofs", src, dialect, driver)
+	}
+	if err != nil {
+		m.Close()
+		return nil, fmt.Errorf("error preparing the %s migrations: %w", dialect, err)
+	}
+	return m, nil
+}
+
+// latestVersion is the version of the last migration of src.
+func latestVersion(src source.Driver) (uint, error) {
+	version, err := src.First()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
+}
+
+// autoMigrateColumns are the columns the first migrations have and the
+// tables of the first release, created by AutoMigrate, lack. create table
+// if not exists keeps those tables as they are, adoptAutoMigrate adds them.
+var autoMigrateColumns = []struct{ table, column, definition string }{
+	{"users", "usr_role", "varchar(20) default 'viewer'"},
+	{"products", "prd_version", "bigint not null default 1"},
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up() error {
+	if err := m.adoptAutoMigrate(); err != nil {
+		return err
+	}
+	if err := m.migrate.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// adoptAutoMigrate brings a database created by AutoMigrate to the schema
+// the first migrations expect. It only runs while no migration was applied,
+// the existing rows get the default of the new columns.
+func (m *Migrator) adoptAutoMigrate() error {
+	version, _, err := m.Version()
+	if err != nil || version != 0 {
+		return err
+	}
+	schema := m.db.Migrator()
+	for _, c := range autoMigrateColumns {
+		if !schema.HasTable(c.table) || schema.HasColumn(c.table, c.column) {
+			continue
+		}
+		if err := m.db.Exec("alter table " + c.table + " add column " + c.column + " " + c.definition).Error; err != nil {
+			return fmt.Errorf("error adding %s to the %s table: %w", c.column, c.table, err)
+		}
+	}
+	return nil
+}
+
+// Down reverts the last steps migrations.
+func (m *Migrator) Down(steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("the number of steps must be positive, got %d", steps)
+	}
+	if err := m.migrate.Steps(-steps); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// Version returns the version of the database, 0 when no migration was
+// applied. dirty means the last migration failed halfway and must be fixed
+// by hand.
+func (m *Migrator) Version() (version uint, dirty bool, err error) {
+	version, dirty, err = m.migrate.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// Latest returns the version of the last embedded migration.
+func (m *Migrator) Latest() uint {
+	return m.latest
+}
+
+// CheckSchema makes sure the database is at the latest version, so the
+// server never runs against a schema it doesn't know.
+func (m *Migrator) CheckSchema() error {
+	version, dirty, err := m.Version()
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("%w: version %d is dirty, a migration failed halfway", ErrSchemaVersion, version)
+	}
+	if version != m.latest {
+		return fmt.Errorf("%w: the database is at version %d, this build needs %d", ErrSchemaVersion, version, m.latest)
+	}
+	return nil
+}
+
+// Close releases the migrations source and connection, the GORM pool stays
+// open.
+func (m *Migrator) Close() error {
+	// migrate.Close would also close the *sql.DB shared with GORM
+	err := m.source.Close()
+	if m.conn != nil {
+		err = errors.Join(err, m.conn.Close())
+	}
+	return err
+}
@@ -0,0 +1,211 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/database/dbtest"
+	pkgEntity "github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// setupMigratorTestDB opens an empty database, without the tables of
+// AutoMigrate.
+func setupMigratorTestDB(t *testing.T) (*gorm.DB, *Migrator) {
+	db := dbtest.Open(t)
+	migrator, err := NewMigrator(context.Background(), db)
+	require.NoError(t, err)
+	t.Cleanup(func() { migrator.Close() })
+	return db, migrator
+}
+
+func TestMigrator_UpAndDown(t *testing.T) {
+	db, migrator := setupMigratorTestDB(t)
+	tables := []string{"users", "products", "refresh_tokens", "revoked_tokens", "password_reset_tokens", "product_audits", "categories", "product_categories", "product_images", "idempotency_keys"}
+
+	require.NoError(t, migrator.Up())
+	version, dirty, err := migrator.Version()
+	require.NoError(t, err)
+	assert.Equal(t, migrator.Latest(), version)
+	assert.False(t, dirty)
+	for _, table := range tables {
+		assert.True(t, db.Migrator().HasTable(table), "%s must exist after up", table)
+	}
+	require.NoError(t, migrator.Up(), "up without pending migrations is not an error")
+
+	require.NoError(t, migrator.Down(1))
+	version, _, err = migrator.Version()
+	require.NoError(t, err)
+	assert.Equal(t, migrator.Latest()-1, version)
+	assert.False(t, db.Migrator().HasTable("idempotency_keys"))
+	assert.True(t, db.Migrator().HasTable("product_images"))
+
+	require.NoError(t, migrator.Down(int(version)))
+	version, _, err = migrator.Version()
+	require.NoError(t, err)
+	assert.Zero(t, version)
+	for _, table := range tables {
+		assert.False(t, db.Migrator().HasTable(table), "%s must be dropped after down", table)
+	}
+
+	assert.Error(t, migrator.Down(0))
+	require.NoError(t, migrator.Up(), "the migrations must apply again after a full down")
+}
+
+func TestMigrator_CheckSchema(t *testing.T) {
+	_, migrator := setupMigratorTestDB(t)
+
+	err := migrator.CheckSchema()
+	assert.ErrorIs(t, err, ErrSchemaVersion, "an empty database is not at the latest version")
+
+	require.NoError(t, migrator.Up())
+	assert.NoError(t, migrator.CheckSchema())
+
+	require.NoError(t, migrator.Down(1))
+	assert.ErrorIs(t, migrator.CheckSchema(), ErrSchemaVersion)
+}
+
+func TestMigrator_CloseKeepsThePool(t *testing.T) {
+	db, migrator := setupMigratorTestDB(t)
+	require.NoError(t, migrator.Up())
+	require.NoError(t, migrator.Close())
+
+	assert.NoError(t, db.Exec("SELECT 1").Error)
+}
+
+func TestMigrator_ConvertsPricesToMinorUnits(t *testing.T) {
+	db, migrator := setupMigratorTestDB(t)
+	require.NoError(t, migrator.Up())
+	// Back to the version before the money columns
+	require.NoError(t, migrator.Down(3))
+	require.NoError(t, db.Exec(
+		"insert into products (prd_id, prd_name, prd_price, prd_version, created_at, updated_at) values (?, ?, ?, 1, ?, ?)",
+		"019ab24a-dc97-72a4-9056-cc09f4c13bef", "Laptop", 899.99, time.Now(), time.Now(),
+	).Error)
+
+	require.NoError(t, migrator.Up())
+	found, err := NewProductDB(db, 0).FindByID(context.Background(), "019ab24a-dc97-72a4-9056-cc09f4c13bef")
+	require.NoError(t, err)
+	assert.Equal(t, pkgEntity.NewMoney(89999, pkgEntity.DefaultCurrency), found.Price)
+}
+
+// baselineUser and baselineProduct are the tables of the first release,
+// created by AutoMigrate before the migrations existed.
+type baselineUser struct {
+	ID       string `gorm:"column:usr_id;type:uuid;primarykey"`
+	Name     string `gorm:"column:usr_name;size:255"`
+	Email    string `gorm:"column:usr_email;size:255;unique"`
+	Password string `gorm:"column:usr_password;size:255"`
+	pkgEntity.BaseModel
+}
+
+func (baselineUser) TableName() string { return "users" }
+
+type baselineProduct struct {
+	ID    string  `gorm:"column:prd_id;type:uuid;primarykey"`
+	Name  string  `gorm:"column:prd_name;size:255"`
+	Price float64 `gorm:"column:prd_price;type:decimal(10,2)"`
+	pkgEntity.BaseModel
+}
+
+func (baselineProduct) TableName() string { return "products" }
+
+func TestMigrator_AdoptsAutoMigrateDatabase(t *testing.T) {
+	db, migrator := setupMigratorTestDB(t)
+	require.NoError(t, db.AutoMigrate(&baselineUser{}, &baselineProduct{}))
+	user, err := entity.NewUser("John", "john@example.com", "secret123")
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&baselineUser{ID: user.ID.String(), Name: user.Name, Email: user.Email, Password: user.Password}).Error)
+	productID := pkgEntity.NewID().String()
+	require.NoError(t, db.Create(&baselineProduct{ID: productID, Name: "Laptop", Price: 899.99}).Error)
+
+	require.NoError(t, migrator.Up())
+	require.NoError(t, migrator.CheckSchema())
+
+	foundUser, err := NewUserDB(db, 0).FindByEmail(context.Background(), "john@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, entity.RoleViewer, foundUser.Role)
+	productDB := NewProductDB(db, 0)
+	found, err := productDB.FindByID(context.Background(), productID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), found.Version.Int64)
+	assert.Equal(t, pkgEntity.NewMoney(89999, pkgEntity.DefaultCurrency), found.Price)
+	found.Name = "Gaming Laptop"
+	require.NoError(t, productDB.Update(context.Background(), found))
+}
+
+// The repositories must work on the migrated schema exactly as on the one
+// AutoMigrate creates for the other tests.
+func TestMigrator_SchemaMatchesEntities(t *testing.T) {
+	db, migrator := setupMigratorTestDB(t)
+	require.NoError(t, migrator.Up())
+	ctx := entity.ContextWithActor(context.Background(), "admin")
+
+	productDB := NewProductDB(db, 0)
+	product, err := entity.NewProduct("Laptop", pkgEntity.NewMoney(99999, "BRL"))
+	require.NoError(t, err)
+	require.NoError(t, productDB.Create(ctx, product))
+	product.Price = pkgEntity.NewMoney(89950, "BRL")
+	require.NoError(t, productDB.Update(ctx, product))
+	found, err := productDB.FindByID(ctx, product.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, pkgEntity.NewMoney(89950, "BRL"), found.Price)
+	assert.Equal(t, int64(2), found.Version.Int64)
+	history, err := productDB.History(ctx, product.ID.String(), 1, 10, "aud_id asc")
+	require.NoError(t, err)
+	assert.Len(t, history, 2)
+
+	categoryDB := NewCategoryDB(db, 0)
+	category, err := entity.NewCategory("Electronics")
+	require.NoError(t, err)
+	require.NoError(t, categoryDB.Create(ctx, category))
+	assert.ErrorIs(t, categoryDB.Create(ctx, &entity.Category{ID: pkgEntity.NewID(), Name: "Electronics"}), entity.ErrCategoryDuplicate)
+	require.NoError(t, productDB.SetCategories(ctx, product.ID.String(), []string{category.ID.String()}))
+	count, err := productDB.Count(ctx, ProductFilter{Category: category.ID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	image, err := entity.NewProductImage(product.ID, "image/png", 10)
+	require.NoError(t, err)
+	require.NoError(t, productDB.AddImage(ctx, image))
+	found, err = productDB.FindByID(ctx, product.ID.String())
+	require.NoError(t, err)
+	require.Len(t, found.Images, 1)
+	assert.Equal(t, image.Key, found.Images[0].Key)
+
+	userDB := NewUserDB(db, 0)
+	user, err := entity.NewUser("John", "john@example.com", "secret123")
+	require.NoError(t, err)
+	require.NoError(t, userDB.Create(ctx, user))
+	assert.ErrorIs(t, userDB.Create(ctx, user), entity.ErrEmailTaken)
+	foundUser, err := userDB.FindByEmail(ctx, "john@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, entity.RoleViewer, foundUser.Role)
+
+	tokenDB := NewTokenDB(db, 0)
+	refresh, _, err := entity.NewRefreshToken(user.ID, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, tokenDB.CreateRefreshToken(ctx, refresh))
+	require.NoError(t, tokenDB.RevokeAccessToken(ctx, "jti", time.Now().Add(time.Hour)))
+	revoked, err := tokenDB.IsAccessTokenRevoked(ctx, "jti")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	idempotencyDB := NewIdempotencyDB(db, 0)
+	key, err := entity.NewIdempotencyKey("sub:1 POST /products", "retry-1", "hash", time.Hour)
+	require.NoError(t, err)
+	_, err = idempotencyDB.Reserve(ctx, key)
+	require.NoError(t, err)
+	key.Status = 201
+	key.Header = map[string]string{"Content-Type": "application/json"}
+	key.Body = []byte(`{}`)
+	require.NoError(t, idempotencyDB.Complete(ctx, key))
+	existing, err := idempotencyDB.Reserve(ctx, key)
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.Equal(t, key.Header, existing.Header)
+	assert.Equal(t, key.Body, existing.Body)
+}
@@ -0,0 +1,14 @@
+-- if not exists lets databases created by the old AutoMigrate adopt the migrations,
+-- Migrator.Up first adds the columns their tables lack (see autoMigrateColumns)
+create table if not exists users (
+    usr_id char(36) primary key,
+    usr_name varchar(255),
+    usr_email varchar(255),
+    usr_password varchar(255),
+    usr_role varchar(20) default 'viewer',
+    created_at datetime(3),
+    updated_at datetime(3),
+    deleted_at datetime(3),
+    constraint uni_users_usr_email unique (usr_email),
+    index idx_users_deleted_at (deleted_at)
+);
@@ -0,0 +1,10 @@
+create table if not exists products (
+    prd_id char(36) primary key,
+    prd_name varchar(255),
+    prd_price decimal(10,2),
+    prd_version bigint not null default 1,
+    created_at datetime(3),
+    updated_at datetime(3),
+    deleted_at datetime(3),
+    index idx_products_deleted_at (deleted_at)
+);
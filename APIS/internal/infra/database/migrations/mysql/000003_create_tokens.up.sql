@@ -0,0 +1,38 @@
+create table if not exists refresh_tokens (
+    rtk_id char(36) primary key,
+    rtk_usr_id char(36),
+    rtk_hash varchar(64),
+    rtk_expires_at datetime(3),
+    rtk_revoked_at datetime(3),
+    rtk_replaced_by char(36),
+    created_at datetime(3),
+    updated_at datetime(3),
+    deleted_at datetime(3),
+    index idx_refresh_tokens_user_id (rtk_usr_id),
+    unique index idx_refresh_tokens_token_hash (rtk_hash),
+    index idx_refresh_tokens_deleted_at (deleted_at)
+);
+
+create table if not exists revoked_tokens (
+    rvk_jti varchar(64) primary key,
+    rvk_expires_at datetime(3),
+    created_at datetime(3),
+    updated_at datetime(3),
+    deleted_at datetime(3),
+    index idx_revoked_tokens_expires_at (rvk_expires_at),
+    index idx_revoked_tokens_deleted_at (deleted_at)
+);
+
+create table if not exists password_reset_tokens (
+    prt_id char(36) primary key,
+    prt_usr_id char(36),
+    prt_hash varchar(64),
+    prt_expires_at datetime(3),
+    prt_used_at datetime(3),
+    created_at datetime(3),
+    updated_at datetime(3),
+    deleted_at datetime(3),
+    index idx_password_reset_tokens_user_id (prt_usr_id),
+    unique index idx_password_reset_tokens_token_hash (prt_hash),
+    index idx_password_reset_tokens_deleted_at (deleted_at)
+);
@@ -0,0 +1,10 @@
+create table if not exists product_audits (
+    aud_id char(36) primary key,
+    aud_prd_id char(36),
+    aud_usr_id varchar(64),
+    aud_action varchar(16),
+    aud_before text,
+    aud_after text,
+    aud_created_at datetime(3),
+    index idx_product_audits_product_id (aud_prd_id)
+);
@@ -0,0 +1,16 @@
+create table if not exists categories (
+    cat_id char(36) primary key,
+    cat_name varchar(255),
+    created_at datetime(3),
+    updated_at datetime(3),
+    deleted_at datetime(3),
+    unique index idx_categories_name (cat_name),
+    index idx_categories_deleted_at (deleted_at)
+);
+
+create table if not exists product_categories (
+    pct_prd_id char(36),
+    pct_cat_id char(36),
+    primary key (pct_prd_id, pct_cat_id),
+    index idx_product_categories_category_id (pct_cat_id)
+);
@@ -0,0 +1,9 @@
+create table if not exists product_images (
+    pim_id char(36) primary key,
+    pim_prd_id char(36),
+    pim_key varchar(255),
+    pim_content_type varchar(100),
+    pim_size bigint,
+    pim_created_at datetime(3),
+    index idx_product_images_product_id (pim_prd_id)
+);
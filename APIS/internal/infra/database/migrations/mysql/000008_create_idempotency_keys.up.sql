@@ -0,0 +1,13 @@
+create table if not exists idempotency_keys (
+    idk_id char(36) primary key,
+    idk_scope varchar(255),
+    idk_key varchar(255),
+    idk_request_hash varchar(64),
+    idk_status int,
+    idk_header text,
+    idk_body mediumblob,
+    idk_expires_at datetime(3),
+    idk_created_at datetime(3),
+    unique index idx_idempotency_keys_scope_key (idk_scope, idk_key),
+    index idx_idempotency_keys_expires_at (idk_expires_at)
+);
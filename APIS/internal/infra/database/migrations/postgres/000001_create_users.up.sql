@@ -0,0 +1,15 @@
+-- if not exists lets databases created by the old AutoMigrate adopt the migrations,
+-- Migrator.Up first adds the columns their tables lack (see autoMigrateColumns)
+create table if not exists users (
+    usr_id uuid primary key,
+    usr_name varchar(255),
+    usr_email varchar(255),
+    usr_password varchar(255),
+    usr_role varchar(20) default 'viewer',
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    constraint uni_users_usr_email unique (usr_email)
+);
+
+create index if not exists idx_users_deleted_at on users (deleted_at);
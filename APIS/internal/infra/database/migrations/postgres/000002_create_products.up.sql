@@ -0,0 +1,14 @@
+create table if not exists products (
+    prd_id uuid primary key,
+    prd_name varchar(255),
+    prd_price decimal(10,2),
+    prd_version bigint not null default 1,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz
+);
+
+create index if not exists idx_products_deleted_at on products (deleted_at);
+
+-- full-text search of ProductFilter.Query, the expression must match productSearchVector
+create index if not exists idx_products_search on products using gin (to_tsvector('simple', prd_name));
@@ -0,0 +1,11 @@
+create table if not exists product_audits (
+    aud_id uuid primary key,
+    aud_prd_id uuid,
+    aud_usr_id varchar(64),
+    aud_action varchar(16),
+    aud_before text,
+    aud_after text,
+    aud_created_at timestamptz
+);
+
+create index if not exists idx_product_audits_product_id on product_audits (aud_prd_id);
@@ -0,0 +1,2 @@
+drop table if exists product_categories;
+drop table if exists categories;
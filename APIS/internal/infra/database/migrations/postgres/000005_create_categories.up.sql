@@ -0,0 +1,19 @@
+create table if not exists categories (
+    cat_id uuid primary key,
+    cat_name varchar(255),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz
+);
+
+create unique index if not exists idx_categories_name on categories (cat_name);
+create index if not exists idx_categories_deleted_at on categories (deleted_at);
+
+create table if not exists product_categories (
+    pct_prd_id uuid,
+    pct_cat_id uuid,
+    primary key (pct_prd_id, pct_cat_id)
+);
+
+-- the primary key covers the lookups by product, this one the category filter
+create index if not exists idx_product_categories_category_id on product_categories (pct_cat_id);
@@ -0,0 +1,8 @@
+-- prices become integer minor units with a currency, the existing ones are
+-- in the default currency
+alter table products add column if not exists prd_price_amount bigint;
+alter table products add column if not exists prd_price_currency char(3);
+
+update products set prd_price_amount = round(prd_price * 100), prd_price_currency = 'BRL';
+
+alter table products drop column if exists prd_price;
@@ -0,0 +1,10 @@
+create table if not exists product_images (
+    pim_id uuid primary key,
+    pim_prd_id uuid,
+    pim_key varchar(255),
+    pim_content_type varchar(100),
+    pim_size bigint,
+    pim_created_at timestamptz
+);
+
+create index if not exists idx_product_images_product_id on product_images (pim_prd_id);
@@ -0,0 +1,14 @@
+create table if not exists idempotency_keys (
+    idk_id uuid primary key,
+    idk_scope varchar(255),
+    idk_key varchar(255),
+    idk_request_hash varchar(64),
+    idk_status integer,
+    idk_header text,
+    idk_body bytea,
+    idk_expires_at timestamptz,
+    idk_created_at timestamptz
+);
+
+create unique index if not exists idx_idempotency_keys_scope_key on idempotency_keys (idk_scope, idk_key);
+create index if not exists idx_idempotency_keys_expires_at on idempotency_keys (idk_expires_at);
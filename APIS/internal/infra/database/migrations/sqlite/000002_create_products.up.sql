@@ -0,0 +1,12 @@
+create table if not exists products (
+    prd_id uuid primary key,
+    prd_name text,
+    prd_price decimal(10,2),
+    prd_version integer not null default 1,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime
+);
+
+create index if not exists idx_products_deleted_at on products (deleted_at);
+
@@ -0,0 +1,41 @@
+create table if not exists refresh_tokens (
+    rtk_id uuid primary key,
+    rtk_usr_id uuid,
+    rtk_hash text,
+    rtk_expires_at datetime,
+    rtk_revoked_at datetime,
+    rtk_replaced_by uuid,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime
+);
+
+create index if not exists idx_refresh_tokens_user_id on refresh_tokens (rtk_usr_id);
+create unique index if not exists idx_refresh_tokens_token_hash on refresh_tokens (rtk_hash);
+create index if not exists idx_refresh_tokens_deleted_at on refresh_tokens (deleted_at);
+
+create table if not exists revoked_tokens (
+    rvk_jti text primary key,
+    rvk_expires_at datetime,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime
+);
+
+create index if not exists idx_revoked_tokens_expires_at on revoked_tokens (rvk_expires_at);
+create index if not exists idx_revoked_tokens_deleted_at on revoked_tokens (deleted_at);
+
+create table if not exists password_reset_tokens (
+    prt_id uuid primary key,
+    prt_usr_id uuid,
+    prt_hash text,
+    prt_expires_at datetime,
+    prt_used_at datetime,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime
+);
+
+create index if not exists idx_password_reset_tokens_user_id on password_reset_tokens (prt_usr_id);
+create unique index if not exists idx_password_reset_tokens_token_hash on password_reset_tokens (prt_hash);
+create index if not exists idx_password_reset_tokens_deleted_at on password_reset_tokens (deleted_at);
@@ -0,0 +1,11 @@
+create table if not exists product_audits (
+    aud_id uuid primary key,
+    aud_prd_id uuid,
+    aud_usr_id text,
+    aud_action text,
+    aud_before text,
+    aud_after text,
+    aud_created_at datetime
+);
+
+create index if not exists idx_product_audits_product_id on product_audits (aud_prd_id);
@@ -0,0 +1,18 @@
+create table if not exists categories (
+    cat_id uuid primary key,
+    cat_name text,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime
+);
+
+create unique index if not exists idx_categories_name on categories (cat_name);
+create index if not exists idx_categories_deleted_at on categories (deleted_at);
+
+create table if not exists product_categories (
+    pct_prd_id uuid,
+    pct_cat_id uuid,
+    primary key (pct_prd_id, pct_cat_id)
+);
+
+create index if not exists idx_product_categories_category_id on product_categories (pct_cat_id);
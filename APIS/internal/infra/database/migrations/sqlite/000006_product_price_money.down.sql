@@ -0,0 +1,7 @@
+-- the currencies are lost
+alter table products add column prd_price decimal(10,2);
+
+update products set prd_price = prd_price_amount / 100.0;
+
+alter table products drop column prd_price_currency;
+alter table products drop column prd_price_amount;
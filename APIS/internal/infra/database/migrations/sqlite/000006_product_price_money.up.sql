@@ -0,0 +1,8 @@
+-- prices become integer minor units with a currency, the existing ones are
+-- in the default currency
+alter table products add column prd_price_amount integer;
+alter table products add column prd_price_currency text;
+
+update products set prd_price_amount = cast(round(prd_price * 100) as integer), prd_price_currency = 'BRL';
+
+alter table products drop column prd_price;
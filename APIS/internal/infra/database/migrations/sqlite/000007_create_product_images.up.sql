@@ -0,0 +1,10 @@
+create table if not exists product_images (
+    pim_id uuid primary key,
+    pim_prd_id uuid,
+    pim_key text,
+    pim_content_type text,
+    pim_size integer,
+    pim_created_at datetime
+);
+
+create index if not exists idx_product_images_product_id on product_images (pim_prd_id);
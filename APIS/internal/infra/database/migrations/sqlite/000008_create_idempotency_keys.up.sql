@@ -0,0 +1,14 @@
+create table if not exists idempotency_keys (
+    idk_id uuid primary key,
+    idk_scope text,
+    idk_key text,
+    idk_request_hash text,
+    idk_status integer,
+    idk_header text,
+    idk_body blob,
+    idk_expires_at datetime,
+    idk_created_at datetime
+);
+
+create unique index if not exists idx_idempotency_keys_scope_key on idempotency_keys (idk_scope, idk_key);
+create index if not exists idx_idempotency_keys_expires_at on idempotency_keys (idk_expires_at);
@@ -2,75 +2,507 @@ package database
 
 import (
 	"context"
+	"slices"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
 	pkgEntity "github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
 )
 
+// ProductFilter narrows FindAll and Count. Zero values are ignored.
+type ProductFilter struct {
+	Name          string // case insensitive "contains"
+	Currency      string // ISO 4217 code of the price
+	MinPrice      *int64 // in minor units of Currency
+	MaxPrice      *int64
+	CreatedAfter  *time.Time   // inclusive
+	CreatedBefore *time.Time   // exclusive
+	Query         string       // full-text search on the name
+	Category      pkgEntity.ID // tagged with this category
+}
+
+// createBatchSize bounds the rows of one INSERT, the databases limit the
+// number of parameters of a statement.
+const createBatchSize = 100
+
 type Product struct {
-	db *gorm.DB
+	repository
 }
 
-func NewProductDB(db *gorm.DB) *Product {
-	return &Product{db: db}
+// NewProductDB returns the repository. Every query is bounded by queryTimeout,
+// zero leaves only the deadline of the caller's context.
+func NewProductDB(db *gorm.DB, queryTimeout time.Duration) *Product {
+	return &Product{repository{db: db, timeout: queryTimeout}}
 }
 
-func (p *Product) Create(product *entity.Product) error {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+// Create inserts the product and its audit.
+func (p *Product) Create(ctx context.Context, product *entity.Product) error {
+	ctx, cancel := p.queryContext(ctx, "Product.Create")
 	defer cancel()
-	return gorm.G[entity.Product](p.db).Create(ctx, product)
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := gorm.G[entity.Product](tx).Create(ctx, product); err != nil {
+			return err
+		}
+		return createAudits(ctx, tx, *entity.NewProductAudit(ctx, entity.AuditCreate, product.ID, nil, product))
+	})
+	return translateError(err, entity.ErrProductNotFound, entity.ErrProductDuplicate)
 }
 
-func (p *Product) FindAll(page, limit int, sort string) ([]entity.Product, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+// CreateBatch inserts products in a single transaction, none is saved if
+// one of them fails.
+func (p *Product) CreateBatch(ctx context.Context, products []entity.Product) error {
+	ctx, cancel := p.queryContext(ctx, "Product.CreateBatch")
+	defer cancel()
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := gorm.G[entity.Product](tx).CreateInBatches(ctx, &products, createBatchSize); err != nil {
+			return err
+		}
+		audits := make([]entity.ProductAudit, 0, len(products))
+		for i := range products {
+			audits = append(audits, *entity.NewProductAudit(ctx, entity.AuditCreate, products[i].ID, nil, &products[i]))
+		}
+		return createAudits(ctx, tx, audits...)
+	})
+	return translateError(err, entity.ErrProductNotFound, entity.ErrProductDuplicate)
+}
+
+func (p *Product) FindAll(ctx context.Context, filter ProductFilter, page, limit int, sort string) ([]entity.Product, error) {
+	ctx, cancel := p.queryContext(ctx, "Product.FindAll")
 	defer cancel()
 	offset := (page - 1) * limit
-	return gorm.G[entity.Product](p.db).
+	items, err := p.filtered(filter).
 		Order(sort).
 		Limit(limit).
 		Offset(offset).
 		Find(ctx)
+	return items, translateError(err, entity.ErrProductNotFound, entity.ErrProductDuplicate)
+}
+
+func (p *Product) FindByID(ctx context.Context, id string) (*entity.Product, error) {
+	ctx, cancel := p.queryContext(ctx, "Product.FindByID")
+	defer cancel()
+
+	productID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := gorm.G[entity.Product](p.db).
+		Preload("Categories", func(db gorm.PreloadBuilder) error {
+			db.Order("cat_name")
+			return nil
+		}).
+		Preload("Images", func(db gorm.PreloadBuilder) error {
+			db.Order("pim_created_at, pim_id")
+			return nil
+		}).
+		Where("prd_id = ?", productID).
+		First(ctx)
+	return &product, translateError(err, entity.ErrProductNotFound, entity.ErrProductDuplicate)
 }
 
-func (p *Product) FindByID(id string) (*entity.Product, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+// Update saves product only if nobody changed it since it was read, the
+// row must still have product.Version. The version is bumped on success.
+// fields limits the update to those struct fields, none saves all of them.
+func (p *Product) Update(ctx context.Context, product *entity.Product, fields ...string) error {
+	ctx, cancel := p.queryContext(ctx, "Product.Update")
 	defer cancel()
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		before, err := findCurrent(ctx, tx, product.ID, product.Version.Int64)
+		if err != nil {
+			return err
+		}
+		// The categories and images loaded by FindByID are saved by
+		// SetCategories and AddImage only
+		query := gorm.G[entity.Product](tx).Omit(clause.Associations)
+		if len(fields) > 0 {
+			columns := make([]any, 0, len(fields))
+			for _, field := range fields {
+				// Select doesn't reach into embedded structs, the price
+				// columns are named instead
+				if field == "Price" {
+					columns = append(columns, "prd_price_amount", "prd_price_currency")
+					continue
+				}
+				columns = append(columns, field)
+			}
+			// Version must be selected too or its increment is dropped
+			query = query.Select("Version", columns...)
+		}
+		rows, err := query.Updates(ctx, *product)
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return entity.ErrProductModified
+		}
+		after, err := gorm.G[entity.Product](tx).Where("prd_id = ?", product.ID).First(ctx)
+		if err != nil {
+			return err
+		}
+		return createAudits(ctx, tx, *entity.NewProductAudit(ctx, entity.AuditUpdate, product.ID, before, &after))
+	})
+	if err != nil {
+		return translateError(err, entity.ErrProductNotFound, entity.ErrProductDuplicate)
+	}
+	product.Version.Int64++
+	return nil
+}
 
-	productID, err := pkgEntity.ParseID(id)
+// Delete removes the product if it is still at version.
+func (p *Product) Delete(ctx context.Context, id string, version int64) error {
+	ctx, cancel := p.queryContext(ctx, "Product.Delete")
+	defer cancel()
+
+	productID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+
+	err = p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		before, err := findCurrent(ctx, tx, productID, version)
+		if err != nil {
+			return err
+		}
+		rows, err := gorm.G[entity.Product](tx).
+			Where("prd_id = ? AND prd_version = ?", productID, version).
+			Delete(ctx)
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return entity.ErrProductModified
+		}
+		return createAudits(ctx, tx, *entity.NewProductAudit(ctx, entity.AuditDelete, productID, before, nil))
+	})
+	return translateError(err, entity.ErrProductNotFound, entity.ErrProductDuplicate)
+}
+
+// findCurrent loads the product for a conditional write, it must exist and
+// still be at version.
+func findCurrent(ctx context.Context, tx *gorm.DB, id pkgEntity.ID, version int64) (*entity.Product, error) {
+	product, err := gorm.G[entity.Product](tx).Where("prd_id = ?", id).First(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if product.Version.Int64 != version {
+		return nil, entity.ErrProductModified
+	}
+	return &product, nil
+}
+
+// FindByCursor returns up to limit products after the cursor, or before it
+// when cursor.Backward is set, in prd_id order. A nil cursor starts from the
+// first product. more tells whether other products follow in the direction
+// that was read. Keyset pagination doesn't scan the skipped rows like OFFSET.
+func (p *Product) FindByCursor(ctx context.Context, filter ProductFilter, cursor *pkgEntity.Cursor, desc bool, limit int) (items []entity.Product, more bool, err error) {
+	// A limit below 1 would slice the page out of bounds
+	if limit < 1 {
+		return nil, false, entity.ErrInvalidLimit
+	}
+	ctx, cancel := p.queryContext(ctx, "Product.FindByCursor")
+	defer cancel()
+
+	backward := cursor != nil && cursor.Backward
+	// Reading backward walks the index the other way, the page is reversed below
+	ascending := desc == backward
+	query := p.filtered(filter)
+	if cursor != nil {
+		if ascending {
+			query = query.Where("prd_id > ?", cursor.ID)
+		} else {
+			query = query.Where("prd_id < ?", cursor.ID)
+		}
+	}
+	order := "prd_id desc"
+	if ascending {
+		order = "prd_id asc"
+	}
+	// One extra row tells if there is another page
+	items, err = query.Order(order).Limit(limit + 1).Find(ctx)
+	if err != nil {
+		return nil, false, translateError(err, entity.ErrProductNotFound, entity.ErrProductDuplicate)
+	}
+	if len(items) > limit {
+		items = items[:limit]
+		more = true
+	}
+	if backward {
+		slices.Reverse(items)
+	}
+	return items, more, nil
+}
+
+// Count returns how many products match the filter, the total of the
+// pages returned by FindAll with the same filter.
+func (p *Product) Count(ctx context.Context, filter ProductFilter) (int64, error) {
+	ctx, cancel := p.queryContext(ctx, "Product.Count")
+	defer cancel()
+
+	count, err := p.filtered(filter).Count(ctx, "prd_id")
+	return count, translateError(err, entity.ErrProductNotFound, entity.ErrProductDuplicate)
+}
+
+// FindDeleted lists the products in the trash, the soft deleted ones.
+func (p *Product) FindDeleted(ctx context.Context, page, limit int, sort string) ([]entity.Product, error) {
+	ctx, cancel := p.queryContext(ctx, "Product.FindDeleted")
+	defer cancel()
+	offset := (page - 1) * limit
+	items, err := trash(p.db).
+		Order(sort).
+		Limit(limit).
+		Offset(offset).
+		Find(ctx)
+	return items, translateError(err, entity.ErrProductNotFound, entity.ErrProductDuplicate)
+}
+
+func (p *Product) CountDeleted(ctx context.Context) (int64, error) {
+	ctx, cancel := p.queryContext(ctx, "Product.CountDeleted")
+	defer cancel()
+
+	count, err := trash(p.db).Count(ctx, "prd_id")
+	return count, translateError(err, entity.ErrProductNotFound, entity.ErrProductDuplicate)
+}
+
+// Restore takes a product out of the trash. Restoring bumps its version.
+func (p *Product) Restore(ctx context.Context, id string) error {
+	ctx, cancel := p.queryContext(ctx, "Product.Restore")
+	defer cancel()
+
+	productID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+
+	err = p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		rows, err := trash(tx).Where("prd_id = ?", productID).Update(ctx, "deleted_at", nil)
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return entity.ErrProductNotFound
+		}
+		after, err := gorm.G[entity.Product](tx).Where("prd_id = ?", productID).First(ctx)
+		if err != nil {
+			return err
+		}
+		return createAudits(ctx, tx, *entity.NewProductAudit(ctx, entity.AuditRestore, productID, nil, &after))
+	})
+	return translateError(err, entity.ErrProductNotFound, entity.ErrProductDuplicate)
+}
+
+// Purge permanently removes the products deleted before deletedBefore and
+// returns how many were removed, with the blob store keys of their images.
+// Only the image rows are removed, the caller deletes the files. It isn't
+// audited, the history of the purged products is kept.
+func (p *Product) Purge(ctx context.Context, deletedBefore time.Time) (int64, []string, error) {
+	ctx, cancel := p.queryContext(ctx, "Product.Purge")
+	defer cancel()
 
-	product, err := gorm.G[entity.Product](p.db).Where("prd_id = ?", productID).First(ctx)
-	return &product, err
+	var rows int
+	var imageKeys []string
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		purged := "SELECT prd_id FROM products WHERE deleted_at < ?"
+		err := tx.Exec("DELETE FROM product_categories WHERE pct_prd_id IN ("+purged+")", deletedBefore).Error
+		if err != nil {
+			return err
+		}
+		images, err := gorm.G[entity.ProductImage](tx).Where("pim_prd_id IN ("+purged+")", deletedBefore).Find(ctx)
+		if err != nil {
+			return err
+		}
+		for _, image := range images {
+			imageKeys = append(imageKeys, image.Key)
+		}
+		err = tx.Exec("DELETE FROM product_images WHERE pim_prd_id IN ("+purged+")", deletedBefore).Error
+		if err != nil {
+			return err
+		}
+		rows, err = trash(tx).Where("deleted_at < ?", deletedBefore).Delete(ctx)
+		return err
+	})
+	if err != nil {
+		return 0, nil, translateError(err, entity.ErrProductNotFound, entity.ErrProductDuplicate)
+	}
+	return int64(rows), imageKeys, nil
 }
 
-func (p *Product) Update(product *entity.Product) error {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+// History lists the audits of a product, even a deleted or purged one.
+func (p *Product) History(ctx context.Context, id string, page, limit int, sort string) ([]entity.ProductAudit, error) {
+	ctx, cancel := p.queryContext(ctx, "Product.History")
 	defer cancel()
-	_, err := gorm.G[entity.Product](p.db).Updates(ctx, *product)
-	return err
+
+	productID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	offset := (page - 1) * limit
+	items, err := gorm.G[entity.ProductAudit](p.db).
+		Where("aud_prd_id = ?", productID).
+		Order(sort).
+		Limit(limit).
+		Offset(offset).
+		Find(ctx)
+	return items, translateError(err, entity.ErrProductNotFound, entity.ErrProductDuplicate)
 }
 
-func (p *Product) Delete(id string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+func (p *Product) CountHistory(ctx context.Context, id string) (int64, error) {
+	ctx, cancel := p.queryContext(ctx, "Product.CountHistory")
 	defer cancel()
 
-	productID, err := pkgEntity.ParseID(id)
+	productID, err := parseID(id)
+	if err != nil {
+		return 0, err
+	}
+	count, err := gorm.G[entity.ProductAudit](p.db).Where("aud_prd_id = ?", productID).Count(ctx, "aud_id")
+	return count, translateError(err, entity.ErrProductNotFound, entity.ErrProductDuplicate)
+}
+
+// SetCategories replaces the categories of the product, and audits them.
+// Every category must exist, otherwise none is changed.
+func (p *Product) SetCategories(ctx context.Context, id string, categoryIDs []string) error {
+	ctx, cancel := p.queryContext(ctx, "Product.SetCategories")
+	defer cancel()
+
+	productID, err := parseID(id)
 	if err != nil {
 		return err
 	}
+	ids := make([]pkgEntity.ID, 0, len(categoryIDs))
+	for _, categoryID := range categoryIDs {
+		parsed, err := parseID(categoryID)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(ids, parsed) {
+			ids = append(ids, parsed)
+		}
+	}
 
-	_, err = gorm.G[entity.Product](p.db).Where("prd_id = ?", productID).Delete(ctx)
-	return err
+	err = p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		before, err := gorm.G[entity.Product](tx).
+			Preload("Categories", func(db gorm.PreloadBuilder) error {
+				db.Order("cat_name")
+				return nil
+			}).
+			Where("prd_id = ?", productID).
+			First(ctx)
+		if err != nil {
+			return err
+		}
+		categories := []entity.Category{}
+		if len(ids) > 0 {
+			categories, err = gorm.G[entity.Category](tx).Where("cat_id IN ?", ids).Order("cat_name").Find(ctx)
+			if err != nil {
+				return err
+			}
+			if len(categories) != len(ids) {
+				return entity.ErrUnknownCategory
+			}
+		}
+		product := before
+		if err := tx.Model(&product).Association("Categories").Replace(categories); err != nil {
+			return err
+		}
+		product.Categories = categories
+		return createAudits(ctx, tx, *entity.NewProductAudit(ctx, entity.AuditCategories, productID, &before, &product))
+	})
+	return translateError(err, entity.ErrProductNotFound, entity.ErrProductDuplicate)
 }
 
-func (p *Product) Count() (int64, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+// AddImage saves the metadata of an image of the product, and audits it.
+// The product must exist and not be deleted. Like the categories, it doesn't
+// bump the version.
+func (p *Product) AddImage(ctx context.Context, image *entity.ProductImage) error {
+	ctx, cancel := p.queryContext(ctx, "Product.AddImage")
 	defer cancel()
 
-	return gorm.G[entity.Product](p.db).Count(ctx, "prd_id")
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		before, err := gorm.G[entity.Product](tx).
+			Preload("Images", func(db gorm.PreloadBuilder) error {
+				db.Order("pim_created_at, pim_id")
+				return nil
+			}).
+			Where("prd_id = ?", image.ProductID).
+			First(ctx)
+		if err != nil {
+			return err
+		}
+		if err := gorm.G[entity.ProductImage](tx).Create(ctx, image); err != nil {
+			return err
+		}
+		after := before
+		after.Images = append(slices.Clip(before.Images), *image)
+		return createAudits(ctx, tx, *entity.NewProductAudit(ctx, entity.AuditImage, image.ProductID, &before, &after))
+	})
+	return translateError(err, entity.ErrProductNotFound, entity.ErrProductDuplicate)
+}
+
+// createAudits appends the audits in the transaction of the change.
+func createAudits(ctx context.Context, tx *gorm.DB, audits ...entity.ProductAudit) error {
+	return gorm.G[entity.ProductAudit](tx).CreateInBatches(ctx, &audits, createBatchSize)
+}
+
+func trash(db *gorm.DB) gorm.ChainInterface[entity.Product] {
+	return gorm.G[entity.Product](db).Scopes(unscoped).Where("deleted_at IS NOT NULL")
+}
+
+// filtered applies every field set in filter. The full-text search uses
+// the tsvector index on Postgres and falls back to LIKE on other databases.
+func (p *Product) filtered(filter ProductFilter) gorm.ChainInterface[entity.Product] {
+	query := gorm.G[entity.Product](p.db).Scopes()
+	if filter.Name != "" {
+		query = query.Where(nameLike, likePattern(filter.Name))
+	}
+	if filter.Currency != "" {
+		query = query.Where("prd_price_currency = ?", filter.Currency)
+	}
+	if filter.MinPrice != nil {
+		query = query.Where("prd_price_amount >= ?", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		query = query.Where("prd_price_amount <= ?", *filter.MaxPrice)
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	if filter.Category != (pkgEntity.ID{}) {
+		query = query.Where("prd_id IN (SELECT pct_prd_id FROM product_categories WHERE pct_cat_id = ?)", filter.Category)
+	}
+	if terms := strings.Fields(filter.Query); len(terms) > 0 {
+		if p.db.Dialector.Name() == "postgres" {
+			query = query.Where(productSearchVector+" @@ plainto_tsquery('simple', ?)", filter.Query)
+		} else {
+			for _, term := range terms {
+				query = query.Where(nameLike, likePattern(term))
+			}
+		}
+	}
+	return query
+}
+
+// productSearchVector must match the expression of the idx_products_search
+// index of the postgres migrations, otherwise Postgres won't use it.
+const productSearchVector = "to_tsvector('simple', prd_name)"
+
+// nameLike matches prd_name with a likePattern. The escape character is
+// '!' because a backslash would need escaping itself in the string literals
+// of MySQL, '!' is read the same way by every dialect.
+const (
+	nameLike   = "LOWER(prd_name) LIKE ? ESCAPE '" + likeEscape + "'"
+	likeEscape = "!"
+)
+
+// likePattern lowercases value, escapes the LIKE wildcards and wraps it in %.
+func likePattern(value string) string {
+	value = strings.NewReplacer(likeEscape, likeEscape+likeEscape, "%", likeEscape+"%", "_", likeEscape+"_").
+		Replace(strings.ToLower(value))
+	return "%" + value + "%"
 }
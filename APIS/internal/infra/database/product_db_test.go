@@ -1,30 +1,29 @@
 package database
 
 import (
+	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/database/dbtest"
+	pkgEntity "github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
 func setupProductTestDB(t *testing.T) *gorm.DB {
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
-		DisableForeignKeyConstraintWhenMigrating: true,
-	})
-	require.NoError(t, err)
-
-	err = db.AutoMigrate(&entity.Product{})
-	require.NoError(t, err)
-
-	return db
+	return dbtest.Open(t, dbtest.ProductTables...)
 }
 
 func TestNewProduct(t *testing.T) {
 	db := setupProductTestDB(t)
-	productDB := NewProductDB(db)
+	productDB := NewProductDB(db, 0)
 
 	assert.NotNil(t, productDB)
 	assert.NotNil(t, productDB.db)
@@ -33,12 +32,12 @@ func TestNewProduct(t *testing.T) {
 func TestProduct_Create(t *testing.T) {
 	t.Run("should create product successfully", func(t *testing.T) {
 		db := setupProductTestDB(t)
-		productDB := NewProductDB(db)
+		productDB := NewProductDB(db, 0)
 
-		product, err := entity.NewProduct("Laptop", 999.99)
+		product, err := entity.NewProduct("Laptop", pkgEntity.NewMoney(99999, "BRL"))
 		require.NoError(t, err)
 
-		err = productDB.Create(product)
+		err = productDB.Create(context.Background(), product)
 		assert.NoError(t, err)
 
 		var count int64
@@ -48,16 +47,16 @@ func TestProduct_Create(t *testing.T) {
 
 	t.Run("should create multiple products", func(t *testing.T) {
 		db := setupProductTestDB(t)
-		productDB := NewProductDB(db)
+		productDB := NewProductDB(db, 0)
 
-		product1, err := entity.NewProduct("Laptop", 999.99)
+		product1, err := entity.NewProduct("Laptop", pkgEntity.NewMoney(99999, "BRL"))
 		require.NoError(t, err)
-		err = productDB.Create(product1)
+		err = productDB.Create(context.Background(), product1)
 		require.NoError(t, err)
 
-		product2, err := entity.NewProduct("Mouse", 29.99)
+		product2, err := entity.NewProduct("Mouse", pkgEntity.NewMoney(2999, "BRL"))
 		require.NoError(t, err)
-		err = productDB.Create(product2)
+		err = productDB.Create(context.Background(), product2)
 		require.NoError(t, err)
 
 		var count int64
@@ -69,15 +68,15 @@ func TestProduct_Create(t *testing.T) {
 func TestProduct_FindByID(t *testing.T) {
 	t.Run("should find product by ID successfully", func(t *testing.T) {
 		db := setupProductTestDB(t)
-		productDB := NewProductDB(db)
+		productDB := NewProductDB(db, 0)
 
-		product, err := entity.NewProduct("Keyboard", 79.99)
+		product, err := entity.NewProduct("Keyboard", pkgEntity.NewMoney(7999, "BRL"))
 		require.NoError(t, err)
 
-		err = productDB.Create(product)
+		err = productDB.Create(context.Background(), product)
 		require.NoError(t, err)
 
-		foundProduct, err := productDB.FindByID(product.ID.String())
+		foundProduct, err := productDB.FindByID(context.Background(), product.ID.String())
 		assert.NoError(t, err)
 		assert.NotNil(t, foundProduct)
 		assert.Equal(t, product.ID, foundProduct.ID)
@@ -87,19 +86,20 @@ func TestProduct_FindByID(t *testing.T) {
 
 	t.Run("should return error when product not found", func(t *testing.T) {
 		db := setupProductTestDB(t)
-		productDB := NewProductDB(db)
+		productDB := NewProductDB(db, 0)
 
-		foundProduct, err := productDB.FindByID("019ab24a-dc97-72a4-9056-cc09f4c13bef")
+		foundProduct, err := productDB.FindByID(context.Background(), "019ab24a-dc97-72a4-9056-cc09f4c13bef")
 		assert.Error(t, err)
-		assert.Equal(t, gorm.ErrRecordNotFound, err)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		assert.ErrorIs(t, err, entity.ErrProductNotFound)
 		assert.NotNil(t, foundProduct)
 	})
 
 	t.Run("should return error for invalid ID format", func(t *testing.T) {
 		db := setupProductTestDB(t)
-		productDB := NewProductDB(db)
+		productDB := NewProductDB(db, 0)
 
-		foundProduct, err := productDB.FindByID("invalid-uuid")
+		foundProduct, err := productDB.FindByID(context.Background(), "invalid-uuid")
 		assert.Error(t, err)
 		assert.Nil(t, foundProduct)
 	})
@@ -108,36 +108,36 @@ func TestProduct_FindByID(t *testing.T) {
 func TestProduct_FindAll(t *testing.T) {
 	t.Run("should find all products with pagination", func(t *testing.T) {
 		db := setupProductTestDB(t)
-		productDB := NewProductDB(db)
+		productDB := NewProductDB(db, 0)
 
 		// Create test products
 		products := []struct {
 			name  string
-			price float64
+			price int64
 		}{
-			{"Product A", 10.00},
-			{"Product C", 30.00},
-			{"Product E", 50.00},
-			{"Product D", 40.00},
-			{"Product B", 20.00},
+			{"Product A", 1000},
+			{"Product C", 3000},
+			{"Product E", 5000},
+			{"Product D", 4000},
+			{"Product B", 2000},
 		}
 
 		for _, p := range products {
-			product, err := entity.NewProduct(p.name, p.price)
+			product, err := entity.NewProduct(p.name, pkgEntity.NewMoney(p.price, "BRL"))
 			require.NoError(t, err)
-			err = productDB.Create(product)
+			err = productDB.Create(context.Background(), product)
 			require.NoError(t, err)
 		}
 
 		// Test first page
-		result, err := productDB.FindAll(1, 2, "prd_name asc")
+		result, err := productDB.FindAll(context.Background(), ProductFilter{}, 1, 2, "prd_name asc")
 		assert.NoError(t, err)
 		assert.Len(t, result, 2)
 		assert.Equal(t, "Product A", result[0].Name)
 		assert.Equal(t, "Product B", result[1].Name)
 
 		// Test second page
-		result, err = productDB.FindAll(2, 2, "prd_name asc")
+		result, err = productDB.FindAll(context.Background(), ProductFilter{}, 2, 2, "prd_name asc")
 		assert.NoError(t, err)
 		assert.Len(t, result, 2)
 		assert.Equal(t, "Product C", result[0].Name)
@@ -146,26 +146,26 @@ func TestProduct_FindAll(t *testing.T) {
 
 	t.Run("should return empty list when no products exist", func(t *testing.T) {
 		db := setupProductTestDB(t)
-		productDB := NewProductDB(db)
+		productDB := NewProductDB(db, 0)
 
-		result, err := productDB.FindAll(1, 10, "prd_name asc")
+		result, err := productDB.FindAll(context.Background(), ProductFilter{}, 1, 10, "prd_name asc")
 		assert.NoError(t, err)
 		assert.Empty(t, result)
 	})
 
 	t.Run("should sort by price descending", func(t *testing.T) {
 		db := setupProductTestDB(t)
-		productDB := NewProductDB(db)
+		productDB := NewProductDB(db, 0)
 
-		product1, _ := entity.NewProduct("Cheap", 10.00)
-		product2, _ := entity.NewProduct("Expensive", 100.00)
-		product3, _ := entity.NewProduct("Medium", 50.00)
+		product1, _ := entity.NewProduct("Cheap", pkgEntity.NewMoney(1000, "BRL"))
+		product2, _ := entity.NewProduct("Expensive", pkgEntity.NewMoney(10000, "BRL"))
+		product3, _ := entity.NewProduct("Medium", pkgEntity.NewMoney(5000, "BRL"))
 
-		productDB.Create(product1)
-		productDB.Create(product2)
-		productDB.Create(product3)
+		productDB.Create(context.Background(), product1)
+		productDB.Create(context.Background(), product2)
+		productDB.Create(context.Background(), product3)
 
-		result, err := productDB.FindAll(1, 10, "prd_price desc")
+		result, err := productDB.FindAll(context.Background(), ProductFilter{}, 1, 10, "prd_price_amount desc")
 		assert.NoError(t, err)
 		assert.Len(t, result, 3)
 		assert.Equal(t, "Expensive", result[0].Name)
@@ -177,65 +177,129 @@ func TestProduct_FindAll(t *testing.T) {
 func TestProduct_Update(t *testing.T) {
 	t.Run("should update product successfully", func(t *testing.T) {
 		db := setupProductTestDB(t)
-		productDB := NewProductDB(db)
+		productDB := NewProductDB(db, 0)
 
-		product, err := entity.NewProduct("Old Name", 99.99)
+		product, err := entity.NewProduct("Old Name", pkgEntity.NewMoney(9999, "BRL"))
 		require.NoError(t, err)
 
-		err = productDB.Create(product)
+		err = productDB.Create(context.Background(), product)
 		require.NoError(t, err)
 
 		// Update product
 		product.Name = "New Name"
-		product.Price = 149.99
+		product.Price = pkgEntity.NewMoney(14999, "BRL")
 
-		err = productDB.Update(product)
+		err = productDB.Update(context.Background(), product)
 		assert.NoError(t, err)
 
 		// Verify update
-		foundProduct, err := productDB.FindByID(product.ID.String())
+		foundProduct, err := productDB.FindByID(context.Background(), product.ID.String())
 		assert.NoError(t, err)
 		assert.Equal(t, "New Name", foundProduct.Name)
-		assert.Equal(t, 149.99, foundProduct.Price)
+		assert.Equal(t, pkgEntity.NewMoney(14999, "BRL"), foundProduct.Price)
 	})
 
 	t.Run("should update only price", func(t *testing.T) {
 		db := setupProductTestDB(t)
-		productDB := NewProductDB(db)
+		productDB := NewProductDB(db, 0)
 
-		product, err := entity.NewProduct("Product", 50.00)
+		product, err := entity.NewProduct("Product", pkgEntity.NewMoney(5000, "BRL"))
 		require.NoError(t, err)
 
-		err = productDB.Create(product)
+		err = productDB.Create(context.Background(), product)
 		require.NoError(t, err)
 
 		// Update only price
-		product.Price = 75.00
+		product.Price = pkgEntity.NewMoney(7500, "BRL")
 
-		err = productDB.Update(product)
+		err = productDB.Update(context.Background(), product)
 		assert.NoError(t, err)
 
 		// Verify update
-		foundProduct, err := productDB.FindByID(product.ID.String())
+		foundProduct, err := productDB.FindByID(context.Background(), product.ID.String())
 		assert.NoError(t, err)
 		assert.Equal(t, "Product", foundProduct.Name)
-		assert.Equal(t, 75.00, foundProduct.Price)
+		assert.Equal(t, pkgEntity.NewMoney(7500, "BRL"), foundProduct.Price)
+	})
+
+	t.Run("should bump the version", func(t *testing.T) {
+		db := setupProductTestDB(t)
+		productDB := NewProductDB(db, 0)
+
+		product, err := entity.NewProduct("Product", pkgEntity.NewMoney(5000, "BRL"))
+		require.NoError(t, err)
+		require.NoError(t, productDB.Create(context.Background(), product))
+		assert.Equal(t, int64(1), product.Version.Int64)
+
+		product.Price = pkgEntity.NewMoney(7500, "BRL")
+		require.NoError(t, productDB.Update(context.Background(), product))
+		assert.Equal(t, int64(2), product.Version.Int64)
+
+		foundProduct, err := productDB.FindByID(context.Background(), product.ID.String())
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), foundProduct.Version.Int64)
+	})
+
+	t.Run("should update only the given fields", func(t *testing.T) {
+		db := setupProductTestDB(t)
+		productDB := NewProductDB(db, 0)
+
+		product, err := entity.NewProduct("Product", pkgEntity.NewMoney(5000, "BRL"))
+		require.NoError(t, err)
+		require.NoError(t, productDB.Create(context.Background(), product))
+
+		product.Name = "Not saved"
+		product.Price = pkgEntity.NewMoney(7500, "BRL")
+		require.NoError(t, productDB.Update(context.Background(), product, "Price"))
+		assert.Equal(t, int64(2), product.Version.Int64)
+
+		foundProduct, err := productDB.FindByID(context.Background(), product.ID.String())
+		require.NoError(t, err)
+		assert.Equal(t, "Product", foundProduct.Name)
+		assert.Equal(t, pkgEntity.NewMoney(7500, "BRL"), foundProduct.Price)
+		assert.Equal(t, int64(2), foundProduct.Version.Int64)
+	})
+
+	t.Run("should reject a stale product", func(t *testing.T) {
+		db := setupProductTestDB(t)
+		productDB := NewProductDB(db, 0)
+
+		product, err := entity.NewProduct("Product", pkgEntity.NewMoney(5000, "BRL"))
+		require.NoError(t, err)
+		require.NoError(t, productDB.Create(context.Background(), product))
+
+		first, err := productDB.FindByID(context.Background(), product.ID.String())
+		require.NoError(t, err)
+		second, err := productDB.FindByID(context.Background(), product.ID.String())
+		require.NoError(t, err)
+
+		first.Price = pkgEntity.NewMoney(6000, "BRL")
+		require.NoError(t, productDB.Update(context.Background(), first))
+
+		second.Price = pkgEntity.NewMoney(7000, "BRL")
+		err = productDB.Update(context.Background(), second)
+		assert.ErrorIs(t, err, entity.ErrProductModified)
+		assert.ErrorIs(t, err, entity.ErrPrecondition)
+
+		foundProduct, err := productDB.FindByID(context.Background(), product.ID.String())
+		require.NoError(t, err)
+		assert.Equal(t, pkgEntity.NewMoney(6000, "BRL"), foundProduct.Price)
 	})
 }
 
 func TestProduct_Delete(t *testing.T) {
 	t.Run("should delete product successfully", func(t *testing.T) {
 		db := setupProductTestDB(t)
-		productDB := NewProductDB(db)
+		productDB := NewProductDB(db, 0)
 
-		product, err := entity.NewProduct("To Delete", 99.99)
+		product, err := entity.NewProduct("To Delete", pkgEntity.NewMoney(9999, "BRL"))
 		require.NoError(t, err)
 
-		err = productDB.Create(product)
+		err = productDB.Create(context.Background(), product)
 		require.NoError(t, err)
 
 		// Delete product
-		err = productDB.Delete(product.ID.String())
+		err = productDB.Delete(context.Background(), product.ID.String(), product.Version.Int64)
 		assert.NoError(t, err)
 
 		// Verify deletion (soft delete)
@@ -244,25 +308,40 @@ func TestProduct_Delete(t *testing.T) {
 		assert.Equal(t, int64(1), count)
 
 		// Verify not found in normal query
-		foundProduct, err := productDB.FindByID(product.ID.String())
+		foundProduct, err := productDB.FindByID(context.Background(), product.ID.String())
 		assert.Error(t, err)
-		assert.Equal(t, gorm.ErrRecordNotFound, err)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
 		assert.NotNil(t, foundProduct)
 	})
 
 	t.Run("should return error for invalid ID format", func(t *testing.T) {
 		db := setupProductTestDB(t)
-		productDB := NewProductDB(db)
+		productDB := NewProductDB(db, 0)
 
-		err := productDB.Delete("invalid-uuid")
+		err := productDB.Delete(context.Background(), "invalid-uuid", 1)
 		assert.Error(t, err)
 	})
 
-	t.Run("should not error when deleting non-existent product", func(t *testing.T) {
+	t.Run("should return not found when deleting non-existent product", func(t *testing.T) {
+		db := setupProductTestDB(t)
+		productDB := NewProductDB(db, 0)
+
+		err := productDB.Delete(context.Background(), "019ab24a-dc97-72a4-9056-cc09f4c13bef", 1)
+		assert.ErrorIs(t, err, entity.ErrProductNotFound)
+	})
+
+	t.Run("should not delete a product at another version", func(t *testing.T) {
 		db := setupProductTestDB(t)
-		productDB := NewProductDB(db)
+		productDB := NewProductDB(db, 0)
 
-		err := productDB.Delete("019ab24a-dc97-72a4-9056-cc09f4c13bef")
+		product, err := entity.NewProduct("Product", pkgEntity.NewMoney(1000, "BRL"))
+		require.NoError(t, err)
+		require.NoError(t, productDB.Create(context.Background(), product))
+
+		err = productDB.Delete(context.Background(), product.ID.String(), product.Version.Int64+1)
+		assert.ErrorIs(t, err, entity.ErrProductModified)
+
+		_, err = productDB.FindByID(context.Background(), product.ID.String())
 		assert.NoError(t, err)
 	})
 }
@@ -270,72 +349,509 @@ func TestProduct_Delete(t *testing.T) {
 func TestProduct_Count(t *testing.T) {
 	t.Run("should return zero when no products exist", func(t *testing.T) {
 		db := setupProductTestDB(t)
-		productDB := NewProductDB(db)
+		productDB := NewProductDB(db, 0)
 
-		count, err := productDB.Count()
+		count, err := productDB.Count(context.Background(), ProductFilter{})
 		assert.NoError(t, err)
 		assert.Equal(t, int64(0), count)
 	})
 
 	t.Run("should return correct count with products", func(t *testing.T) {
 		db := setupProductTestDB(t)
-		productDB := NewProductDB(db)
+		productDB := NewProductDB(db, 0)
 
 		// Create test products
 		for i := 1; i <= 5; i++ {
-			product, err := entity.NewProduct("Product", 10.00)
+			product, err := entity.NewProduct("Product", pkgEntity.NewMoney(1000, "BRL"))
 			require.NoError(t, err)
-			err = productDB.Create(product)
+			err = productDB.Create(context.Background(), product)
 			require.NoError(t, err)
 		}
 
-		count, err := productDB.Count()
+		count, err := productDB.Count(context.Background(), ProductFilter{})
 		assert.NoError(t, err)
 		assert.Equal(t, int64(5), count)
 	})
 
 	t.Run("should not count deleted products", func(t *testing.T) {
 		db := setupProductTestDB(t)
-		productDB := NewProductDB(db)
+		productDB := NewProductDB(db, 0)
 
 		// Create products
-		product1, _ := entity.NewProduct("Product 1", 10.00)
-		product2, _ := entity.NewProduct("Product 2", 20.00)
-		product3, _ := entity.NewProduct("Product 3", 30.00)
+		product1, _ := entity.NewProduct("Product 1", pkgEntity.NewMoney(1000, "BRL"))
+		product2, _ := entity.NewProduct("Product 2", pkgEntity.NewMoney(2000, "BRL"))
+		product3, _ := entity.NewProduct("Product 3", pkgEntity.NewMoney(3000, "BRL"))
 
-		productDB.Create(product1)
-		productDB.Create(product2)
-		productDB.Create(product3)
+		productDB.Create(context.Background(), product1)
+		productDB.Create(context.Background(), product2)
+		productDB.Create(context.Background(), product3)
 
 		// Delete one product
-		err := productDB.Delete(product2.ID.String())
+		err := productDB.Delete(context.Background(), product2.ID.String(), product2.Version.Int64)
 		require.NoError(t, err)
 
 		// Count should be 2 (soft delete)
-		count, err := productDB.Count()
+		count, err := productDB.Count(context.Background(), ProductFilter{})
 		assert.NoError(t, err)
 		assert.Equal(t, int64(2), count)
 	})
 
 	t.Run("should return count after updates", func(t *testing.T) {
 		db := setupProductTestDB(t)
-		productDB := NewProductDB(db)
+		productDB := NewProductDB(db, 0)
 
 		// Create product
-		product, err := entity.NewProduct("Original", 50.00)
+		product, err := entity.NewProduct("Original", pkgEntity.NewMoney(5000, "BRL"))
 		require.NoError(t, err)
-		err = productDB.Create(product)
+		err = productDB.Create(context.Background(), product)
 		require.NoError(t, err)
 
 		// Update product
 		product.Name = "Updated"
-		product.Price = 75.00
-		err = productDB.Update(product)
+		product.Price = pkgEntity.NewMoney(7500, "BRL")
+		err = productDB.Update(context.Background(), product)
 		require.NoError(t, err)
 
 		// Count should still be 1
-		count, err := productDB.Count()
+		count, err := productDB.Count(context.Background(), ProductFilter{})
 		assert.NoError(t, err)
 		assert.Equal(t, int64(1), count)
 	})
 }
+
+func TestProduct_FindAllFiltered(t *testing.T) {
+	db := setupProductTestDB(t)
+	productDB := NewProductDB(db, 0)
+
+	products := []struct {
+		name     string
+		price    int64
+		currency string
+		created  time.Time
+	}{
+		{"Gaming Laptop", 250000, "BRL", time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)},
+		{"Office Laptop", 90000, "BRL", time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC)},
+		{"Gaming Mouse", 8000, "BRL", time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)},
+		{"100% Cotton Mouse Pad", 1500, "BRL", time.Date(2025, 4, 10, 0, 0, 0, 0, time.UTC)},
+		{"Travel Adapter", 3000, "USD", time.Date(2025, 5, 10, 0, 0, 0, 0, time.UTC)},
+	}
+	var laptops []string
+	for _, p := range products {
+		product, err := entity.NewProduct(p.name, pkgEntity.NewMoney(p.price, p.currency))
+		require.NoError(t, err)
+		product.CreatedAt = p.created
+		require.NoError(t, productDB.Create(context.Background(), product))
+		if strings.HasSuffix(p.name, "Laptop") {
+			laptops = append(laptops, product.ID.String())
+		}
+	}
+	computers, _ := entity.NewCategory("Computers")
+	require.NoError(t, NewCategoryDB(db, 0).Create(context.Background(), computers))
+	for _, id := range laptops {
+		require.NoError(t, productDB.SetCategories(context.Background(), id, []string{computers.ID.String()}))
+	}
+
+	// Prices are filtered in cents of the currency
+	price := func(v int64) *int64 { return &v }
+	date := func(v time.Time) *time.Time { return &v }
+
+	tests := []struct {
+		name   string
+		filter ProductFilter
+		want   []string
+	}{
+		{name: "no filter", filter: ProductFilter{}, want: []string{"100% Cotton Mouse Pad", "Gaming Laptop", "Gaming Mouse", "Office Laptop", "Travel Adapter"}},
+		{name: "name contains, case insensitive", filter: ProductFilter{Name: "laptop"}, want: []string{"Gaming Laptop", "Office Laptop"}},
+		{name: "name with wildcard", filter: ProductFilter{Name: "100%"}, want: []string{"100% Cotton Mouse Pad"}},
+		{name: "price range", filter: ProductFilter{Currency: "BRL", MinPrice: price(5000), MaxPrice: price(100000)}, want: []string{"Gaming Mouse", "Office Laptop"}},
+		{name: "currency", filter: ProductFilter{Currency: "USD"}, want: []string{"Travel Adapter"}},
+		{name: "price in another currency", filter: ProductFilter{Currency: "BRL", MaxPrice: price(5000)}, want: []string{"100% Cotton Mouse Pad"}},
+		{name: "created after", filter: ProductFilter{CreatedAfter: date(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))}, want: []string{"100% Cotton Mouse Pad", "Gaming Mouse", "Travel Adapter"}},
+		{name: "created before", filter: ProductFilter{CreatedBefore: date(time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC))}, want: []string{"Gaming Laptop"}},
+		{name: "search matches every term", filter: ProductFilter{Query: "gaming mouse"}, want: []string{"Gaming Mouse"}},
+		{name: "search and price", filter: ProductFilter{Query: "gaming", Currency: "BRL", MaxPrice: price(10000)}, want: []string{"Gaming Mouse"}},
+		{name: "category", filter: ProductFilter{Category: computers.ID}, want: []string{"Gaming Laptop", "Office Laptop"}},
+		{name: "category and price", filter: ProductFilter{Category: computers.ID, Currency: "BRL", MaxPrice: price(100000)}, want: []string{"Office Laptop"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := productDB.FindAll(context.Background(), tt.filter, 1, 10, "prd_name asc")
+			require.NoError(t, err)
+			names := []string{}
+			for _, product := range result {
+				names = append(names, product.Name)
+			}
+			assert.Equal(t, tt.want, names)
+
+			count, err := productDB.Count(context.Background(), tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(tt.want)), count, "Count must match the filtered set")
+		})
+	}
+}
+
+func TestProduct_FindByCursor(t *testing.T) {
+	db := setupProductTestDB(t)
+	productDB := NewProductDB(db, 0)
+
+	var ids []pkgEntity.ID
+	for _, name := range []string{"A", "B", "C"} {
+		product, err := entity.NewProduct(name, pkgEntity.NewMoney(1000, "BRL"))
+		require.NoError(t, err)
+		require.NoError(t, productDB.Create(context.Background(), product))
+		ids = append(ids, product.ID)
+	}
+
+	t.Run("should read forward from the start", func(t *testing.T) {
+		result, more, err := productDB.FindByCursor(context.Background(), ProductFilter{}, nil, false, 2)
+		require.NoError(t, err)
+		assert.True(t, more)
+		require.Len(t, result, 2)
+		assert.Equal(t, ids[0], result[0].ID)
+		assert.Equal(t, ids[1], result[1].ID)
+	})
+
+	t.Run("should read after the cursor", func(t *testing.T) {
+		cursor := &pkgEntity.Cursor{ID: ids[1]}
+		result, more, err := productDB.FindByCursor(context.Background(), ProductFilter{}, cursor, false, 2)
+		require.NoError(t, err)
+		assert.False(t, more)
+		require.Len(t, result, 1)
+		assert.Equal(t, ids[2], result[0].ID)
+	})
+
+	t.Run("should read backward in sort order", func(t *testing.T) {
+		cursor := &pkgEntity.Cursor{ID: ids[2], Backward: true}
+		result, more, err := productDB.FindByCursor(context.Background(), ProductFilter{}, cursor, false, 1)
+		require.NoError(t, err)
+		assert.True(t, more)
+		require.Len(t, result, 1)
+		assert.Equal(t, ids[1], result[0].ID)
+	})
+
+	t.Run("should read descending", func(t *testing.T) {
+		cursor := &pkgEntity.Cursor{ID: ids[2], Desc: true}
+		result, more, err := productDB.FindByCursor(context.Background(), ProductFilter{}, cursor, true, 5)
+		require.NoError(t, err)
+		assert.False(t, more)
+		require.Len(t, result, 2)
+		assert.Equal(t, ids[1], result[0].ID)
+		assert.Equal(t, ids[0], result[1].ID)
+	})
+
+	t.Run("should reject a limit below 1", func(t *testing.T) {
+		for _, limit := range []int{0, -1} {
+			_, _, err := productDB.FindByCursor(context.Background(), ProductFilter{}, nil, false, limit)
+			assert.ErrorIs(t, err, entity.ErrInvalidLimit)
+		}
+	})
+}
+
+// The filters only run on sqlite in the tests, the SQL rendered for the
+// other dialects must still be valid there. A backslash in a string literal
+// is an escape in MySQL, '\' would never end.
+func TestProduct_FilterSQL(t *testing.T) {
+	dialectors := map[string]gorm.Dialector{
+		"sqlite":   sqlite.Open(":memory:"),
+		"postgres": postgres.New(postgres.Config{DSN: "host=localhost"}),
+		"mysql":    mysql.New(mysql.Config{DSN: "user@tcp(localhost:3306)/db", SkipInitializeWithVersion: true}),
+	}
+	for name, dialector := range dialectors {
+		t.Run(name, func(t *testing.T) {
+			db, err := gorm.Open(dialector, &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+			require.NoError(t, err)
+			var sql string
+			var vars []any
+			err = db.Callback().Query().After("gorm:query").Register("test:capture", func(tx *gorm.DB) {
+				if sql == "" {
+					sql, vars = tx.Statement.SQL.String(), tx.Statement.Vars
+				}
+			})
+			require.NoError(t, err)
+
+			filter := ProductFilter{Name: "50%_off!", Query: "gaming"}
+			_, err = NewProductDB(db, 0).FindAll(context.Background(), filter, 1, 10, "prd_name asc")
+			require.NoError(t, err)
+			assert.Contains(t, sql, "ESCAPE '!'")
+			assert.NotContains(t, sql, `\`)
+			assert.Contains(t, vars, "%50!%!_off!!%")
+		})
+	}
+}
+
+func TestProduct_CreateBatch(t *testing.T) {
+	t.Run("should create every product", func(t *testing.T) {
+		db := setupProductTestDB(t)
+		productDB := NewProductDB(db, 0)
+
+		var products []entity.Product
+		for range createBatchSize + 1 {
+			product, err := entity.NewProduct("Product", pkgEntity.NewMoney(1000, "BRL"))
+			require.NoError(t, err)
+			products = append(products, *product)
+		}
+
+		require.NoError(t, productDB.CreateBatch(context.Background(), products))
+
+		count, err := productDB.Count(context.Background(), ProductFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(createBatchSize+1), count)
+	})
+
+	t.Run("should save nothing when a product fails", func(t *testing.T) {
+		db := setupProductTestDB(t)
+		productDB := NewProductDB(db, 0)
+
+		existing, err := entity.NewProduct("Existing", pkgEntity.NewMoney(1000, "BRL"))
+		require.NoError(t, err)
+		require.NoError(t, productDB.Create(context.Background(), existing))
+
+		product, err := entity.NewProduct("Product", pkgEntity.NewMoney(1000, "BRL"))
+		require.NoError(t, err)
+		err = productDB.CreateBatch(context.Background(), []entity.Product{*product, *existing})
+		assert.ErrorIs(t, err, entity.ErrProductDuplicate)
+
+		count, err := productDB.Count(context.Background(), ProductFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+}
+
+func TestProduct_Trash(t *testing.T) {
+	t.Run("should list and count only deleted products", func(t *testing.T) {
+		db := setupProductTestDB(t)
+		productDB := NewProductDB(db, 0)
+
+		kept, _ := entity.NewProduct("Kept", pkgEntity.NewMoney(1000, "BRL"))
+		deleted, _ := entity.NewProduct("Deleted", pkgEntity.NewMoney(2000, "BRL"))
+		require.NoError(t, productDB.Create(context.Background(), kept))
+		require.NoError(t, productDB.Create(context.Background(), deleted))
+		require.NoError(t, productDB.Delete(context.Background(), deleted.ID.String(), deleted.Version.Int64))
+
+		items, err := productDB.FindDeleted(context.Background(), 1, 10, "prd_name asc")
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, deleted.ID, items[0].ID)
+		assert.True(t, items[0].DeletedAt.Valid)
+
+		count, err := productDB.CountDeleted(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("should restore a deleted product", func(t *testing.T) {
+		db := setupProductTestDB(t)
+		productDB := NewProductDB(db, 0)
+
+		product, _ := entity.NewProduct("Product", pkgEntity.NewMoney(1000, "BRL"))
+		require.NoError(t, productDB.Create(context.Background(), product))
+		require.NoError(t, productDB.Delete(context.Background(), product.ID.String(), product.Version.Int64))
+
+		require.NoError(t, productDB.Restore(context.Background(), product.ID.String()))
+
+		found, err := productDB.FindByID(context.Background(), product.ID.String())
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), found.Version.Int64)
+
+		count, err := productDB.CountDeleted(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("should not restore a product that is not deleted", func(t *testing.T) {
+		db := setupProductTestDB(t)
+		productDB := NewProductDB(db, 0)
+
+		product, _ := entity.NewProduct("Product", pkgEntity.NewMoney(1000, "BRL"))
+		require.NoError(t, productDB.Create(context.Background(), product))
+
+		err := productDB.Restore(context.Background(), product.ID.String())
+		assert.ErrorIs(t, err, entity.ErrProductNotFound)
+		err = productDB.Restore(context.Background(), "019ab24a-dc97-72a4-9056-cc09f4c13bef")
+		assert.ErrorIs(t, err, entity.ErrProductNotFound)
+	})
+
+	t.Run("should purge only products deleted before the limit", func(t *testing.T) {
+		db := setupProductTestDB(t)
+		productDB := NewProductDB(db, 0)
+
+		old, _ := entity.NewProduct("Old", pkgEntity.NewMoney(1000, "BRL"))
+		recent, _ := entity.NewProduct("Recent", pkgEntity.NewMoney(1000, "BRL"))
+		active, _ := entity.NewProduct("Active", pkgEntity.NewMoney(1000, "BRL"))
+		for _, product := range []*entity.Product{old, recent, active} {
+			require.NoError(t, productDB.Create(context.Background(), product))
+		}
+		category, _ := entity.NewCategory("Category")
+		require.NoError(t, NewCategoryDB(db, 0).Create(context.Background(), category))
+		var oldImage string
+		for _, product := range []*entity.Product{old, recent} {
+			require.NoError(t, productDB.SetCategories(context.Background(), product.ID.String(), []string{category.ID.String()}))
+			image, _ := entity.NewProductImage(product.ID, "image/png", 10)
+			require.NoError(t, productDB.AddImage(context.Background(), image))
+			if product == old {
+				oldImage = image.Key
+			}
+		}
+		require.NoError(t, productDB.Delete(context.Background(), old.ID.String(), old.Version.Int64))
+		require.NoError(t, productDB.Delete(context.Background(), recent.ID.String(), recent.Version.Int64))
+		db.Model(&entity.Product{}).Unscoped().Where("prd_id = ?", old.ID).
+			Update("deleted_at", time.Now().AddDate(0, 0, -40))
+
+		purged, imageKeys, err := productDB.Purge(context.Background(), time.Now().AddDate(0, 0, -30))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), purged)
+		assert.Equal(t, []string{oldImage}, imageKeys, "The files of the old product must be deleted by the caller")
+
+		var count int64
+		db.Model(&entity.Product{}).Unscoped().Count(&count)
+		assert.Equal(t, int64(2), count, "The old product must be gone for good")
+		db.Table("product_categories").Count(&count)
+		assert.Equal(t, int64(1), count, "The tags of the old product must be purged with it")
+		db.Model(&entity.ProductImage{}).Count(&count)
+		assert.Equal(t, int64(1), count, "The images of the old product must be purged with it")
+		deleted, err := productDB.CountDeleted(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+	})
+}
+
+func TestProduct_History(t *testing.T) {
+	t.Run("should audit every change with its actor", func(t *testing.T) {
+		db := setupProductTestDB(t)
+		productDB := NewProductDB(db, 0)
+		ctx := entity.ContextWithActor(context.Background(), "019ab7b2-26bf-7f2e-b1c2-320248d7b796")
+
+		product, err := entity.NewProduct("Product", pkgEntity.NewMoney(1000, "BRL"))
+		require.NoError(t, err)
+		require.NoError(t, productDB.Create(ctx, product))
+		product.Price = pkgEntity.NewMoney(1500, "BRL")
+		require.NoError(t, productDB.Update(ctx, product, "Price"))
+		require.NoError(t, productDB.Delete(ctx, product.ID.String(), product.Version.Int64))
+		require.NoError(t, productDB.Restore(ctx, product.ID.String()))
+
+		audits, err := productDB.History(context.Background(), product.ID.String(), 1, 10, "aud_id asc")
+		require.NoError(t, err)
+		require.Len(t, audits, 4)
+
+		var actions []string
+		for _, audit := range audits {
+			actions = append(actions, audit.Action)
+			assert.Equal(t, "019ab7b2-26bf-7f2e-b1c2-320248d7b796", audit.UserID)
+		}
+		assert.Equal(t, []string{entity.AuditCreate, entity.AuditUpdate, entity.AuditDelete, entity.AuditRestore}, actions)
+
+		assert.Nil(t, audits[0].Before)
+		assert.Equal(t, &entity.ProductState{Name: "Product", Price: pkgEntity.NewMoney(1000, "BRL"), Version: 1}, audits[0].After)
+		assert.Equal(t, &entity.ProductState{Name: "Product", Price: pkgEntity.NewMoney(1000, "BRL"), Version: 1}, audits[1].Before)
+		assert.Equal(t, &entity.ProductState{Name: "Product", Price: pkgEntity.NewMoney(1500, "BRL"), Version: 2}, audits[1].After)
+		assert.Equal(t, &entity.ProductState{Name: "Product", Price: pkgEntity.NewMoney(1500, "BRL"), Version: 2}, audits[2].Before)
+		assert.Nil(t, audits[2].After)
+		assert.Equal(t, &entity.ProductState{Name: "Product", Price: pkgEntity.NewMoney(1500, "BRL"), Version: 3}, audits[3].After)
+
+		count, err := productDB.CountHistory(context.Background(), product.ID.String())
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), count)
+	})
+
+	t.Run("should audit the categories and images", func(t *testing.T) {
+		db := setupProductTestDB(t)
+		productDB := NewProductDB(db, 0)
+		ctx := entity.ContextWithActor(context.Background(), "019ab7b2-26bf-7f2e-b1c2-320248d7b796")
+
+		product, err := entity.NewProduct("Product", pkgEntity.NewMoney(1000, "BRL"))
+		require.NoError(t, err)
+		require.NoError(t, productDB.Create(ctx, product))
+		category, _ := entity.NewCategory("Computers")
+		require.NoError(t, NewCategoryDB(db, 0).Create(ctx, category))
+		require.NoError(t, productDB.SetCategories(ctx, product.ID.String(), []string{category.ID.String()}))
+		image, _ := entity.NewProductImage(product.ID, "image/png", 10)
+		require.NoError(t, productDB.AddImage(ctx, image))
+
+		audits, err := productDB.History(context.Background(), product.ID.String(), 1, 10, "aud_id asc")
+		require.NoError(t, err)
+		require.Len(t, audits, 3)
+
+		assert.Equal(t, entity.AuditCategories, audits[1].Action)
+		assert.Equal(t, "019ab7b2-26bf-7f2e-b1c2-320248d7b796", audits[1].UserID)
+		assert.Empty(t, audits[1].Before.Categories)
+		assert.Equal(t, []string{category.ID.String()}, audits[1].After.Categories)
+		assert.Equal(t, entity.AuditImage, audits[2].Action)
+		assert.Empty(t, audits[2].Before.Images)
+		assert.Equal(t, []string{image.ID.String()}, audits[2].After.Images)
+		assert.Equal(t, int64(1), audits[2].After.Version, "Neither change bumps the version")
+
+		// A rejected change leaves no audit
+		unknown := pkgEntity.NewID().String()
+		assert.ErrorIs(t, productDB.SetCategories(ctx, product.ID.String(), []string{unknown}), entity.ErrUnknownCategory)
+		count, err := productDB.CountHistory(context.Background(), product.ID.String())
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), count)
+	})
+
+	t.Run("should not audit a rejected change", func(t *testing.T) {
+		db := setupProductTestDB(t)
+		productDB := NewProductDB(db, 0)
+
+		product, err := entity.NewProduct("Product", pkgEntity.NewMoney(1000, "BRL"))
+		require.NoError(t, err)
+		require.NoError(t, productDB.Create(context.Background(), product))
+
+		stale := *product
+		stale.Version.Int64 = 5
+		assert.ErrorIs(t, productDB.Update(context.Background(), &stale), entity.ErrProductModified)
+		assert.ErrorIs(t, productDB.Delete(context.Background(), product.ID.String(), 5), entity.ErrProductModified)
+
+		count, err := productDB.CountHistory(context.Background(), product.ID.String())
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("should roll back the change when the audit fails", func(t *testing.T) {
+		db := setupProductTestDB(t)
+		productDB := NewProductDB(db, 0)
+		require.NoError(t, db.Migrator().DropTable(&entity.ProductAudit{}))
+
+		product, err := entity.NewProduct("Product", pkgEntity.NewMoney(1000, "BRL"))
+		require.NoError(t, err)
+		assert.Error(t, productDB.Create(context.Background(), product))
+
+		var count int64
+		db.Model(&entity.Product{}).Unscoped().Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+}
+
+func TestProduct_AddImage(t *testing.T) {
+	db := setupProductTestDB(t)
+	productDB := NewProductDB(db, 0)
+	ctx := context.Background()
+
+	product, _ := entity.NewProduct("Laptop", pkgEntity.NewMoney(99999, "BRL"))
+	require.NoError(t, productDB.Create(ctx, product))
+	first, _ := entity.NewProductImage(product.ID, "image/png", 10)
+	second, _ := entity.NewProductImage(product.ID, "image/jpeg", 20)
+	require.NoError(t, productDB.AddImage(ctx, first))
+	require.NoError(t, productDB.AddImage(ctx, second))
+
+	found, err := productDB.FindByID(ctx, product.ID.String())
+	require.NoError(t, err)
+	require.Len(t, found.Images, 2)
+	assert.Equal(t, first.Key, found.Images[0].Key, "Images are in upload order")
+	assert.Equal(t, "image/jpeg", found.Images[1].ContentType)
+	assert.Equal(t, int64(20), found.Images[1].Size)
+	assert.Equal(t, product.Version.Int64, found.Version.Int64, "Adding an image doesn't bump the version")
+
+	// Saving the loaded product must not duplicate its images
+	found.Name = "Gaming Laptop"
+	require.NoError(t, productDB.Update(ctx, found))
+	var count int64
+	db.Model(&entity.ProductImage{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+
+	require.NoError(t, productDB.Delete(ctx, product.ID.String(), found.Version.Int64))
+	image, _ := entity.NewProductImage(product.ID, "image/png", 10)
+	assert.ErrorIs(t, productDB.AddImage(ctx, image), entity.ErrProductNotFound)
+	image, _ = entity.NewProductImage(pkgEntity.NewID(), "image/png", 10)
+	assert.ErrorIs(t, productDB.AddImage(ctx, image), entity.ErrProductNotFound)
+}
@@ -0,0 +1,32 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// repository holds what every GORM repository shares.
+type repository struct {
+	db      *gorm.DB
+	timeout time.Duration
+}
+
+// queryContext bounds the caller's context with the per query timeout.
+// The caller's cancellation and deadline still apply, a zero timeout adds
+// no limit of its own. method names the repository method in the metrics of
+// its statements.
+func (r repository) queryContext(ctx context.Context, method string) (context.Context, context.CancelFunc) {
+	ctx = context.WithValue(ctx, methodKey{}, method)
+	if r.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, r.timeout)
+}
+
+// unscoped is a scope that lets a query see the soft deleted rows, the
+// generics API has no Unscoped of its own.
+func unscoped(stmt *gorm.Statement) {
+	stmt.Unscoped = true
+}
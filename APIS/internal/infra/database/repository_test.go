@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_QueryContext(t *testing.T) {
+	t.Run("should bound the query with the timeout", func(t *testing.T) {
+		repo := repository{timeout: time.Second}
+
+		ctx, cancel := repo.queryContext(context.Background(), "Test.Method")
+		defer cancel()
+
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+	})
+
+	t.Run("should keep the caller's cancellation", func(t *testing.T) {
+		repo := repository{timeout: time.Minute}
+		parent, cancelParent := context.WithCancel(context.Background())
+
+		ctx, cancel := repo.queryContext(parent, "Test.Method")
+		defer cancel()
+		cancelParent()
+
+		assert.ErrorIs(t, ctx.Err(), context.Canceled)
+	})
+
+	t.Run("should not add a deadline without timeout", func(t *testing.T) {
+		repo := repository{}
+
+		ctx, cancel := repo.queryContext(context.Background(), "Test.Method")
+		defer cancel()
+
+		_, ok := ctx.Deadline()
+		assert.False(t, ok)
+	})
+}
+
+func TestUser_CanceledContext(t *testing.T) {
+	userDB := NewUserDB(setupTestDB(t), time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := userDB.FindByEmail(ctx, "john@example.com")
+	assert.ErrorIs(t, err, context.Canceled)
+}
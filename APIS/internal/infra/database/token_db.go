@@ -0,0 +1,176 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+)
+
+type Token struct {
+	repository
+}
+
+// NewTokenDB returns the repository. Every query is bounded by queryTimeout,
+// zero leaves only the deadline of the caller's context.
+func NewTokenDB(db *gorm.DB, queryTimeout time.Duration) *Token {
+	return &Token{repository{db: db, timeout: queryTimeout}}
+}
+
+func (t *Token) CreateRefreshToken(ctx context.Context, token *entity.RefreshToken) error {
+	ctx, cancel := t.queryContext(ctx, "Token.CreateRefreshToken")
+	defer cancel()
+	err := gorm.G[entity.RefreshToken](t.db).Create(ctx, token)
+	return translateError(err, entity.ErrNotFound, entity.ErrConflict)
+}
+
+func (t *Token) FindRefreshTokenByHash(ctx context.Context, hash string) (*entity.RefreshToken, error) {
+	ctx, cancel := t.queryContext(ctx, "Token.FindRefreshTokenByHash")
+	defer cancel()
+	token, err := gorm.G[entity.RefreshToken](t.db).Where("rtk_hash = ?", hash).First(ctx)
+	return &token, translateError(err, entity.ErrNotFound, entity.ErrConflict)
+}
+
+// RotateRefreshToken revokes the current token and stores its replacement
+// in a single transaction, so a token can only be exchanged once.
+func (t *Token) RotateRefreshToken(ctx context.Context, current, next *entity.RefreshToken) error {
+	ctx, cancel := t.queryContext(ctx, "Token.RotateRefreshToken")
+	defer cancel()
+	err := t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		rows, err := gorm.G[entity.RefreshToken](tx).
+			Where("rtk_id = ? AND rtk_revoked_at IS NULL", current.ID).
+			Updates(ctx, entity.RefreshToken{RevokedAt: &now, ReplacedBy: &next.ID})
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return entity.ErrTokenRevoked
+		}
+		current.RevokedAt = &now
+		current.ReplacedBy = &next.ID
+		return gorm.G[entity.RefreshToken](tx).Create(ctx, next)
+	})
+	return translateError(err, entity.ErrNotFound, entity.ErrConflict)
+}
+
+func (t *Token) RevokeRefreshToken(ctx context.Context, id string) error {
+	ctx, cancel := t.queryContext(ctx, "Token.RevokeRefreshToken")
+	defer cancel()
+
+	tokenID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = gorm.G[entity.RefreshToken](t.db).
+		Where("rtk_id = ? AND rtk_revoked_at IS NULL", tokenID).
+		Update(ctx, "rtk_revoked_at", time.Now())
+	return translateError(err, entity.ErrNotFound, entity.ErrConflict)
+}
+
+func (t *Token) RevokeUserRefreshTokens(ctx context.Context, userID string) error {
+	ctx, cancel := t.queryContext(ctx, "Token.RevokeUserRefreshTokens")
+	defer cancel()
+
+	id, err := parseID(userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = gorm.G[entity.RefreshToken](t.db).
+		Where("rtk_usr_id = ? AND rtk_revoked_at IS NULL", id).
+		Update(ctx, "rtk_revoked_at", time.Now())
+	return translateError(err, entity.ErrNotFound, entity.ErrConflict)
+}
+
+func (t *Token) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	ctx, cancel := t.queryContext(ctx, "Token.RevokeAccessToken")
+	defer cancel()
+	err := gorm.G[entity.RevokedToken](t.db).Create(ctx, &entity.RevokedToken{
+		JTI:       jti,
+		ExpiresAt: expiresAt,
+	})
+	return translateError(err, entity.ErrNotFound, entity.ErrConflict)
+}
+
+func (t *Token) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	ctx, cancel := t.queryContext(ctx, "Token.IsAccessTokenRevoked")
+	defer cancel()
+	count, err := gorm.G[entity.RevokedToken](t.db).Where("rvk_jti = ?", jti).Count(ctx, "rvk_jti")
+	return count > 0, translateError(err, entity.ErrNotFound, entity.ErrConflict)
+}
+
+// Purge permanently removes the refresh, revoked and password reset tokens
+// that expired before expiredBefore. Once expired they are rejected anyway.
+func (t *Token) Purge(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	ctx, cancel := t.queryContext(ctx, "Token.Purge")
+	defer cancel()
+
+	var purged int
+	err := t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		rows, err := gorm.G[entity.RefreshToken](tx).Scopes(unscoped).Where("rtk_expires_at < ?", expiredBefore).Delete(ctx)
+		if err != nil {
+			return err
+		}
+		purged += rows
+		rows, err = gorm.G[entity.RevokedToken](tx).Scopes(unscoped).Where("rvk_expires_at < ?", expiredBefore).Delete(ctx)
+		if err != nil {
+			return err
+		}
+		purged += rows
+		rows, err = gorm.G[entity.PasswordResetToken](tx).Scopes(unscoped).Where("prt_expires_at < ?", expiredBefore).Delete(ctx)
+		purged += rows
+		return err
+	})
+	if err != nil {
+		return 0, translateError(err, entity.ErrNotFound, entity.ErrConflict)
+	}
+	return int64(purged), nil
+}
+
+func (t *Token) CreatePasswordResetToken(ctx context.Context, token *entity.PasswordResetToken) error {
+	ctx, cancel := t.queryContext(ctx, "Token.CreatePasswordResetToken")
+	defer cancel()
+	err := gorm.G[entity.PasswordResetToken](t.db).Create(ctx, token)
+	return translateError(err, entity.ErrNotFound, entity.ErrConflict)
+}
+
+func (t *Token) FindPasswordResetTokenByHash(ctx context.Context, hash string) (*entity.PasswordResetToken, error) {
+	ctx, cancel := t.queryContext(ctx, "Token.FindPasswordResetTokenByHash")
+	defer cancel()
+	token, err := gorm.G[entity.PasswordResetToken](t.db).Where("prt_hash = ?", hash).First(ctx)
+	return &token, translateError(err, entity.ErrNotFound, entity.ErrConflict)
+}
+
+// UsePasswordResetToken marks the token as used. Only the first call
+// succeeds, the next ones return entity.ErrTokenRevoked. The other tokens
+// of the user are used up in the same transaction, one reset is enough.
+func (t *Token) UsePasswordResetToken(ctx context.Context, token *entity.PasswordResetToken) error {
+	ctx, cancel := t.queryContext(ctx, "Token.UsePasswordResetToken")
+	defer cancel()
+
+	now := time.Now()
+	err := t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		rows, err := gorm.G[entity.PasswordResetToken](tx).
+			Where("prt_id = ? AND prt_used_at IS NULL", token.ID).
+			Update(ctx, "prt_used_at", now)
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return entity.ErrTokenRevoked
+		}
+		_, err = gorm.G[entity.PasswordResetToken](tx).
+			Where("prt_usr_id = ? AND prt_used_at IS NULL", token.UserID).
+			Update(ctx, "prt_used_at", now)
+		return err
+	})
+	if err != nil {
+		return translateError(err, entity.ErrNotFound, entity.ErrConflict)
+	}
+	token.UsedAt = &now
+	return nil
+}
@@ -0,0 +1,261 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/database/dbtest"
+	pkgEntity "github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupTokenTestDB(t *testing.T) *gorm.DB {
+	return dbtest.Open(t, &entity.RefreshToken{}, &entity.RevokedToken{}, &entity.PasswordResetToken{})
+}
+
+func TestToken_CreateAndFindRefreshToken(t *testing.T) {
+	t.Run("should find refresh token by hash", func(t *testing.T) {
+		db := setupTokenTestDB(t)
+		tokenDB := NewTokenDB(db, 0)
+
+		token, plain, err := entity.NewRefreshToken(pkgEntity.NewID(), time.Hour)
+		require.NoError(t, err)
+
+		err = tokenDB.CreateRefreshToken(context.Background(), token)
+		require.NoError(t, err)
+
+		found, err := tokenDB.FindRefreshTokenByHash(context.Background(), entity.HashToken(plain))
+		assert.NoError(t, err)
+		assert.Equal(t, token.ID, found.ID)
+		assert.Equal(t, token.UserID, found.UserID)
+		assert.Nil(t, found.RevokedAt)
+	})
+
+	t.Run("should return error when hash is unknown", func(t *testing.T) {
+		db := setupTokenTestDB(t)
+		tokenDB := NewTokenDB(db, 0)
+
+		_, err := tokenDB.FindRefreshTokenByHash(context.Background(), entity.HashToken("unknown"))
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+func TestToken_RotateRefreshToken(t *testing.T) {
+	t.Run("should revoke current token and store the next one", func(t *testing.T) {
+		db := setupTokenTestDB(t)
+		tokenDB := NewTokenDB(db, 0)
+		userID := pkgEntity.NewID()
+
+		current, plain, err := entity.NewRefreshToken(userID, time.Hour)
+		require.NoError(t, err)
+		require.NoError(t, tokenDB.CreateRefreshToken(context.Background(), current))
+
+		next, nextPlain, err := entity.NewRefreshToken(userID, time.Hour)
+		require.NoError(t, err)
+
+		err = tokenDB.RotateRefreshToken(context.Background(), current, next)
+		assert.NoError(t, err)
+
+		old, err := tokenDB.FindRefreshTokenByHash(context.Background(), entity.HashToken(plain))
+		require.NoError(t, err)
+		assert.True(t, old.IsRevoked())
+		require.NotNil(t, old.ReplacedBy)
+		assert.Equal(t, next.ID, *old.ReplacedBy)
+
+		stored, err := tokenDB.FindRefreshTokenByHash(context.Background(), entity.HashToken(nextPlain))
+		assert.NoError(t, err)
+		assert.False(t, stored.IsRevoked())
+	})
+
+	t.Run("should not rotate a token twice", func(t *testing.T) {
+		db := setupTokenTestDB(t)
+		tokenDB := NewTokenDB(db, 0)
+		userID := pkgEntity.NewID()
+
+		current, _, err := entity.NewRefreshToken(userID, time.Hour)
+		require.NoError(t, err)
+		require.NoError(t, tokenDB.CreateRefreshToken(context.Background(), current))
+
+		next1, _, _ := entity.NewRefreshToken(userID, time.Hour)
+		next2, _, _ := entity.NewRefreshToken(userID, time.Hour)
+
+		require.NoError(t, tokenDB.RotateRefreshToken(context.Background(), current, next1))
+		err = tokenDB.RotateRefreshToken(context.Background(), current, next2)
+		assert.ErrorIs(t, err, entity.ErrTokenRevoked)
+
+		var count int64
+		db.Model(&entity.RefreshToken{}).Count(&count)
+		assert.Equal(t, int64(2), count)
+	})
+}
+
+func TestToken_RevokeRefreshTokens(t *testing.T) {
+	t.Run("should revoke a single token", func(t *testing.T) {
+		db := setupTokenTestDB(t)
+		tokenDB := NewTokenDB(db, 0)
+
+		token, plain, _ := entity.NewRefreshToken(pkgEntity.NewID(), time.Hour)
+		require.NoError(t, tokenDB.CreateRefreshToken(context.Background(), token))
+
+		err := tokenDB.RevokeRefreshToken(context.Background(), token.ID.String())
+		assert.NoError(t, err)
+
+		found, err := tokenDB.FindRefreshTokenByHash(context.Background(), entity.HashToken(plain))
+		require.NoError(t, err)
+		assert.True(t, found.IsRevoked())
+	})
+
+	t.Run("should revoke only the tokens of the user", func(t *testing.T) {
+		db := setupTokenTestDB(t)
+		tokenDB := NewTokenDB(db, 0)
+		userID := pkgEntity.NewID()
+
+		_, plain1 := createRefreshToken(t, tokenDB, userID)
+		_, plain2 := createRefreshToken(t, tokenDB, userID)
+		_, other := createRefreshToken(t, tokenDB, pkgEntity.NewID())
+
+		err := tokenDB.RevokeUserRefreshTokens(context.Background(), userID.String())
+		assert.NoError(t, err)
+
+		for _, plain := range []string{plain1, plain2} {
+			found, err := tokenDB.FindRefreshTokenByHash(context.Background(), entity.HashToken(plain))
+			require.NoError(t, err)
+			assert.True(t, found.IsRevoked())
+		}
+		found, err := tokenDB.FindRefreshTokenByHash(context.Background(), entity.HashToken(other))
+		require.NoError(t, err)
+		assert.False(t, found.IsRevoked())
+	})
+
+	t.Run("should return error for invalid ID format", func(t *testing.T) {
+		db := setupTokenTestDB(t)
+		tokenDB := NewTokenDB(db, 0)
+
+		assert.Error(t, tokenDB.RevokeRefreshToken(context.Background(), "invalid-uuid"))
+		assert.Error(t, tokenDB.RevokeUserRefreshTokens(context.Background(), "invalid-uuid"))
+	})
+}
+
+func TestToken_RevokeAccessToken(t *testing.T) {
+	db := setupTokenTestDB(t)
+	tokenDB := NewTokenDB(db, 0)
+
+	revoked, err := tokenDB.IsAccessTokenRevoked(context.Background(), "jti-1")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	err = tokenDB.RevokeAccessToken(context.Background(), "jti-1", time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+
+	revoked, err = tokenDB.IsAccessTokenRevoked(context.Background(), "jti-1")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = tokenDB.IsAccessTokenRevoked(context.Background(), "jti-2")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+}
+
+func TestToken_PasswordResetToken(t *testing.T) {
+	t.Run("should find reset token by hash", func(t *testing.T) {
+		db := setupTokenTestDB(t)
+		tokenDB := NewTokenDB(db, 0)
+
+		token, plain, err := entity.NewPasswordResetToken(pkgEntity.NewID(), time.Hour)
+		require.NoError(t, err)
+		require.NoError(t, tokenDB.CreatePasswordResetToken(context.Background(), token))
+
+		found, err := tokenDB.FindPasswordResetTokenByHash(context.Background(), entity.HashToken(plain))
+		assert.NoError(t, err)
+		assert.Equal(t, token.ID, found.ID)
+		assert.False(t, found.IsUsed())
+	})
+
+	t.Run("should use a reset token only once", func(t *testing.T) {
+		db := setupTokenTestDB(t)
+		tokenDB := NewTokenDB(db, 0)
+
+		token, plain, err := entity.NewPasswordResetToken(pkgEntity.NewID(), time.Hour)
+		require.NoError(t, err)
+		require.NoError(t, tokenDB.CreatePasswordResetToken(context.Background(), token))
+
+		err = tokenDB.UsePasswordResetToken(context.Background(), token)
+		assert.NoError(t, err)
+		assert.True(t, token.IsUsed())
+
+		found, err := tokenDB.FindPasswordResetTokenByHash(context.Background(), entity.HashToken(plain))
+		require.NoError(t, err)
+		assert.True(t, found.IsUsed())
+
+		err = tokenDB.UsePasswordResetToken(context.Background(), found)
+		assert.ErrorIs(t, err, entity.ErrTokenRevoked)
+	})
+
+	t.Run("should revoke the other reset tokens of the user", func(t *testing.T) {
+		db := setupTokenTestDB(t)
+		tokenDB := NewTokenDB(db, 0)
+
+		userID := pkgEntity.NewID()
+		var plains []string
+		for range 2 {
+			token, plain, err := entity.NewPasswordResetToken(userID, time.Hour)
+			require.NoError(t, err)
+			require.NoError(t, tokenDB.CreatePasswordResetToken(context.Background(), token))
+			plains = append(plains, plain)
+		}
+		other, otherPlain, err := entity.NewPasswordResetToken(pkgEntity.NewID(), time.Hour)
+		require.NoError(t, err)
+		require.NoError(t, tokenDB.CreatePasswordResetToken(context.Background(), other))
+
+		used, err := tokenDB.FindPasswordResetTokenByHash(context.Background(), entity.HashToken(plains[0]))
+		require.NoError(t, err)
+		require.NoError(t, tokenDB.UsePasswordResetToken(context.Background(), used))
+
+		older, err := tokenDB.FindPasswordResetTokenByHash(context.Background(), entity.HashToken(plains[1]))
+		require.NoError(t, err)
+		assert.True(t, older.IsUsed(), "An older email must not reset the password again")
+		assert.ErrorIs(t, tokenDB.UsePasswordResetToken(context.Background(), older), entity.ErrTokenRevoked)
+
+		found, err := tokenDB.FindPasswordResetTokenByHash(context.Background(), entity.HashToken(otherPlain))
+		require.NoError(t, err)
+		assert.False(t, found.IsUsed(), "The tokens of other users are kept")
+	})
+}
+
+func createRefreshToken(t *testing.T, tokenDB *Token, userID pkgEntity.ID) (*entity.RefreshToken, string) {
+	t.Helper()
+	token, plain, err := entity.NewRefreshToken(userID, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, tokenDB.CreateRefreshToken(context.Background(), token))
+	return token, plain
+}
+
+func TestToken_Purge(t *testing.T) {
+	db := setupTokenTestDB(t)
+	tokenDB := NewTokenDB(db, 0)
+	ctx := context.Background()
+	userID := pkgEntity.NewID()
+
+	for _, ttl := range []time.Duration{-time.Minute, time.Hour} {
+		refresh, _, err := entity.NewRefreshToken(userID, ttl)
+		require.NoError(t, err)
+		require.NoError(t, tokenDB.CreateRefreshToken(ctx, refresh))
+		reset, _, err := entity.NewPasswordResetToken(userID, ttl)
+		require.NoError(t, err)
+		require.NoError(t, tokenDB.CreatePasswordResetToken(ctx, reset))
+		require.NoError(t, tokenDB.RevokeAccessToken(ctx, pkgEntity.NewID().String(), time.Now().Add(ttl)))
+	}
+
+	purged, err := tokenDB.Purge(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+	for _, model := range []any{&entity.RefreshToken{}, &entity.RevokedToken{}, &entity.PasswordResetToken{}} {
+		var count int64
+		db.Unscoped().Model(model).Count(&count)
+		assert.Equal(t, int64(1), count, "Only the live token is kept")
+	}
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	pkgEntity "github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRegisterTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	db := setupProductTestDB(t)
+	require.NoError(t, RegisterTracing(db))
+	productDB := NewProductDB(db, 0)
+	ctx, request := provider.Tracer("test").Start(context.Background(), "request")
+
+	product, err := entity.NewProduct("Secret name", pkgEntity.NewMoney(99999, "BRL"))
+	require.NoError(t, err)
+	require.NoError(t, productDB.Create(ctx, product))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2, "the product and its audit are two statements")
+	for _, span := range spans {
+		assert.Equal(t, "Product.Create", span.Name())
+		assert.Equal(t, request.SpanContext().SpanID(), span.Parent().SpanID(), "statements are children of the caller's span")
+		assert.Equal(t, codes.Unset, span.Status().Code)
+		for _, attr := range span.Attributes() {
+			if attr.Key == "db.query.text" {
+				assert.True(t, strings.HasPrefix(attr.Value.AsString(), "INSERT INTO"))
+				assert.NotContains(t, attr.Value.AsString(), "Secret name", "values must not reach the traces")
+			}
+		}
+	}
+
+	require.NoError(t, db.Migrator().DropTable(&entity.Product{}))
+	recorder.Reset()
+	_, err = productDB.Count(ctx, ProductFilter{})
+	require.Error(t, err)
+	spans = recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "Product.Count", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	request.End()
+}
@@ -10,22 +10,79 @@ import (
 )
 
 type User struct {
-	db *gorm.DB
+	repository
 }
 
-func NewUserDB(db *gorm.DB) *User {
-	return &User{db: db}
+// NewUserDB returns the repository. Every query is bounded by queryTimeout,
+// zero leaves only the deadline of the caller's context.
+func NewUserDB(db *gorm.DB, queryTimeout time.Duration) *User {
+	return &User{repository{db: db, timeout: queryTimeout}}
 }
 
-func (u *User) Create(user *entity.User) error {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+func (u *User) Create(ctx context.Context, user *entity.User) error {
+	ctx, cancel := u.queryContext(ctx, "User.Create")
 	defer cancel()
-	return gorm.G[entity.User](u.db).Create(ctx, user)
+	err := gorm.G[entity.User](u.db).Create(ctx, user)
+	return translateError(err, entity.ErrUserNotFound, entity.ErrEmailTaken)
 }
 
-func (u *User) FindByEmail(email string) (*entity.User, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+func (u *User) FindByEmail(ctx context.Context, email string) (*entity.User, error) {
+	ctx, cancel := u.queryContext(ctx, "User.FindByEmail")
 	defer cancel()
 	user, err := gorm.G[entity.User](u.db).Where("usr_email = ?", email).First(ctx)
-	return &user, err
+	return &user, translateError(err, entity.ErrUserNotFound, entity.ErrEmailTaken)
+}
+
+func (u *User) FindByID(ctx context.Context, id string) (*entity.User, error) {
+	ctx, cancel := u.queryContext(ctx, "User.FindByID")
+	defer cancel()
+
+	userID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := gorm.G[entity.User](u.db).Where("usr_id = ?", userID).First(ctx)
+	return &user, translateError(err, entity.ErrUserNotFound, entity.ErrEmailTaken)
+}
+
+func (u *User) Update(ctx context.Context, user *entity.User) error {
+	ctx, cancel := u.queryContext(ctx, "User.Update")
+	defer cancel()
+	_, err := gorm.G[entity.User](u.db).Updates(ctx, *user)
+	return translateError(err, entity.ErrUserNotFound, entity.ErrEmailTaken)
+}
+
+// Delete soft deletes the user through BaseModel.DeletedAt.
+func (u *User) Delete(ctx context.Context, id string) error {
+	ctx, cancel := u.queryContext(ctx, "User.Delete")
+	defer cancel()
+
+	userID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = gorm.G[entity.User](u.db).Where("usr_id = ?", userID).Delete(ctx)
+	return translateError(err, entity.ErrUserNotFound, entity.ErrEmailTaken)
+}
+
+func (u *User) FindAll(ctx context.Context, page, limit int, sort string) ([]entity.User, error) {
+	ctx, cancel := u.queryContext(ctx, "User.FindAll")
+	defer cancel()
+	offset := (page - 1) * limit
+	items, err := gorm.G[entity.User](u.db).
+		Order(sort).
+		Limit(limit).
+		Offset(offset).
+		Find(ctx)
+	return items, translateError(err, entity.ErrUserNotFound, entity.ErrEmailTaken)
+}
+
+func (u *User) Count(ctx context.Context) (int64, error) {
+	ctx, cancel := u.queryContext(ctx, "User.Count")
+	defer cancel()
+
+	count, err := gorm.G[entity.User](u.db).Count(ctx, "usr_id")
+	return count, translateError(err, entity.ErrUserNotFound, entity.ErrEmailTaken)
 }
@@ -1,31 +1,23 @@
 package database
 
 import (
+	"context"
 	"testing"
 
 	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/database/dbtest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
 func setupTestDB(t *testing.T) *gorm.DB {
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
-		DisableForeignKeyConstraintWhenMigrating: true,
-	})
-	require.NoError(t, err)
-
-	// Use auto migration
-	err = db.AutoMigrate(&entity.User{})
-	require.NoError(t, err)
-
-	return db
+	return dbtest.Open(t, &entity.User{})
 }
 
 func TestNewUser(t *testing.T) {
 	db := setupTestDB(t)
-	userDB := NewUserDB(db)
+	userDB := NewUserDB(db, 0)
 
 	assert.NotNil(t, userDB)
 	assert.NotNil(t, userDB.db)
@@ -34,12 +26,12 @@ func TestNewUser(t *testing.T) {
 func TestUser_Create(t *testing.T) {
 	t.Run("should create user successfully", func(t *testing.T) {
 		db := setupTestDB(t)
-		userDB := NewUserDB(db)
+		userDB := NewUserDB(db, 0)
 
 		user, err := entity.NewUser("John Doe", "john@example.com", "password123")
 		require.NoError(t, err)
 
-		err = userDB.Create(user)
+		err = userDB.Create(context.Background(), user)
 		assert.NoError(t, err)
 
 		// Verify user was created
@@ -50,34 +42,36 @@ func TestUser_Create(t *testing.T) {
 
 	t.Run("should return error for duplicate email", func(t *testing.T) {
 		db := setupTestDB(t)
-		userDB := NewUserDB(db)
+		userDB := NewUserDB(db, 0)
 
 		user1, err := entity.NewUser("John Doe", "john@example.com", "password123")
 		require.NoError(t, err)
 
-		err = userDB.Create(user1)
+		err = userDB.Create(context.Background(), user1)
 		require.NoError(t, err)
 
 		user2, err := entity.NewUser("Jane Doe", "john@example.com", "password456")
 		require.NoError(t, err)
 
-		err = userDB.Create(user2)
+		err = userDB.Create(context.Background(), user2)
 		assert.Error(t, err)
+		assert.ErrorIs(t, err, entity.ErrEmailTaken)
+		assert.ErrorIs(t, err, entity.ErrConflict)
 	})
 }
 
 func TestUser_FindByEmail(t *testing.T) {
 	t.Run("should find user by email successfully", func(t *testing.T) {
 		db := setupTestDB(t)
-		userDB := NewUserDB(db)
+		userDB := NewUserDB(db, 0)
 
 		user, err := entity.NewUser("John Doe", "john@example.com", "password123")
 		require.NoError(t, err)
 
-		err = userDB.Create(user)
+		err = userDB.Create(context.Background(), user)
 		require.NoError(t, err)
 
-		foundUser, err := userDB.FindByEmail("john@example.com")
+		foundUser, err := userDB.FindByEmail(context.Background(), "john@example.com")
 		assert.NoError(t, err)
 		assert.NotNil(t, foundUser)
 		assert.Equal(t, user.ID, foundUser.ID)
@@ -87,29 +81,29 @@ func TestUser_FindByEmail(t *testing.T) {
 
 	t.Run("should return error when user not found", func(t *testing.T) {
 		db := setupTestDB(t)
-		userDB := NewUserDB(db)
+		userDB := NewUserDB(db, 0)
 
-		foundUser, err := userDB.FindByEmail("nonexistent@example.com")
+		foundUser, err := userDB.FindByEmail(context.Background(), "nonexistent@example.com")
 		assert.Error(t, err)
-		assert.Equal(t, gorm.ErrRecordNotFound, err)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
 		assert.NotNil(t, foundUser)
 	})
 
 	t.Run("should find correct user when multiple users exist", func(t *testing.T) {
 		db := setupTestDB(t)
-		userDB := NewUserDB(db)
+		userDB := NewUserDB(db, 0)
 
 		user1, err := entity.NewUser("John Doe", "john@example.com", "password123")
 		require.NoError(t, err)
-		err = userDB.Create(user1)
+		err = userDB.Create(context.Background(), user1)
 		require.NoError(t, err)
 
 		user2, err := entity.NewUser("Jane Doe", "jane@example.com", "password456")
 		require.NoError(t, err)
-		err = userDB.Create(user2)
+		err = userDB.Create(context.Background(), user2)
 		require.NoError(t, err)
 
-		foundUser, err := userDB.FindByEmail("jane@example.com")
+		foundUser, err := userDB.FindByEmail(context.Background(), "jane@example.com")
 		assert.NoError(t, err)
 		assert.NotNil(t, foundUser)
 		assert.Equal(t, user2.ID, foundUser.ID)
@@ -117,3 +111,131 @@ func TestUser_FindByEmail(t *testing.T) {
 		assert.Equal(t, user2.Email, foundUser.Email)
 	})
 }
+
+func TestUser_FindByID(t *testing.T) {
+	t.Run("should find user by ID successfully", func(t *testing.T) {
+		db := setupTestDB(t)
+		userDB := NewUserDB(db, 0)
+
+		user, err := entity.NewUser("John Doe", "john@example.com", "password123")
+		require.NoError(t, err)
+		err = userDB.Create(context.Background(), user)
+		require.NoError(t, err)
+
+		foundUser, err := userDB.FindByID(context.Background(), user.ID.String())
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, foundUser.ID)
+		assert.Equal(t, user.Email, foundUser.Email)
+	})
+
+	t.Run("should return error when user not found", func(t *testing.T) {
+		db := setupTestDB(t)
+		userDB := NewUserDB(db, 0)
+
+		_, err := userDB.FindByID(context.Background(), "019ab24a-dc97-72a4-9056-cc09f4c13bef")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("should return error for invalid ID format", func(t *testing.T) {
+		db := setupTestDB(t)
+		userDB := NewUserDB(db, 0)
+
+		foundUser, err := userDB.FindByID(context.Background(), "invalid-uuid")
+		assert.Error(t, err)
+		assert.Nil(t, foundUser)
+	})
+}
+
+func TestUser_Update(t *testing.T) {
+	t.Run("should update user role", func(t *testing.T) {
+		db := setupTestDB(t)
+		userDB := NewUserDB(db, 0)
+
+		user, err := entity.NewUser("John Doe", "john@example.com", "password123")
+		require.NoError(t, err)
+		require.NoError(t, userDB.Create(context.Background(), user))
+		assert.Equal(t, entity.RoleViewer, user.Role)
+
+		require.NoError(t, user.SetRole(entity.RoleEditor))
+		err = userDB.Update(context.Background(), user)
+		assert.NoError(t, err)
+
+		foundUser, err := userDB.FindByID(context.Background(), user.ID.String())
+		assert.NoError(t, err)
+		assert.Equal(t, entity.RoleEditor, foundUser.Role)
+	})
+}
+
+func TestUser_Delete(t *testing.T) {
+	t.Run("should soft delete user", func(t *testing.T) {
+		db := setupTestDB(t)
+		userDB := NewUserDB(db, 0)
+
+		user, err := entity.NewUser("John Doe", "john@example.com", "password123")
+		require.NoError(t, err)
+		require.NoError(t, userDB.Create(context.Background(), user))
+
+		err = userDB.Delete(context.Background(), user.ID.String())
+		assert.NoError(t, err)
+
+		var count int64
+		db.Model(&entity.User{}).Unscoped().Where("usr_id = ?", user.ID).Count(&count)
+		assert.Equal(t, int64(1), count)
+
+		_, err = userDB.FindByID(context.Background(), user.ID.String())
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		_, err = userDB.FindByEmail(context.Background(), user.Email)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("should return error for invalid ID format", func(t *testing.T) {
+		db := setupTestDB(t)
+		userDB := NewUserDB(db, 0)
+
+		assert.Error(t, userDB.Delete(context.Background(), "invalid-uuid"))
+	})
+}
+
+func TestUser_FindAll(t *testing.T) {
+	t.Run("should paginate users", func(t *testing.T) {
+		db := setupTestDB(t)
+		userDB := NewUserDB(db, 0)
+
+		for _, name := range []string{"Carol", "Alice", "Bob"} {
+			user, err := entity.NewUser(name, name+"@example.com", "password123")
+			require.NoError(t, err)
+			require.NoError(t, userDB.Create(context.Background(), user))
+		}
+
+		result, err := userDB.FindAll(context.Background(), 1, 2, "usr_name asc")
+		assert.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.Equal(t, "Alice", result[0].Name)
+		assert.Equal(t, "Bob", result[1].Name)
+
+		result, err = userDB.FindAll(context.Background(), 2, 2, "usr_name asc")
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+		assert.Equal(t, "Carol", result[0].Name)
+	})
+
+	t.Run("should not list nor count deleted users", func(t *testing.T) {
+		db := setupTestDB(t)
+		userDB := NewUserDB(db, 0)
+
+		user1, _ := entity.NewUser("Alice", "alice@example.com", "password123")
+		user2, _ := entity.NewUser("Bob", "bob@example.com", "password123")
+		require.NoError(t, userDB.Create(context.Background(), user1))
+		require.NoError(t, userDB.Create(context.Background(), user2))
+		require.NoError(t, userDB.Delete(context.Background(), user1.ID.String()))
+
+		result, err := userDB.FindAll(context.Background(), 1, 10, "usr_name asc")
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+		assert.Equal(t, "Bob", result[0].Name)
+
+		count, err := userDB.Count(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+}
@@ -0,0 +1,77 @@
+// Package jobs holds the background work started with the server.
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jb-oliveira/fullcycle/APIS/pkg/log"
+)
+
+// Purger permanently removes the rows that expired before a time.
+type Purger interface {
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+// TrashPurger permanently removes the rows soft deleted before a time and
+// returns the keys of the files they owned in the blob store.
+type TrashPurger interface {
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, []string, error)
+}
+
+// BlobDeleter removes a file of the blob store, storage.BlobStore is one.
+type BlobDeleter interface {
+	Delete(ctx context.Context, key string) error
+}
+
+// PurgeTrash removes the rows deleted more than retention ago and then
+// their files, once at start and then every interval until ctx is done.
+// Errors are logged and the next run tries again, a file that can't be
+// deleted is only logged since its row is already gone.
+func PurgeTrash(ctx context.Context, trash TrashPurger, blobs BlobDeleter, retention, interval time.Duration) {
+	every(ctx, interval, func() {
+		deletedBefore := time.Now().Add(-retention)
+		purged, keys, err := trash.Purge(ctx, deletedBefore)
+		logPurge(ctx, "the trash", purged, deletedBefore, err)
+		for _, key := range keys {
+			if err := blobs.Delete(ctx, key); err != nil {
+				log.Error(ctx, "failed to delete the purged file "+key+": "+err.Error())
+			}
+		}
+	})
+}
+
+// PurgeExpired removes the rows that expired, like the idempotency keys,
+// every interval.
+func PurgeExpired(ctx context.Context, expired Purger, interval time.Duration) {
+	every(ctx, interval, func() {
+		expiredBefore := time.Now()
+		purged, err := expired.Purge(ctx, expiredBefore)
+		logPurge(ctx, "the expired rows", purged, expiredBefore, err)
+	})
+}
+
+// every runs fn at once and then on every tick until ctx is done.
+func every(ctx context.Context, interval time.Duration, fn func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		fn()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func logPurge(ctx context.Context, what string, purged int64, before time.Time, err error) {
+	if err != nil {
+		log.Error(ctx, "failed to purge "+what+": "+err.Error())
+		return
+	}
+	if purged > 0 {
+		log.Info(ctx, fmt.Sprintf("purged %d rows of %s before %s", purged, what, before.Format(time.RFC3339)))
+	}
+}
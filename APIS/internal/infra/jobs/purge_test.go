@@ -0,0 +1,120 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePurger struct {
+	mu    sync.Mutex
+	calls []time.Time
+	err   error
+}
+
+func (f *fakePurger) Purge(_ context.Context, deletedBefore time.Time) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, deletedBefore)
+	return 1, f.err
+}
+
+// fakeTrash is a fakePurger whose rows own a file each.
+type fakeTrash struct {
+	fakePurger
+	keys []string
+}
+
+func (f *fakeTrash) Purge(ctx context.Context, deletedBefore time.Time) (int64, []string, error) {
+	purged, err := f.fakePurger.Purge(ctx, deletedBefore)
+	if err != nil {
+		return 0, nil, err
+	}
+	return purged, f.keys, nil
+}
+
+type fakeBlobs struct {
+	mu      sync.Mutex
+	deleted []string
+	err     error
+}
+
+func (f *fakeBlobs) Delete(_ context.Context, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleted = append(f.deleted, key)
+	return f.err
+}
+
+func (f *fakePurger) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.calls)
+}
+
+func TestPurgeTrash(t *testing.T) {
+	t.Run("should purge at start and on every tick", func(t *testing.T) {
+		trash := &fakeTrash{}
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			PurgeTrash(ctx, trash, &fakeBlobs{}, 48*time.Hour, 10*time.Millisecond)
+			close(done)
+		}()
+
+		require.Eventually(t, func() bool { return trash.count() >= 3 }, time.Second, 5*time.Millisecond)
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("PurgeTrash must return when the context is done")
+		}
+
+		trash.mu.Lock()
+		defer trash.mu.Unlock()
+		assert.WithinDuration(t, time.Now().Add(-48*time.Hour), trash.calls[0], time.Second)
+	})
+
+	t.Run("should keep running after an error", func(t *testing.T) {
+		trash := &fakeTrash{fakePurger: fakePurger{err: errors.New("database is down")}}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go PurgeTrash(ctx, trash, &fakeBlobs{}, time.Hour, 10*time.Millisecond)
+
+		assert.Eventually(t, func() bool { return trash.count() >= 2 }, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("should delete the files of the purged rows", func(t *testing.T) {
+		trash := &fakeTrash{keys: []string{"products/1/a.png", "products/1/b.png"}}
+		blobs := &fakeBlobs{err: errors.New("access denied")}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go PurgeTrash(ctx, trash, blobs, time.Hour, time.Hour)
+
+		require.Eventually(t, func() bool {
+			blobs.mu.Lock()
+			defer blobs.mu.Unlock()
+			return len(blobs.deleted) == 2
+		}, time.Second, 5*time.Millisecond, "A failed delete must not stop the others")
+		blobs.mu.Lock()
+		defer blobs.mu.Unlock()
+		assert.Equal(t, trash.keys, blobs.deleted)
+	})
+}
+
+func TestPurgeExpired(t *testing.T) {
+	keys := &fakePurger{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go PurgeExpired(ctx, keys, 10*time.Millisecond)
+
+	require.Eventually(t, func() bool { return keys.count() >= 2 }, time.Second, 5*time.Millisecond)
+	keys.mu.Lock()
+	defer keys.mu.Unlock()
+	assert.WithinDuration(t, time.Now(), keys.calls[0], time.Second, "Only the rows already expired")
+}
@@ -0,0 +1,29 @@
+package mail
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryMailer(t *testing.T) {
+	mailer := NewMemoryMailer()
+	assert.Empty(t, mailer.Messages())
+
+	err := mailer.Send(context.Background(), Message{To: "john@example.com", Subject: "Hello", Body: "World"})
+	assert.NoError(t, err)
+
+	messages := mailer.Messages()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "john@example.com", messages[0].To)
+	assert.Equal(t, "Hello", messages[0].Subject)
+
+	messages[0].To = "changed"
+	assert.Equal(t, "john@example.com", mailer.Messages()[0].To, "Messages should return a copy")
+}
+
+func TestLogMailer(t *testing.T) {
+	var mailer Mailer = NewLogMailer()
+	assert.NoError(t, mailer.Send(context.Background(), Message{To: "john@example.com"}))
+}
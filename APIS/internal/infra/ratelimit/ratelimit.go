@@ -0,0 +1,112 @@
+// Package ratelimit limits requests with token buckets: a bucket holds up
+// to Limit.Requests tokens, every request takes one and they are refilled
+// at Limit.Requests per Limit.Period.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit is the size of a bucket and how fast it refills. Bursts of up to
+// Requests are allowed after a quiet Period.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Enabled tells if the limit applies, a zero limit lets everything through.
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+// interval is the time to refill a single token.
+func (l Limit) interval() time.Duration {
+	return l.Period / time.Duration(l.Requests)
+}
+
+// Result is the state of a bucket after a request took its token, or
+// failed to.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is the time until the next token, zero when Allowed
+	RetryAfter time.Duration
+}
+
+// Store keeps the buckets. MemoryStore serves a single instance, a shared
+// store lets several instances enforce the same limits.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	fullAt time.Time
+}
+
+// MemoryStore keeps the buckets of a single instance in memory.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Take refills the bucket of key for the time since its last request and
+// takes a token from it.
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.sweep(now)
+
+	capacity := float64(limit.Requests)
+	interval := limit.interval()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		s.buckets[key] = b
+	}
+	b.tokens = min(capacity, b.tokens+float64(now.Sub(b.last))/float64(interval))
+	b.last = now
+
+	result := Result{Limit: limit.Requests}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.tokens) * float64(interval))
+	}
+	result.Remaining = int(math.Floor(b.tokens))
+	result.Reset = time.Duration((capacity - b.tokens) * float64(interval))
+	b.fullAt = now.Add(result.Reset)
+	return result, nil
+}
+
+// sweep drops the full buckets once a minute, they are the same as a new
+// one, so the map doesn't grow with every client ever seen.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if !now.Before(b.fullAt) {
+			delete(s.buckets, key)
+		}
+	}
+}
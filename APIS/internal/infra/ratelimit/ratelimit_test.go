@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(now *time.Time) *MemoryStore {
+	s := NewMemoryStore()
+	s.now = func() time.Time { return *now }
+	return s
+}
+
+func TestLimit_Enabled(t *testing.T) {
+	assert.True(t, Limit{Requests: 10, Period: time.Minute}.Enabled())
+	assert.False(t, Limit{Period: time.Minute}.Enabled())
+	assert.False(t, Limit{Requests: 10}.Enabled())
+}
+
+func TestMemoryStore_Take(t *testing.T) {
+	ctx := context.Background()
+	limit := Limit{Requests: 3, Period: 3 * time.Second}
+
+	t.Run("should allow a burst up to the limit", func(t *testing.T) {
+		now := time.Now()
+		store := newTestStore(&now)
+
+		for remaining := 2; remaining >= 0; remaining-- {
+			result, err := store.Take(ctx, "user:1", limit)
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+			assert.Equal(t, 3, result.Limit)
+			assert.Equal(t, remaining, result.Remaining)
+			assert.Zero(t, result.RetryAfter)
+		}
+
+		result, err := store.Take(ctx, "user:1", limit)
+		require.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.Equal(t, 0, result.Remaining)
+		assert.Equal(t, time.Second, result.RetryAfter, "a token is refilled every second")
+		assert.Equal(t, 3*time.Second, result.Reset)
+	})
+
+	t.Run("should refill the tokens over time", func(t *testing.T) {
+		now := time.Now()
+		store := newTestStore(&now)
+		for range 3 {
+			store.Take(ctx, "user:1", limit)
+		}
+
+		now = now.Add(500 * time.Millisecond)
+		result, _ := store.Take(ctx, "user:1", limit)
+		assert.False(t, result.Allowed)
+		assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+
+		now = now.Add(500 * time.Millisecond)
+		result, _ = store.Take(ctx, "user:1", limit)
+		assert.True(t, result.Allowed)
+
+		now = now.Add(time.Hour)
+		result, _ = store.Take(ctx, "user:1", limit)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 2, result.Remaining, "the bucket never holds more than the limit")
+	})
+
+	t.Run("should keep a bucket per key", func(t *testing.T) {
+		now := time.Now()
+		store := newTestStore(&now)
+		for range 3 {
+			store.Take(ctx, "user:1", limit)
+		}
+
+		result, _ := store.Take(ctx, "user:2", limit)
+		assert.True(t, result.Allowed)
+	})
+
+	t.Run("should drop the full buckets", func(t *testing.T) {
+		now := time.Now()
+		store := newTestStore(&now)
+		store.Take(ctx, "user:1", limit)
+		store.Take(ctx, "user:2", Limit{Requests: 1, Period: time.Hour})
+
+		now = now.Add(2 * time.Minute)
+		store.Take(ctx, "user:3", limit)
+
+		assert.NotContains(t, store.buckets, "user:1")
+		assert.Contains(t, store.buckets, "user:2", "a bucket still refilling is kept")
+		assert.Contains(t, store.buckets, "user:3")
+	})
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// LocalPath is where the server exposes the files of a LocalStore.
+const LocalPath = "/media/"
+
+// LocalStore keeps the files in a directory of the server. It is meant for
+// development and single instance deployments, the files are served by
+// ServeHTTP.
+type LocalStore struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocalStore creates dir if needed. An empty baseURL serves the files
+// under LocalPath.
+func NewLocalStore(dir, baseURL string) (*LocalStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("the local storage needs a directory")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating the storage directory: %w", err)
+	}
+	if baseURL == "" {
+		baseURL = LocalPath
+	}
+	return &LocalStore{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/")}, nil
+}
+
+// Put writes the file through an os.Root, a key can't escape the directory.
+func (s *LocalStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	root, err := os.OpenRoot(s.dir)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	if dir := path.Dir(key); dir != "." {
+		if err := root.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	file, err := root.Create(key)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, io.LimitReader(body, size))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// A partial file must not be served
+		root.Remove(key)
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	root, err := os.OpenRoot(s.dir)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	if err := root.Remove(key); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStore) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+// ServeHTTP serves the file of the key in the path, the path must be
+// stripped of LocalPath first. Directories are not listed.
+func (s *LocalStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	http.FileServerFS(os.DirFS(s.dir)).ServeHTTP(w, r)
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalStore(dir, "")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, store.Put(ctx, "products/1/a.png", strings.NewReader("image"), 5, "image/png"))
+	data, err := os.ReadFile(filepath.Join(dir, "products", "1", "a.png"))
+	require.NoError(t, err)
+	assert.Equal(t, "image", string(data))
+	assert.Equal(t, "/media/products/1/a.png", store.URL("products/1/a.png"))
+
+	server := httptest.NewServer(http.StripPrefix(strings.TrimSuffix(LocalPath, "/"), store))
+	defer server.Close()
+	response, err := http.Get(server.URL + "/media/products/1/a.png")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "image", string(body))
+	response, err = http.Get(server.URL + "/media/products/1/")
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusNotFound, response.StatusCode, "Directories must not be listed")
+
+	require.NoError(t, store.Delete(ctx, "products/1/a.png"))
+	assert.NoFileExists(t, filepath.Join(dir, "products", "1", "a.png"))
+	assert.NoError(t, store.Delete(ctx, "products/1/a.png"), "A missing file is not an error")
+}
+
+func TestLocalStore_KeysStayInTheDirectory(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalStore(filepath.Join(dir, "media"), "https://cdn.example.com/")
+	require.NoError(t, err)
+
+	err = store.Put(context.Background(), "../escaped.png", strings.NewReader("image"), 5, "image/png")
+	assert.Error(t, err)
+	assert.NoFileExists(t, filepath.Join(dir, "escaped.png"))
+	assert.Equal(t, "https://cdn.example.com/a.png", store.URL("a.png"))
+}
+
+func TestNew(t *testing.T) {
+	store, err := New(context.Background(), Config{Driver: DriverLocal, LocalDir: t.TempDir()})
+	require.NoError(t, err)
+	assert.IsType(t, &LocalStore{}, store)
+
+	_, err = New(context.Background(), Config{Driver: DriverS3})
+	assert.Error(t, err, "S3 needs a bucket")
+	_, err = New(context.Background(), Config{Driver: "ftp"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 records the requests instead of sending them.
+type fakeS3 struct {
+	put     *s3.PutObjectInput
+	body    string
+	deleted *s3.DeleteObjectInput
+	err     error
+}
+
+func (f *fakeS3) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	f.put = params
+	data, _ := io.ReadAll(params.Body)
+	f.body = string(data)
+	return &s3.PutObjectOutput{}, f.err
+}
+
+func (f *fakeS3) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	f.deleted = params
+	return &s3.DeleteObjectOutput{}, f.err
+}
+
+func TestS3Store(t *testing.T) {
+	client := &fakeS3{}
+	store := NewS3Store(client, "images", "https://images.s3.sa-east-1.amazonaws.com/")
+	ctx := context.Background()
+
+	require.NoError(t, store.Put(ctx, "products/1/a.png", strings.NewReader("image"), 5, "image/png"))
+	assert.Equal(t, "images", *client.put.Bucket)
+	assert.Equal(t, "products/1/a.png", *client.put.Key)
+	assert.Equal(t, int64(5), *client.put.ContentLength)
+	assert.Equal(t, "image/png", *client.put.ContentType)
+	assert.Equal(t, "image", client.body)
+
+	require.NoError(t, store.Delete(ctx, "products/1/a.png"))
+	assert.Equal(t, "products/1/a.png", *client.deleted.Key)
+	assert.Equal(t, "https://images.s3.sa-east-1.amazonaws.com/products/1/a.png", store.URL("products/1/a.png"))
+
+	client.err = errors.New("access denied")
+	assert.ErrorContains(t, store.Put(ctx, "products/1/b.png", strings.NewReader("image"), 5, "image/png"), "access denied")
+}
+
+func TestBucketURL(t *testing.T) {
+	assert.Equal(t, "https://images.s3.sa-east-1.amazonaws.com", bucketURL("images", "sa-east-1", ""))
+	assert.Equal(t, "http://localhost:9000/images", bucketURL("images", "us-east-1", "http://localhost:9000/"))
+}
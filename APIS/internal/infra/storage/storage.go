@@ -0,0 +1,62 @@
+// Package storage keeps the uploaded files, on the local disk or in an S3
+// compatible bucket.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// BlobStore keeps files by key. Keys are relative paths with forward
+// slashes, the URL of a key is where the clients download the file.
+type BlobStore interface {
+	// Put saves size bytes of body under key, replacing any previous file.
+	// body should also be an io.Seeker, the S3 client needs it to sign
+	// the upload.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Delete removes the file, a missing one is not an error.
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// Drivers accepted by New.
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+// Config selects and configures the BlobStore returned by New.
+type Config struct {
+	Driver string
+	// BaseURL prefixes the keys in the URLs, the default is LocalPath for
+	// the local store and the bucket URL for S3
+	BaseURL    string
+	LocalDir   string
+	S3Bucket   string
+	S3Region   string
+	S3Endpoint string // for S3 compatible services, empty for AWS
+}
+
+// New returns the store of config.Driver.
+func New(ctx context.Context, config Config) (BlobStore, error) {
+	switch config.Driver {
+	case DriverLocal, "":
+		return NewLocalStore(config.LocalDir, config.BaseURL)
+	case DriverS3:
+		if config.S3Bucket == "" {
+			return nil, fmt.Errorf("the s3 storage needs a bucket")
+		}
+		client, err := NewS3Client(ctx, config.S3Region, config.S3Endpoint)
+		if err != nil {
+			return nil, err
+		}
+		baseURL := config.BaseURL
+		if baseURL == "" {
+			baseURL = bucketURL(config.S3Bucket, config.S3Region, config.S3Endpoint)
+		}
+		return NewS3Store(client, config.S3Bucket, baseURL), nil
+	default:
+		return nil, fmt.Errorf("unsupported storage driver %q, use %s or %s", config.Driver, DriverLocal, DriverS3)
+	}
+}
@@ -0,0 +1,137 @@
+package throttle
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Policy defines how many consecutive failures are tolerated before a key
+// is locked, and for how long. Every failure after MaxAttempts doubles the
+// lockout, starting at BaseLockout and never exceeding MaxLockout.
+type Policy struct {
+	MaxAttempts int
+	BaseLockout time.Duration
+	MaxLockout  time.Duration
+}
+
+func (p Policy) lockout(failures int) time.Duration {
+	if p.MaxAttempts <= 0 || failures < p.MaxAttempts {
+		return 0
+	}
+	lockout := p.BaseLockout
+	for i := p.MaxAttempts; i < failures && lockout < p.MaxLockout; i++ {
+		lockout *= 2
+	}
+	return min(lockout, p.MaxLockout)
+}
+
+type attempts struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// LoginThrottle tracks failed logins per email and per client IP in memory.
+// The IP policy is usually more permissive, since many users can share an
+// address behind a NAT.
+type LoginThrottle struct {
+	mu        sync.Mutex
+	email     Policy
+	ip        Policy
+	entries   map[string]*attempts
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewLoginThrottle(email, ip Policy) *LoginThrottle {
+	return &LoginThrottle{
+		email:   email,
+		ip:      ip,
+		entries: make(map[string]*attempts),
+		now:     time.Now,
+	}
+}
+
+// Locked returns for how long the email or the IP are still locked.
+// Zero means the login can be attempted.
+func (t *LoginThrottle) Locked(email, ip string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	return max(t.remaining(emailKey(email), now), t.remaining(ipKey(ip), now))
+}
+
+// Fail registers a failed login and returns the lockout now in effect.
+func (t *LoginThrottle) Fail(email, ip string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.sweep(now)
+	return max(t.fail(emailKey(email), t.email, now), t.fail(ipKey(ip), t.ip, now))
+}
+
+// Succeed clears the failures of the email. The IP counter is kept, so a
+// valid account can't be used to reset it between guesses.
+func (t *LoginThrottle) Succeed(email, ip string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.entries, emailKey(email))
+}
+
+func (t *LoginThrottle) remaining(key string, now time.Time) time.Duration {
+	entry, ok := t.entries[key]
+	if !ok || !now.Before(entry.lockedUntil) {
+		return 0
+	}
+	return entry.lockedUntil.Sub(now)
+}
+
+func (t *LoginThrottle) fail(key string, policy Policy, now time.Time) time.Duration {
+	entry, ok := t.entries[key]
+	if !ok || t.expired(entry, policy, now) {
+		entry = &attempts{}
+		t.entries[key] = entry
+	}
+	entry.failures++
+	entry.lastFailure = now
+	if lockout := policy.lockout(entry.failures); lockout > 0 {
+		entry.lockedUntil = now.Add(lockout)
+		return lockout
+	}
+	return 0
+}
+
+// expired tells if the failures are old enough to be forgotten.
+func (t *LoginThrottle) expired(entry *attempts, policy Policy, now time.Time) bool {
+	return now.After(entry.lockedUntil) && now.Sub(entry.lastFailure) > policy.MaxLockout
+}
+
+// sweep drops forgotten entries once a minute, so the map doesn't grow
+// with every email ever tried.
+func (t *LoginThrottle) sweep(now time.Time) {
+	if now.Sub(t.lastSweep) < time.Minute {
+		return
+	}
+	t.lastSweep = now
+	for key, entry := range t.entries {
+		policy := t.email
+		if strings.HasPrefix(key, "ip:") {
+			policy = t.ip
+		}
+		if t.expired(entry, policy, now) {
+			delete(t.entries, key)
+		}
+	}
+}
+
+func emailKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,90 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestThrottle(now *time.Time) *LoginThrottle {
+	t := NewLoginThrottle(
+		Policy{MaxAttempts: 3, BaseLockout: time.Minute, MaxLockout: 10 * time.Minute},
+		Policy{MaxAttempts: 10, BaseLockout: time.Minute, MaxLockout: 10 * time.Minute},
+	)
+	t.now = func() time.Time { return *now }
+	return t
+}
+
+func TestPolicy_Lockout(t *testing.T) {
+	policy := Policy{MaxAttempts: 3, BaseLockout: time.Minute, MaxLockout: 10 * time.Minute}
+
+	assert.Equal(t, time.Duration(0), policy.lockout(2))
+	assert.Equal(t, time.Minute, policy.lockout(3))
+	assert.Equal(t, 2*time.Minute, policy.lockout(4))
+	assert.Equal(t, 4*time.Minute, policy.lockout(5))
+	assert.Equal(t, 8*time.Minute, policy.lockout(6))
+	assert.Equal(t, 10*time.Minute, policy.lockout(7), "Lockout should be capped")
+	assert.Equal(t, 10*time.Minute, policy.lockout(50))
+	assert.Equal(t, time.Duration(0), Policy{}.lockout(100), "Zero policy never locks")
+}
+
+func TestLoginThrottle_LocksEmail(t *testing.T) {
+	now := time.Now()
+	throttle := newTestThrottle(&now)
+
+	assert.Equal(t, time.Duration(0), throttle.Fail("john@example.com", "10.0.0.1"))
+	assert.Equal(t, time.Duration(0), throttle.Fail("john@example.com", "10.0.0.2"))
+	assert.Equal(t, time.Minute, throttle.Fail("JOHN@example.com ", "10.0.0.3"), "Email should be normalized")
+
+	assert.Equal(t, time.Minute, throttle.Locked("john@example.com", "10.0.0.4"))
+	assert.Equal(t, time.Duration(0), throttle.Locked("jane@example.com", "10.0.0.1"))
+
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, 30*time.Second, throttle.Locked("john@example.com", "10.0.0.4"))
+
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, time.Duration(0), throttle.Locked("john@example.com", "10.0.0.4"))
+
+	// The next failure doubles the lockout
+	assert.Equal(t, 2*time.Minute, throttle.Fail("john@example.com", "10.0.0.4"))
+}
+
+func TestLoginThrottle_LocksIP(t *testing.T) {
+	now := time.Now()
+	throttle := newTestThrottle(&now)
+
+	for i := 0; i < 9; i++ {
+		throttle.Fail("user"+string(rune('a'+i))+"@example.com", "10.0.0.1")
+	}
+	assert.Equal(t, time.Duration(0), throttle.Locked("other@example.com", "10.0.0.1"))
+
+	throttle.Fail("last@example.com", "10.0.0.1")
+	assert.Equal(t, time.Minute, throttle.Locked("other@example.com", "10.0.0.1"))
+	assert.Equal(t, time.Duration(0), throttle.Locked("other@example.com", "10.0.0.2"))
+}
+
+func TestLoginThrottle_SucceedResetsEmailOnly(t *testing.T) {
+	now := time.Now()
+	throttle := newTestThrottle(&now)
+
+	throttle.Fail("john@example.com", "10.0.0.1")
+	throttle.Fail("john@example.com", "10.0.0.1")
+	throttle.Succeed("john@example.com", "10.0.0.1")
+
+	assert.Equal(t, time.Duration(0), throttle.Fail("john@example.com", "10.0.0.1"))
+	assert.Equal(t, 3, throttle.entries[ipKey("10.0.0.1")].failures)
+}
+
+func TestLoginThrottle_ForgetsOldFailures(t *testing.T) {
+	now := time.Now()
+	throttle := newTestThrottle(&now)
+
+	throttle.Fail("john@example.com", "10.0.0.1")
+	throttle.Fail("john@example.com", "10.0.0.1")
+
+	now = now.Add(11 * time.Minute)
+	assert.Equal(t, time.Duration(0), throttle.Fail("john@example.com", "10.0.0.1"))
+	assert.Len(t, throttle.entries, 2)
+	assert.Equal(t, 1, throttle.entries[emailKey("john@example.com")].failures)
+}
@@ -0,0 +1,53 @@
+// Package tracing sets up OpenTelemetry for the API: the global tracer
+// provider and the W3C trace context propagator.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+)
+
+// Exporters accepted by Setup. With ExporterNone the spans are still
+// created, so the trace ids reach the logs and the propagated headers.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+)
+
+// TracerName is the instrumentation name of the spans of the API.
+const TracerName = "github.com/jb-oliveira/fullcycle/APIS"
+
+// Setup installs the global tracer provider, exporting the spans to
+// exporter, and the W3C traceparent and baggage propagator. The returned
+// shutdown flushes the pending spans.
+func Setup(exporter, serviceName string) (shutdown func(context.Context) error, err error) {
+	options := []sdktrace.TracerProviderOption{
+		// An incoming traceparent decides whether the request is sampled
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.AlwaysSample())),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	}
+	switch exporter {
+	case ExporterNone, "":
+	case ExporterStdout:
+		spanExporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, fmt.Errorf("error creating the stdout span exporter: %w", err)
+		}
+		options = append(options, sdktrace.WithBatcher(spanExporter))
+	default:
+		return nil, fmt.Errorf("unsupported tracing exporter %q, use %s or %s", exporter, ExporterNone, ExporterStdout)
+	}
+
+	provider := sdktrace.NewTracerProvider(options...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSetup(t *testing.T) {
+	t.Run("should reject an unknown exporter", func(t *testing.T) {
+		_, err := Setup("jaeger", "test")
+		assert.Error(t, err)
+	})
+
+	for _, exporter := range []string{ExporterNone, ExporterStdout} {
+		t.Run("should trace with the "+exporter+" exporter", func(t *testing.T) {
+			shutdown, err := Setup(exporter, "test")
+			require.NoError(t, err)
+			defer shutdown(context.Background())
+
+			ctx, span := otel.Tracer(TracerName).Start(context.Background(), "operation")
+			defer span.End()
+			assert.True(t, span.SpanContext().IsValid())
+			assert.True(t, span.SpanContext().IsSampled())
+
+			header := http.Header{}
+			otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+			assert.Contains(t, header.Get("traceparent"), span.SpanContext().TraceID().String())
+		})
+	}
+
+	t.Run("should continue the trace of an incoming traceparent", func(t *testing.T) {
+		shutdown, err := Setup(ExporterNone, "test")
+		require.NoError(t, err)
+		defer shutdown(context.Background())
+
+		header := http.Header{"Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}}
+		ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.HeaderCarrier(header))
+		_, span := otel.Tracer(TracerName).Start(ctx, "operation")
+		defer span.End()
+
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+		assert.Equal(t, trace.SpanContextFromContext(ctx).SpanID(), span.(interface{ Parent() trace.SpanContext }).Parent().SpanID())
+	})
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/dto"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/database"
+	entityPkg "github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
+)
+
+type CategoryHandler struct {
+	categoryDB database.CategoryInterface
+}
+
+func NewCategoryHandler(db database.CategoryInterface) *CategoryHandler {
+	return &CategoryHandler{categoryDB: db}
+}
+
+// Create Category Godoc
+// @Summary Create a new category
+// @Description Create a new category, the name must be unique
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param category body dto.CreateCategoryInput true "Category to create"
+// @Success 201 {object} dto.CategoryOutput
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /categories [post]
+func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var categoryDTO dto.CreateCategoryInput
+	if !DecodeJSON(w, r, &categoryDTO) {
+		return
+	}
+	category, err := entity.NewCategory(categoryDTO.Name)
+	if err != nil {
+		ReturnError(w, r, err, "invalid category data")
+		return
+	}
+	if err := h.categoryDB.Create(r.Context(), category); err != nil {
+		ReturnError(w, r, err, "failed to create category")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newCategoryOutput(category))
+}
+
+// Get Category Godoc
+// @Summary Get a category by ID
+// @Description Get a category by ID
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Success 200 {object} dto.CategoryOutput
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /categories/{id} [get]
+func (h *CategoryHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
+	category, err := h.categoryDB.FindByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		ReturnError(w, r, err, "failed to load category")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(newCategoryOutput(category))
+}
+
+// Update Category Godoc
+// @Summary Rename a category
+// @Description Rename a category, the products keep it
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param category body dto.UpdateCategoryInput true "Category to update"
+// @Success 200 {object} dto.CategoryOutput
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /categories/{id} [put]
+func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	var categoryDTO dto.UpdateCategoryInput
+	if !DecodeJSON(w, r, &categoryDTO) {
+		return
+	}
+	category, err := h.categoryDB.FindByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		ReturnError(w, r, err, "failed to load category")
+		return
+	}
+	category.Name = categoryDTO.Name
+	if err := category.Validate(); err != nil {
+		ReturnError(w, r, err, "invalid category data")
+		return
+	}
+	if err := h.categoryDB.Update(r.Context(), category); err != nil {
+		ReturnError(w, r, err, "failed to update category")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(newCategoryOutput(category))
+}
+
+// Delete Category Godoc
+// @Summary Delete a category
+// @Description Delete a category and remove it from its products, the products are kept
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Success 204
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /categories/{id} [delete]
+func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	if err := h.categoryDB.Delete(r.Context(), chi.URLParam(r, "id")); err != nil {
+		ReturnError(w, r, err, "failed to delete category")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Get Categories Godoc
+// @Summary Get all categories
+// @Description Get all categories
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number, from 1" default(1)
+// @Param limit query int false "Number of items per page, 1 to 100" default(10)
+// @Param sort query string false "Sort by (id, name)"
+// @Param sort_direction query string false "Sort direction"
+// @Param include_total query bool false "Set to false to skip the total count"
+// @Success 200 {object} dto.CategoryOutput
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /categories [get]
+func (h *CategoryHandler) GetCategories(w http.ResponseWriter, r *http.Request) {
+	params, ok := ReadPageParams(w, r, map[string]string{
+		"id":   "cat_id",
+		"name": "cat_name",
+	}, "cat_name")
+	if !ok {
+		return
+	}
+	categories, err := h.categoryDB.FindAll(r.Context(), params.Page, params.Limit, params.OrderBy)
+	if err != nil {
+		ReturnError(w, r, err, "failed to list categories")
+		return
+	}
+	result := entityPkg.NewPageWithoutTotal(newCategoryOutputs(categories), params.Page, params.Limit, params.Sort, params.SortDir)
+	if params.IncludeTotal {
+		count, err := h.categoryDB.Count(r.Context())
+		if err != nil {
+			ReturnError(w, r, err, "failed to list categories")
+			return
+		}
+		result.Meta.SetTotal(int(count))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+func newCategoryOutput(category *entity.Category) dto.CategoryOutput {
+	return dto.CategoryOutput{ID: category.ID.String(), Name: category.Name}
+}
+
+func newCategoryOutputs(categories []entity.Category) []dto.CategoryOutput {
+	dtos := []dto.CategoryOutput{}
+	for _, category := range categories {
+		dtos = append(dtos, newCategoryOutput(&category))
+	}
+	return dtos
+}
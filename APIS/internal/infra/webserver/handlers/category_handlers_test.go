@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/dto"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/database"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/database/dbtest"
+	entityPkg "github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func categoryRequest(method, id, body string) *http.Request {
+	req := httptest.NewRequest(method, "/categories/"+id, strings.NewReader(body))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestCategoryHandler_CRUD(t *testing.T) {
+	db := dbtest.Open(t, dbtest.ProductTables...)
+	handler := NewCategoryHandler(database.NewCategoryDB(db, 0))
+
+	rec := httptest.NewRecorder()
+	handler.CreateCategory(rec, categoryRequest(http.MethodPost, "", `{"name":"Peripherals"}`))
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var created dto.CategoryOutput
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&created))
+	assert.Equal(t, "Peripherals", created.Name)
+
+	rec = httptest.NewRecorder()
+	handler.CreateCategory(rec, categoryRequest(http.MethodPost, "", `{"name":"Peripherals"}`))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	rec = httptest.NewRecorder()
+	handler.CreateCategory(rec, categoryRequest(http.MethodPost, "", `{}`))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.UpdateCategory(rec, categoryRequest(http.MethodPut, created.ID, `{"name":"Accessories"}`))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.GetCategory(rec, categoryRequest(http.MethodGet, created.ID, ""))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var found dto.CategoryOutput
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&found))
+	assert.Equal(t, dto.CategoryOutput{ID: created.ID, Name: "Accessories"}, found)
+
+	rec = httptest.NewRecorder()
+	handler.GetCategories(rec, httptest.NewRequest(http.MethodGet, "/categories", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var page entityPkg.Page[dto.CategoryOutput]
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&page))
+	assert.Equal(t, []dto.CategoryOutput{found}, page.Data)
+	require.NotNil(t, page.Meta.TotalItems)
+	assert.Equal(t, 1, *page.Meta.TotalItems)
+
+	rec = httptest.NewRecorder()
+	handler.DeleteCategory(rec, categoryRequest(http.MethodDelete, created.ID, ""))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.GetCategory(rec, categoryRequest(http.MethodGet, created.ID, ""))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	rec = httptest.NewRecorder()
+	handler.GetCategory(rec, categoryRequest(http.MethodGet, "invalid", ""))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.ErrorIs(t, handler.categoryDB.Delete(context.Background(), created.ID), entity.ErrCategoryNotFound)
+}
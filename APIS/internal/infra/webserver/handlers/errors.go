@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	"github.com/jb-oliveira/fullcycle/APIS/pkg/log"
+)
+
+// HttpStatus maps the kind of a domain error to its HTTP status code.
+// Errors without a kind are internal errors.
+func HttpStatus(err error) int {
+	switch {
+	case errors.Is(err, entity.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, entity.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, entity.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, entity.ErrPrecondition):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, entity.ErrUnprocessable):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, entity.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ReturnError logs err and writes it with the status of its kind. Domain
+// errors are sent with their own message, internal errors with message so
+// nothing about the database leaks to the client.
+func ReturnError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	log.Error(r.Context(), err.Error())
+	ReturnHttpError(w, errors.New(clientMessage(err, message)), HttpStatus(err))
+}
+
+// clientMessage is the message of a domain error, or message for internal
+// errors.
+func clientMessage(err error, message string) string {
+	var domainErr *entity.Error
+	if HttpStatus(err) != http.StatusInternalServerError && errors.As(err, &domainErr) {
+		return domainErr.Message
+	}
+	return message
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHttpStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "validation", err: entity.ErrNameRequired, want: http.StatusBadRequest},
+		{name: "not found", err: entity.Wrap(entity.ErrProductNotFound, errors.New("record not found")), want: http.StatusNotFound},
+		{name: "conflict", err: entity.ErrEmailTaken, want: http.StatusConflict},
+		{name: "precondition", err: entity.ErrProductModified, want: http.StatusPreconditionFailed},
+		{name: "unprocessable", err: entity.ErrIdempotencyKeyReused, want: http.StatusUnprocessableEntity},
+		{name: "unavailable", err: entity.Wrap(entity.ErrUnavailable, context.DeadlineExceeded), want: http.StatusServiceUnavailable},
+		{name: "unknown", err: errors.New("boom"), want: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, HttpStatus(tt.err))
+		})
+	}
+}
@@ -2,7 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/jb-oliveira/fullcycle/APIS/internal/dto"
 )
@@ -11,16 +15,127 @@ func ReturnHttpError(w http.ResponseWriter, err error, code int) {
 	ReturnHttpErrors(w, []error{err}, code)
 }
 
-func ReturnHttpErrors(w http.ResponseWriter, errors []error, code int) {
+func ReturnHttpErrors(w http.ResponseWriter, errs []error, code int) {
 	var messages []string
-	for _, err := range errors {
+	var fields map[string]string
+	for _, err := range errs {
 		messages = append(messages, err.Error())
+		var fieldErr *FieldError
+		if errors.As(err, &fieldErr) {
+			if fields == nil {
+				fields = make(map[string]string)
+			}
+			fields[fieldErr.Field] = fieldErr.Message
+		}
 	}
 	newVar := dto.ErrorResponse{
 		Messages: messages,
+		Fields:   fields,
 		Code:     code,
 	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
 	json.NewEncoder(w).Encode(newVar)
 }
+
+// PageParams are the pagination and sorting query parameters shared by
+// the list endpoints.
+type PageParams struct {
+	Page         int
+	Limit        int
+	Sort         string
+	SortDir      string
+	OrderBy      string
+	IncludeTotal bool
+}
+
+const (
+	defaultPageLimit = 10
+	// MaxPageLimit bounds the limit query parameter of the list endpoints
+	MaxPageLimit = 100
+)
+
+// ReadPageParams reads page, limit, sort, sort_direction and include_total
+// from the query string. sorts maps the public sort names to columns,
+// unknown names fall back to defaultColumn. include_total=false lets the
+// client skip the count. A page below 1 or a limit out of 1..MaxPageLimit
+// is answered with 400, then false is returned and the handler must stop.
+func ReadPageParams(w http.ResponseWriter, r *http.Request, sorts map[string]string, defaultColumn string) (PageParams, bool) {
+	page, err := intParam(r, "page", 1)
+	if err != nil || page < 1 {
+		ReturnHttpError(w, &FieldError{Field: "page", Message: "must be a positive integer"}, http.StatusBadRequest)
+		return PageParams{}, false
+	}
+	limit, err := intParam(r, "limit", defaultPageLimit)
+	if err != nil || limit < 1 || limit > MaxPageLimit {
+		ReturnHttpError(w, &FieldError{Field: "limit", Message: "must be an integer between 1 and " + strconv.Itoa(MaxPageLimit)}, http.StatusBadRequest)
+		return PageParams{}, false
+	}
+	sort := r.URL.Query().Get("sort")
+	sortDir := r.URL.Query().Get("sort_direction")
+	orderBy := sorts[sort]
+	if orderBy == "" {
+		orderBy = defaultColumn
+	}
+	if sortDir != "desc" {
+		sortDir = "asc"
+	}
+	return PageParams{
+		Page:         page,
+		Limit:        limit,
+		Sort:         sort,
+		SortDir:      sortDir,
+		OrderBy:      orderBy + " " + sortDir,
+		IncludeTotal: r.URL.Query().Get("include_total") != "false",
+	}, true
+}
+
+// intParam reads an integer query parameter, fallback when it is missing.
+func intParam(r *http.Request, name string, fallback int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(raw)
+}
+
+// ClientIP returns the address of the peer without the port. Proxy headers
+// are not trusted, they can be set by anyone.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+var (
+	errIfMatchRequired = errors.New("If-Match header is required, send the ETag of the resource")
+	errIfMatchFailed   = errors.New("resource was modified, reload it and try again")
+)
+
+// setETag sends version as a strong entity tag.
+func setETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", strconv.Quote(strconv.FormatInt(version, 10)))
+}
+
+// checkIfMatch compares the If-Match header with the current version. A
+// missing header is answered with 428 and a stale one with 412, in both
+// cases false is returned and the handler must stop.
+func checkIfMatch(w http.ResponseWriter, r *http.Request, version int64) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		ReturnHttpError(w, errIfMatchRequired, http.StatusPreconditionRequired)
+		return false
+	}
+	current := strconv.Quote(strconv.FormatInt(version, 10))
+	for tag := range strings.SplitSeq(header, ",") {
+		tag = strings.TrimSpace(tag)
+		// Weak tags never match, If-Match uses the strong comparison
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+	ReturnHttpError(w, errIfMatchFailed, http.StatusPreconditionFailed)
+	return false
+}
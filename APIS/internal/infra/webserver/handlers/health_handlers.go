@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/jb-oliveira/fullcycle/APIS/internal/dto"
+	"github.com/jb-oliveira/fullcycle/APIS/pkg/log"
+)
+
+// readyTimeout bounds the database ping of the readiness probe, a slow
+// database is as unready as a down one.
+const readyTimeout = 2 * time.Second
+
+// Pinger is the database the readiness probe checks, *sql.DB implements it.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+type HealthHandler struct {
+	db Pinger
+}
+
+func NewHealthHandler(db Pinger) *HealthHandler {
+	return &HealthHandler{db: db}
+}
+
+// Healthz godoc
+// @Summary      Liveness probe
+// @Description  Answers as long as the process serves requests, dependencies are not checked
+// @Tags         health
+// @Produce      json
+// @Success      200 {object} dto.HealthOutput
+// @Router       /healthz [get]
+func (h *HealthHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, dto.HealthOutput{Status: "ok"})
+}
+
+// Readyz godoc
+// @Summary      Readiness probe
+// @Description  Answers 200 when the database can be reached, 503 otherwise
+// @Tags         health
+// @Produce      json
+// @Success      200 {object} dto.HealthOutput
+// @Failure      503 {object} dto.HealthOutput
+// @Router       /readyz [get]
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+	defer cancel()
+
+	if err := h.db.PingContext(ctx); err != nil {
+		log.Error(r.Context(), "readiness check failed: "+err.Error())
+		writeHealth(w, http.StatusServiceUnavailable, dto.HealthOutput{
+			Status: "unavailable",
+			Checks: map[string]string{"database": "unreachable"},
+		})
+		return
+	}
+	writeHealth(w, http.StatusOK, dto.HealthOutput{Status: "ready", Checks: map[string]string{"database": "ok"}})
+}
+
+func writeHealth(w http.ResponseWriter, status int, output dto.HealthOutput) {
+	// Probes must always see the current state
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(output)
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jb-oliveira/fullcycle/APIS/internal/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type pingerFunc func(ctx context.Context) error
+
+func (f pingerFunc) PingContext(ctx context.Context) error {
+	return f(ctx)
+}
+
+func TestHealthHandler_Healthz(t *testing.T) {
+	handler := NewHealthHandler(pingerFunc(func(context.Context) error {
+		return errors.New("liveness must not ping the database")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.Healthz(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var output dto.HealthOutput
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&output))
+	assert.Equal(t, "ok", output.Status)
+}
+
+func TestHealthHandler_Readyz(t *testing.T) {
+	t.Run("should be ready when the database answers", func(t *testing.T) {
+		db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+		require.NoError(t, err)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		handler := NewHealthHandler(sqlDB)
+
+		rec := httptest.NewRecorder()
+		handler.Readyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+		var output dto.HealthOutput
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&output))
+		assert.Equal(t, "ready", output.Status)
+		assert.Equal(t, "ok", output.Checks["database"])
+	})
+
+	t.Run("should be unavailable when the database is closed", func(t *testing.T) {
+		db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+		require.NoError(t, err)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, sqlDB.Close())
+		handler := NewHealthHandler(sqlDB)
+
+		rec := httptest.NewRecorder()
+		handler.Readyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		var output dto.HealthOutput
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&output))
+		assert.Equal(t, "unavailable", output.Status)
+		assert.Equal(t, "unreachable", output.Checks["database"])
+	})
+
+	t.Run("should give up on a slow database", func(t *testing.T) {
+		handler := NewHealthHandler(pingerFunc(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		rec := httptest.NewRecorder()
+		handler.Readyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil).WithContext(ctx))
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	})
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jb-oliveira/fullcycle/APIS/internal/dto"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/database"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/mail"
+	"github.com/jb-oliveira/fullcycle/APIS/pkg/log"
+)
+
+var errInvalidResetToken = errors.New("Invalid or expired reset token")
+
+// resetMailTimeout bounds the background work of a forgot password request.
+const resetMailTimeout = 30 * time.Second
+
+type PasswordHandler struct {
+	userDB     database.UserInterface
+	tokenDB    database.TokenInterface
+	mailer     mail.Mailer
+	expiration int
+	resetURL   string
+	sending    sync.WaitGroup
+}
+
+// NewPasswordHandler creates the password recovery handler. resetURL is
+// the page that receives the token, "{token}" is replaced by its value.
+// When empty only the token is sent.
+func NewPasswordHandler(userDB database.UserInterface, tokenDB database.TokenInterface, mailer mail.Mailer, expiration int, resetURL string) *PasswordHandler {
+	return &PasswordHandler{
+		userDB:     userDB,
+		tokenDB:    tokenDB,
+		mailer:     mailer,
+		expiration: expiration,
+		resetURL:   resetURL,
+	}
+}
+
+// Forgot Password godoc
+// @Summary      Request a password reset
+// @Description  Send a single use reset token to the email. The response is the same whether the email exists or not
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.ForgotPasswordInput true "Account email"
+// @Success      202
+// @Failure      400 {object} dto.ErrorResponse
+// @Router       /users/password/forgot [post]
+func (h *PasswordHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	input := dto.ForgotPasswordInput{}
+	if !DecodeJSON(w, r, &input) {
+		return
+	}
+
+	// The token is stored and mailed in the background, so known and unknown
+	// emails are answered in the same time. Errors are only logged, the
+	// client must not learn if the account exists
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), resetMailTimeout)
+	h.sending.Go(func() {
+		defer cancel()
+		if err := h.sendResetToken(ctx, input.Email); err != nil {
+			log.Error(ctx, err.Error())
+		}
+	})
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Wait blocks until the reset emails still being sent are done.
+func (h *PasswordHandler) Wait() {
+	h.sending.Wait()
+}
+
+// Reset Password godoc
+// @Summary      Reset password
+// @Description  Set a new password using the token sent by /users/password/forgot. The token can be used only once, the other reset tokens of the user stop working and every session is revoked
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.ResetPasswordInput true "Reset token and new password"
+// @Success      204
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /users/password/reset [post]
+func (h *PasswordHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	input := dto.ResetPasswordInput{}
+	if !DecodeJSON(w, r, &input) {
+		return
+	}
+
+	token, err := h.tokenDB.FindPasswordResetTokenByHash(r.Context(), entity.HashToken(input.Token))
+	if err != nil && !errors.Is(err, entity.ErrNotFound) {
+		ReturnError(w, r, err, "Failed to reset password")
+		return
+	}
+	if err != nil {
+		log.Error(r.Context(), err.Error())
+		ReturnHttpError(w, errInvalidResetToken, http.StatusBadRequest)
+		return
+	}
+	if token.IsUsed() || token.IsExpired(time.Now()) {
+		ReturnHttpError(w, errInvalidResetToken, http.StatusBadRequest)
+		return
+	}
+	user, err := h.userDB.FindByID(r.Context(), token.UserID.String())
+	if err != nil {
+		log.Error(r.Context(), err.Error())
+		ReturnHttpError(w, errInvalidResetToken, http.StatusBadRequest)
+		return
+	}
+	if err := user.ChangePassword(input.NewPassword); err != nil {
+		ReturnError(w, r, err, "Failed to reset password")
+		return
+	}
+	// Burn the token, and the other ones sent to the user, before touching
+	// the password, so two concurrent requests can't both reset it
+	if err := h.tokenDB.UsePasswordResetToken(r.Context(), token); err != nil {
+		log.Error(r.Context(), err.Error())
+		ReturnHttpError(w, errInvalidResetToken, http.StatusBadRequest)
+		return
+	}
+	if err := h.userDB.Update(r.Context(), user); err != nil {
+		ReturnError(w, r, err, "Failed to reset password")
+		return
+	}
+	if err := h.tokenDB.RevokeUserRefreshTokens(r.Context(), user.ID.String()); err != nil {
+		log.Error(r.Context(), err.Error())
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *PasswordHandler) sendResetToken(ctx context.Context, email string) error {
+	user, err := h.userDB.FindByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	token, plain, err := entity.NewPasswordResetToken(user.ID, time.Second*time.Duration(h.expiration))
+	if err != nil {
+		return err
+	}
+	if err := h.tokenDB.CreatePasswordResetToken(ctx, token); err != nil {
+		return err
+	}
+
+	body := "Use this token to reset your password: " + plain
+	if h.resetURL != "" {
+		body = "Open the link below to reset your password:\n" + strings.ReplaceAll(h.resetURL, "{token}", plain)
+	}
+	body += "\n\nIt expires at " + token.ExpiresAt.Format(time.RFC1123) + ". If you didn't ask for it, ignore this email."
+
+	return h.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Password reset",
+		Body:    body,
+	})
+}
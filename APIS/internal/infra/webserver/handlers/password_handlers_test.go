@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jb-oliveira/fullcycle/APIS/internal/dto"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/database"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/database/dbtest"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/mail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupPasswordHandler(t *testing.T) (*PasswordHandler, *database.User, *mail.MemoryMailer) {
+	db := dbtest.Open(t, dbtest.UserTables...)
+
+	userDB := database.NewUserDB(db, 0)
+	mailer := mail.NewMemoryMailer()
+	handler := NewPasswordHandler(userDB, database.NewTokenDB(db, 0), mailer, 1800, "http://localhost:3000/reset?token={token}")
+	return handler, userDB, mailer
+}
+
+func postJSON(t *testing.T, handler http.HandlerFunc, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	payload, err := json.Marshal(body)
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(payload))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestPasswordHandler_ResetFlow(t *testing.T) {
+	handler, userDB, mailer := setupPasswordHandler(t)
+
+	user, err := entity.NewUser("John Doe", "john@example.com", "oldPassword")
+	require.NoError(t, err)
+	require.NoError(t, userDB.Create(context.Background(), user))
+
+	rec := postJSON(t, handler.ForgotPassword, dto.ForgotPasswordInput{Email: "john@example.com"})
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	handler.Wait()
+
+	messages := mailer.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "john@example.com", messages[0].To)
+	_, after, found := strings.Cut(messages[0].Body, "token=")
+	require.True(t, found)
+	token, _, _ := strings.Cut(after, "\n")
+
+	rec = postJSON(t, handler.ResetPassword, dto.ResetPasswordInput{Token: token, NewPassword: "newPassword"})
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	stored, err := userDB.FindByID(context.Background(), user.ID.String())
+	require.NoError(t, err)
+	assert.True(t, stored.ValidatePassword("newPassword"))
+	assert.False(t, stored.ValidatePassword("oldPassword"))
+
+	// The token is single use
+	rec = postJSON(t, handler.ResetPassword, dto.ResetPasswordInput{Token: token, NewPassword: "otherPassword"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestPasswordHandler_ForgotUnknownEmail(t *testing.T) {
+	handler, _, mailer := setupPasswordHandler(t)
+
+	rec := postJSON(t, handler.ForgotPassword, dto.ForgotPasswordInput{Email: "nobody@example.com"})
+	assert.Equal(t, http.StatusAccepted, rec.Code, "Unknown emails must get the same response")
+	handler.Wait()
+	assert.Empty(t, mailer.Messages())
+}
+
+func TestPasswordHandler_ResetInvalidToken(t *testing.T) {
+	handler, _, _ := setupPasswordHandler(t)
+
+	rec := postJSON(t, handler.ResetPassword, dto.ResetPasswordInput{Token: "invalid", NewPassword: "newPassword"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+// slowMailer takes its time, like a real SMTP server.
+type slowMailer struct {
+	mail.MemoryMailer
+	delay time.Duration
+}
+
+func (m *slowMailer) Send(ctx context.Context, msg mail.Message) error {
+	time.Sleep(m.delay)
+	return m.MemoryMailer.Send(ctx, msg)
+}
+
+func TestPasswordHandler_ForgotDoesNotWaitForTheMail(t *testing.T) {
+	db := dbtest.Open(t, dbtest.UserTables...)
+	userDB := database.NewUserDB(db, 0)
+	mailer := &slowMailer{delay: 200 * time.Millisecond}
+	handler := NewPasswordHandler(userDB, database.NewTokenDB(db, 0), mailer, 1800, "")
+
+	user, err := entity.NewUser("John Doe", "john@example.com", "password123")
+	require.NoError(t, err)
+	require.NoError(t, userDB.Create(context.Background(), user))
+
+	start := time.Now()
+	rec := postJSON(t, handler.ForgotPassword, dto.ForgotPasswordInput{Email: "john@example.com"})
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Less(t, time.Since(start), mailer.delay, "A known email must not take longer to answer")
+
+	handler.Wait()
+	assert.Len(t, mailer.Messages(), 1)
+}
@@ -0,0 +1,370 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"iter"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jb-oliveira/fullcycle/APIS/internal/dto"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	entityPkg "github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
+	"github.com/jb-oliveira/fullcycle/APIS/pkg/log"
+)
+
+const (
+	csvContentType    = "text/csv"
+	ndjsonContentType = "application/x-ndjson"
+
+	// importBatchSize is the number of rows saved by each transaction
+	importBatchSize = 500
+	// exportBatchSize is the number of products read by each query
+	exportBatchSize = 500
+	// maxNDJSONLine bounds the memory used by a single NDJSON row
+	maxNDJSONLine = 64 * 1024
+)
+
+// formulaPrefixes are the first characters that make a spreadsheet run a
+// CSV cell as a formula. The quote that disarms them is listed too, so
+// escapeFormula can be undone.
+const formulaPrefixes = "=+-@\t\r'"
+
+var errCSVHeader = errors.New("the first CSV row must be a header with the name and price columns")
+
+// importRow is a row of an uploaded file, err is set when it can't be read.
+type importRow struct {
+	line  int
+	input dto.CreateProductInput
+	err   error
+}
+
+// Import Products Godoc
+// @Summary Import products
+// @Description Create products from a CSV file (header with name and price columns) or NDJSON (one {"name","price"} object per line). Other columns and members are ignored, so an export can be imported back. Rows are saved in batches, each in its own transaction, and the invalid ones are reported by line. The file is limited to IMPORT_MAX_SIZE bytes, a chunked body is cut there
+// @Tags Products
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Param file body string true "CSV or NDJSON rows"
+// @Success 200 {object} dto.ImportProductsOutput
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 413 {object} dto.ErrorResponse
+// @Failure 415 {object} dto.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /products/import [post]
+func (h *ProductHandler) ImportProducts(w http.ResponseWriter, r *http.Request) {
+	if r.ContentLength > h.maxImportSize {
+		ReturnHttpError(w, errors.New("file cannot exceed "+strconv.FormatInt(h.maxImportSize, 10)+" bytes"), http.StatusRequestEntityTooLarge)
+		return
+	}
+	// A chunked body has no length, it is cut at the limit and the rows
+	// read until then are still imported
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxImportSize)
+	// A large file takes longer than the server timeouts, reading it and
+	// writing the report get the import budget instead
+	controller := http.NewResponseController(w)
+	deadline := time.Now().Add(h.importTimeout)
+	controller.SetReadDeadline(deadline)
+	controller.SetWriteDeadline(deadline)
+
+	var rows iter.Seq[importRow]
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case csvContentType:
+		var err error
+		if rows, err = csvRows(r.Context(), r.Body); err != nil {
+			log.Error(r.Context(), err.Error())
+			ReturnHttpError(w, errCSVHeader, http.StatusBadRequest)
+			return
+		}
+	case ndjsonContentType:
+		rows = ndjsonRows(r.Context(), r.Body)
+	default:
+		ReturnHttpError(w, errors.New("Content-Type must be "+csvContentType+" or "+ndjsonContentType), http.StatusUnsupportedMediaType)
+		return
+	}
+	report := dto.ImportProductsOutput{Errors: []dto.ImportRowError{}}
+	fail := func(line int, message string) {
+		report.Failed++
+		report.Errors = append(report.Errors, dto.ImportRowError{Line: line, Message: message})
+	}
+	batch := make([]entity.Product, 0, importBatchSize)
+	lines := make([]int, 0, importBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := h.productDB.CreateBatch(r.Context(), batch); err != nil {
+			log.Error(r.Context(), err.Error())
+			message := clientMessage(err, "failed to save the product")
+			for _, line := range lines {
+				fail(line, message)
+			}
+		} else {
+			report.Imported += len(batch)
+		}
+		batch = batch[:0]
+		lines = lines[:0]
+	}
+
+	for row := range rows {
+		if r.Context().Err() != nil {
+			return
+		}
+		if row.err != nil {
+			fail(row.line, row.err.Error())
+			continue
+		}
+		product, err := entity.NewProduct(row.input.Name, row.input.Price)
+		if err != nil {
+			fail(row.line, err.Error())
+			continue
+		}
+		batch = append(batch, *product)
+		lines = append(lines, row.line)
+		if len(batch) == importBatchSize {
+			flush()
+		}
+	}
+	flush()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
+
+// csvRows reads the header and returns the data rows. Columns are found by
+// name, in any order.
+func csvRows(ctx context.Context, body io.Reader) (iter.Seq[importRow], error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	nameCol, priceCol, currencyCol := -1, -1, -1
+	for i, column := range header {
+		switch strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))) {
+		case "name":
+			nameCol = i
+		case "price":
+			priceCol = i
+		case "currency":
+			currencyCol = i
+		}
+	}
+	if nameCol < 0 || priceCol < 0 {
+		return nil, errors.New("CSV header without name or price: " + strings.Join(header, ","))
+	}
+
+	return func(yield func(importRow) bool) {
+		for {
+			record, err := reader.Read()
+			if err == io.EOF {
+				return
+			}
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				if !yield(importRow{line: parseErr.Line, err: errors.New("malformed CSV row")}) {
+					return
+				}
+				continue
+			}
+			if err != nil {
+				// The body can't be read any further
+				log.Error(ctx, err.Error())
+				yield(importRow{err: readError(err, "failed to read the file, import stopped")})
+				return
+			}
+			line, _ := reader.FieldPos(0)
+			if !yield(csvRow(line, record, nameCol, priceCol, currencyCol)) {
+				return
+			}
+		}
+	}, nil
+}
+
+// csvRow reads a data row. The currency column is optional, without it
+// the prices are in the default currency.
+func csvRow(line int, record []string, nameCol, priceCol, currencyCol int) importRow {
+	row := importRow{line: line}
+	if len(record) <= max(nameCol, priceCol, currencyCol) {
+		row.err = errors.New("row has fewer columns than the header")
+		return row
+	}
+	currency := ""
+	if currencyCol >= 0 {
+		currency = strings.ToUpper(strings.TrimSpace(record[currencyCol]))
+	}
+	price, err := entityPkg.ParseMoney(strings.TrimSpace(record[priceCol]), currency)
+	if errors.Is(err, entityPkg.ErrInvalidCurrency) {
+		row.err = &FieldError{Field: "currency", Message: "must be a 3 letter ISO 4217 code"}
+		return row
+	}
+	if err != nil {
+		row.err = &FieldError{Field: "price", Message: moneyMessage}
+		return row
+	}
+	row.input = dto.CreateProductInput{Name: unescapeFormula(record[nameCol]), Price: price}
+	return row
+}
+
+// escapeFormula quotes a CSV cell a spreadsheet would take for a formula,
+// "=1+1" is written '=1+1.
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune(formulaPrefixes, rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// unescapeFormula undoes escapeFormula, an export is imported back as is.
+func unescapeFormula(cell string) string {
+	if len(cell) > 1 && cell[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(cell[1])) {
+		return cell[1:]
+	}
+	return cell
+}
+
+// ndjsonRows returns one row per non empty line.
+func ndjsonRows(ctx context.Context, body io.Reader) iter.Seq[importRow] {
+	return func(yield func(importRow) bool) {
+		scanner := bufio.NewScanner(body)
+		scanner.Buffer(make([]byte, 0, 4096), maxNDJSONLine)
+		line := 0
+		for scanner.Scan() {
+			line++
+			data := scanner.Bytes()
+			if len(bytes.TrimSpace(data)) == 0 {
+				continue
+			}
+			row := importRow{line: line}
+			if err := json.Unmarshal(data, &row.input); err != nil {
+				row.err = decodeError(err)
+			}
+			if !yield(row) {
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			log.Error(ctx, err.Error())
+			yield(importRow{line: line + 1, err: readError(err, "line is too long or unreadable, import stopped")})
+		}
+	}
+}
+
+// readError is the error of the row where the body stopped being readable.
+func readError(err error, message string) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return errors.New("file cannot exceed " + strconv.FormatInt(maxBytesErr.Limit, 10) + " bytes, import stopped")
+	}
+	return errors.New(message)
+}
+
+// Export Products Godoc
+// @Summary Export products
+// @Description Stream the products matching the filters as CSV (id, name, price, currency) or NDJSON, in id order. CSV names a spreadsheet would run as a formula are prefixed with ', the import removes it
+// @Tags Products
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "csv (default) or ndjson"
+// @Param name query string false "Name contains (case insensitive)"
+// @Param q query string false "Full-text search on the name"
+// @Param currency query string false "Price currency (ISO 4217), required with min_price or max_price"
+// @Param min_price query number false "Minimum price, in currency"
+// @Param max_price query number false "Maximum price, in currency"
+// @Param created_after query string false "Created at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param created_before query string false "Created before (RFC 3339 or YYYY-MM-DD)"
+// @Param category query string false "Category ID"
+// @Success 200 {string} string "CSV or NDJSON rows"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /products/export [get]
+func (h *ProductHandler) ExportProducts(w http.ResponseWriter, r *http.Request) {
+	filter, errs := readProductFilter(r)
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "ndjson" {
+		errs = append(errs, &FieldError{Field: "format", Message: "must be one of csv, ndjson"})
+	}
+	if len(errs) > 0 {
+		ReturnHttpErrors(w, errs, http.StatusBadRequest)
+		return
+	}
+
+	// The first page is read before anything is written, so its errors
+	// still get a proper status
+	products, more, err := h.productDB.FindByCursor(r.Context(), filter, nil, false, exportBatchSize)
+	if err != nil {
+		ReturnError(w, r, err, "failed to export products")
+		return
+	}
+
+	var write func(product entity.Product) error
+	var flush func() error
+	if format == "csv" {
+		writer := csv.NewWriter(w)
+		write = func(product entity.Product) error {
+			return writer.Write([]string{product.ID.String(), escapeFormula(product.Name), product.Price.Decimal(), product.Price.Currency})
+		}
+		flush = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+		w.Header().Set("Content-Type", csvContentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="products.csv"`)
+		w.WriteHeader(http.StatusOK)
+		writer.Write([]string{"id", "name", "price", "currency"})
+	} else {
+		encoder := json.NewEncoder(w)
+		write = func(product entity.Product) error {
+			return encoder.Encode(dto.ProductOutput{ID: product.ID.String(), Name: product.Name, Price: product.Price})
+		}
+		flush = func() error { return nil }
+		w.Header().Set("Content-Type", ndjsonContentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="products.ndjson"`)
+		w.WriteHeader(http.StatusOK)
+	}
+
+	controller := http.NewResponseController(w)
+	// The export lasts as long as there are products, not as long as the
+	// server write timeout
+	controller.SetWriteDeadline(time.Time{})
+	for {
+		for _, product := range products {
+			if err := write(product); err != nil {
+				log.Error(r.Context(), err.Error())
+				return
+			}
+		}
+		if err := flush(); err != nil {
+			log.Error(r.Context(), err.Error())
+			return
+		}
+		controller.Flush()
+		if !more {
+			return
+		}
+		cursor := &entityPkg.Cursor{ID: products[len(products)-1].ID}
+		products, more, err = h.productDB.FindByCursor(r.Context(), filter, cursor, false, exportBatchSize)
+		if err != nil {
+			// The status is already sent, break the connection so the
+			// client doesn't take a truncated file for a complete one
+			log.Error(r.Context(), err.Error())
+			panic(http.ErrAbortHandler)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jb-oliveira/fullcycle/APIS/internal/dto"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/database"
+	entityPkg "github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func importProducts(t *testing.T, handler *ProductHandler, contentType, body string) (*httptest.ResponseRecorder, dto.ImportProductsOutput) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/products/import", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+	handler.ImportProducts(rec, req)
+
+	var report dto.ImportProductsOutput
+	if rec.Code == http.StatusOK {
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&report))
+	}
+	return rec, report
+}
+
+func TestProductHandler_ImportCSV(t *testing.T) {
+	handler, productDB := setupProductHandler(t)
+
+	body := "\ufeffPrice,Name\n" +
+		"10.5,Keyboard\n" +
+		"abc,Mouse\n" +
+		"0,Free\n" +
+		"\"25\",\"Monitor, 24\"\"\"\n"
+	rec, report := importProducts(t, handler, "text/csv; charset=utf-8", body)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, 2, report.Failed)
+	assert.Equal(t, []dto.ImportRowError{
+		{Line: 3, Message: "price " + moneyMessage},
+		{Line: 4, Message: entity.ErrInvalidPrice.Error()},
+	}, report.Errors)
+
+	products, err := productDB.FindAll(context.Background(), database.ProductFilter{}, 1, 10, "prd_name asc")
+	require.NoError(t, err)
+	require.Len(t, products, 2)
+	assert.Equal(t, "Keyboard", products[0].Name)
+	assert.Equal(t, `Monitor, 24"`, products[1].Name)
+	assert.Equal(t, int64(1), products[1].Version.Int64)
+}
+
+func TestProductHandler_ImportCSVWithCurrency(t *testing.T) {
+	handler, productDB := setupProductHandler(t)
+
+	body := "name,price,currency\n" +
+		"Keyboard,10.5,usd\n" +
+		"Mouse,5,\n" +
+		"Monitor,250,dollars\n" +
+		"Cable,1.999,BRL\n"
+	rec, report := importProducts(t, handler, csvContentType, body)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, []dto.ImportRowError{
+		{Line: 4, Message: "currency must be a 3 letter ISO 4217 code"},
+		{Line: 5, Message: "price " + moneyMessage},
+	}, report.Errors)
+
+	products, err := productDB.FindAll(context.Background(), database.ProductFilter{}, 1, 10, "prd_name asc")
+	require.NoError(t, err)
+	require.Len(t, products, 2)
+	assert.Equal(t, entityPkg.NewMoney(1050, "USD"), products[0].Price)
+	assert.Equal(t, entityPkg.NewMoney(500, entityPkg.DefaultCurrency), products[1].Price, "An empty currency is the default one")
+}
+
+func TestProductHandler_ImportNDJSON(t *testing.T) {
+	handler, productDB := setupProductHandler(t)
+
+	body := `{"name":"Keyboard","price":10.5}` + "\n" +
+		"\n" +
+		`{"name":"","price":5}` + "\n" +
+		`{"name":"Mouse","price":"cheap"}` + "\n" +
+		`{"id":"ignored","name":"Monitor","price":250}`
+	rec, report := importProducts(t, handler, "application/x-ndjson", body)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	assert.Equal(t, 2, report.Imported)
+	require.Len(t, report.Errors, 2)
+	assert.Equal(t, dto.ImportRowError{Line: 3, Message: entity.ErrNameRequired.Error()}, report.Errors[0])
+	assert.Equal(t, 4, report.Errors[1].Line)
+	assert.Contains(t, report.Errors[1].Message, moneyMessage)
+
+	count, err := productDB.Count(context.Background(), database.ProductFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestProductHandler_ImportInvalidFile(t *testing.T) {
+	handler, _ := setupProductHandler(t)
+
+	rec, _ := importProducts(t, handler, "application/json", `[]`)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+
+	rec, _ = importProducts(t, handler, "text/csv", "title,cost\nKeyboard,10\n")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec, _ = importProducts(t, handler, "text/csv", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestProductHandler_ImportTooLarge(t *testing.T) {
+	handler, productDB := setupProductHandler(t)
+
+	body := "name,price\n"
+	for i := 0; len(body) <= testImportMaxSize; i++ {
+		body += "Keyboard " + strconv.Itoa(i) + ",1\n"
+	}
+
+	rec, _ := importProducts(t, handler, csvContentType, body)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code, "The length is checked before reading")
+	count, err := productDB.Count(context.Background(), database.ProductFilter{})
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	// A chunked body is cut at the limit
+	req := httptest.NewRequest(http.MethodPost, "/products/import", strings.NewReader(body))
+	req.Header.Set("Content-Type", csvContentType)
+	req.ContentLength = -1
+	rec = httptest.NewRecorder()
+	handler.ImportProducts(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var report dto.ImportProductsOutput
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&report))
+	assert.Positive(t, report.Imported)
+	require.NotEmpty(t, report.Errors)
+	assert.Equal(t, "file cannot exceed 4096 bytes, import stopped", report.Errors[len(report.Errors)-1].Message)
+}
+
+func TestProductHandler_ImportOutlastsServerTimeouts(t *testing.T) {
+	handler, productDB := setupProductHandler(t)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(handler.ImportProducts))
+	server.Config.ReadTimeout = 200 * time.Millisecond
+	server.Config.WriteTimeout = 200 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	// The rows arrive slower than both server timeouts allow
+	body, writer := io.Pipe()
+	go func() {
+		writer.Write([]byte("name,price\n"))
+		for i := range 3 {
+			time.Sleep(150 * time.Millisecond)
+			writer.Write([]byte("Keyboard " + strconv.Itoa(i) + ",10\n"))
+		}
+		writer.Close()
+	}()
+	resp, err := http.Post(server.URL, csvContentType, body)
+	require.NoError(t, err, "The report must be sent after the write timeout")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var report dto.ImportProductsOutput
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	assert.Equal(t, 3, report.Imported)
+	count, err := productDB.Count(context.Background(), database.ProductFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestProductHandler_Export(t *testing.T) {
+	handler, productDB := setupProductHandler(t)
+
+	var products []entity.Product
+	for i := range exportBatchSize + 2 {
+		name := "Mouse"
+		if i%2 == 0 {
+			name = "Keyboard"
+		}
+		product, err := entity.NewProduct(name, entityPkg.NewMoney(int64(i+1)*100, "BRL"))
+		require.NoError(t, err)
+		products = append(products, *product)
+	}
+	require.NoError(t, productDB.CreateBatch(context.Background(), products))
+
+	export := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/products/export?"+query, nil)
+		rec := httptest.NewRecorder()
+		handler.ExportProducts(rec, req)
+		return rec
+	}
+
+	rec := export("")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv", rec.Header().Get("Content-Type"))
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	require.Len(t, lines, len(products)+1, "Every page must be written")
+	assert.Equal(t, "id,name,price,currency", lines[0])
+	assert.Equal(t, products[0].ID.String()+",Keyboard,1.00,BRL", lines[1])
+
+	rec = export("format=ndjson&name=keyboard&currency=BRL&max_price=5")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+	decoder := json.NewDecoder(rec.Body)
+	var names []string
+	for decoder.More() {
+		var output dto.ProductOutput
+		require.NoError(t, decoder.Decode(&output))
+		names = append(names, output.Name)
+	}
+	assert.Equal(t, []string{"Keyboard", "Keyboard", "Keyboard"}, names)
+
+	rec = export("format=xml")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestProductHandler_ExportEscapesFormulas(t *testing.T) {
+	handler, productDB := setupProductHandler(t)
+
+	names := []string{`=HYPERLINK("http://evil.example")`, "+1", "-5 Cable", "@SUM(A1)", "'=quoted", "Plain"}
+	for _, name := range names {
+		product, err := entity.NewProduct(name, entityPkg.NewMoney(100, "BRL"))
+		require.NoError(t, err)
+		require.NoError(t, productDB.Create(context.Background(), product))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/products/export", nil)
+	rec := httptest.NewRecorder()
+	handler.ExportProducts(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	records, err := csv.NewReader(strings.NewReader(rec.Body.String())).ReadAll()
+	require.NoError(t, err)
+	var exported []string
+	for _, record := range records[1:] {
+		exported = append(exported, record[1])
+	}
+	assert.ElementsMatch(t, []string{`'=HYPERLINK("http://evil.example")`, "'+1", "'-5 Cable", "'@SUM(A1)", "''=quoted", "Plain"}, exported)
+
+	// The export is imported back with the original names
+	other, otherDB := setupProductHandler(t)
+	rec, report := importProducts(t, other, csvContentType, rec.Body.String())
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, len(names), report.Imported)
+	products, err := otherDB.FindAll(context.Background(), database.ProductFilter{}, 1, 10, "prd_name asc")
+	require.NoError(t, err)
+	var imported []string
+	for _, product := range products {
+		imported = append(imported, product.Name)
+	}
+	assert.ElementsMatch(t, names, imported)
+}
@@ -1,58 +1,76 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"io"
+	"mime"
 	"net/http"
-	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/jb-oliveira/fullcycle/APIS/internal/dto"
 	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
 	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/database"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/storage"
 	entityPkg "github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
 	"github.com/jb-oliveira/fullcycle/APIS/pkg/log"
+	"github.com/jb-oliveira/fullcycle/APIS/pkg/mergepatch"
 )
 
 type ProductHandler struct {
-	productDB database.ProductInterface
+	productDB     database.ProductInterface
+	blobStore     storage.BlobStore
+	maxImageSize  int64
+	maxImportSize int64
+	importTimeout time.Duration
 }
 
-func NewProductHandler(db database.ProductInterface) *ProductHandler {
-	return &ProductHandler{productDB: db}
+// NewProductHandler keeps the uploaded images in blobStore, up to
+// maxImageSize bytes each. The imported files are up to maxImportSize bytes,
+// read and answered within importTimeout.
+func NewProductHandler(db database.ProductInterface, blobStore storage.BlobStore, maxImageSize, maxImportSize int64, importTimeout time.Duration) *ProductHandler {
+	return &ProductHandler{
+		productDB:     db,
+		blobStore:     blobStore,
+		maxImageSize:  maxImageSize,
+		maxImportSize: maxImportSize,
+		importTimeout: importTimeout,
+	}
 }
 
 // Create Product Godoc
 // @Summary Create a new product
-// @Description Create a new product
+// @Description Create a new product. Send an Idempotency-Key to retry it safely: a retry with the same key and body gets the first response back, a retry with another body gets 422 and one sent while the first request still runs gets 409
 // @Tags Products
 // @Accept json
 // @Produce json
 // @Param product body dto.CreateProductInput true "Product to create"
+// @Param Idempotency-Key header string false "Sending the request again with the same key replays the first response for 24 hours"
 // @Success 201 {object} dto.ProductOutput
+// @Header 201 {string} Idempotent-Replayed "true when the response was replayed"
 // @Failure 400 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Security ApiKeyAuth
 // @Router /products [post]
 func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	var productDTO dto.CreateProductInput
-	err := json.NewDecoder(r.Body).Decode(&productDTO)
-	if err != nil {
-		log.Error(err.Error())
-		ReturnHttpError(w, errors.New("invalid request body"), http.StatusBadRequest)
+	if !DecodeJSON(w, r, &productDTO) {
 		return
 	}
 	// Should be through Use Case, but for now it's going direct
 	p, err := entity.NewProduct(productDTO.Name, productDTO.Price)
 	if err != nil {
-		log.Error(err.Error())
-		ReturnHttpError(w, errors.New("invalid product data"), http.StatusBadRequest)
+		ReturnError(w, r, err, "invalid product data")
 		return
 	}
-	err = h.productDB.Create(p)
+	err = h.productDB.Create(r.Context(), p)
 	if err != nil {
-		log.Error(err.Error())
-		ReturnHttpError(w, errors.New("failed to create product"), http.StatusInternalServerError)
+		ReturnError(w, r, err, "failed to create product")
 		return
 	}
 	productOutput := dto.ProductOutput{
@@ -60,6 +78,7 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 		Name:  p.Name,
 		Price: p.Price,
 	}
+	setETag(w, p.Version.Int64)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(productOutput)
@@ -73,6 +92,7 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path string true "Product ID"
 // @Success 200 {object} dto.ProductOutput
+// @Header 200 {string} ETag "Version of the product, send it back in If-Match"
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 404 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
@@ -81,37 +101,36 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		log.Error("invalid id")
+		log.Error(r.Context(), "invalid id")
 		ReturnHttpError(w, errors.New("invalid id"), http.StatusBadRequest)
 		return
 	}
-	product, err := h.productDB.FindByID(id)
+	product, err := h.productDB.FindByID(r.Context(), id)
 	if err != nil {
-		log.Error(err.Error())
-		ReturnHttpError(w, errors.New("product not found"), http.StatusNotFound)
+		ReturnError(w, r, err, "failed to load product")
 		return
 	}
+	setETag(w, product.Version.Int64)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	dto := dto.ProductOutput{
-		ID:    product.ID.String(),
-		Name:  product.Name,
-		Price: product.Price,
-	}
-	json.NewEncoder(w).Encode(dto)
+	json.NewEncoder(w).Encode(h.newProductOutput(product))
 }
 
 // Update Product Godoc
 // @Summary Update a product
-// @Description Update a product
+// @Description Update a product. If-Match must carry the ETag returned by GET /products/{id}
 // @Tags Products
 // @Accept json
 // @Produce json
 // @Param id path string true "Product ID"
+// @Param If-Match header string true "ETag of the product"
 // @Param product body dto.UpdateProductInput true "Product to update"
 // @Success 200 {object} dto.ProductOutput
+// @Header 200 {string} ETag "New version of the product"
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 404 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 428 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Security ApiKeyAuth
 // @Router /products/{id} [put]
@@ -119,46 +138,133 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	// acquire the id
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		log.Error("id is required")
+		log.Error(r.Context(), "id is required")
 		ReturnHttpError(w, errors.New("id is required"), http.StatusBadRequest)
 		return
 	}
 	_, err := entityPkg.ParseID(id)
 	if err != nil {
-		log.Error(err.Error())
+		log.Error(r.Context(), err.Error())
 		ReturnHttpError(w, err, http.StatusBadRequest)
 		return
 	}
 	// deserialize
 	var productDTO dto.UpdateProductInput
-	err = json.NewDecoder(r.Body).Decode(&productDTO)
-	if err != nil {
-		log.Error(err.Error())
-		ReturnHttpError(w, errors.New("invalid request body"), http.StatusBadRequest)
+	if !DecodeJSON(w, r, &productDTO) {
 		return
 	}
 	// load the product
-	product, err := h.productDB.FindByID(id)
+	product, err := h.productDB.FindByID(r.Context(), id)
 	if err != nil {
-		log.Error(err.Error())
-		ReturnHttpError(w, errors.New("product not found"), http.StatusNotFound)
+		ReturnError(w, r, err, "failed to load product")
+		return
+	}
+	if !checkIfMatch(w, r, product.Version.Int64) {
 		return
 	}
 	// save the product
 	product.Name = productDTO.Name
 	product.Price = productDTO.Price
-	err = h.productDB.Update(product)
+	if err := product.Validate(); err != nil {
+		ReturnError(w, r, err, "invalid product data")
+		return
+	}
+	err = h.productDB.Update(r.Context(), product)
 	if err != nil {
-		log.Error(err.Error())
-		ReturnHttpError(w, err, http.StatusInternalServerError)
+		ReturnError(w, r, err, "failed to update product")
 		return
 	}
 	// Return the product
-	productOutput := dto.ProductOutput{
-		ID:    product.ID.String(),
-		Name:  product.Name,
-		Price: product.Price,
+	productOutput := h.newProductOutput(product)
+	setETag(w, product.Version.Int64)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(productOutput)
+}
+
+// Patch Product Godoc
+// @Summary Partially update a product
+// @Description Apply a JSON Merge Patch (RFC 7396) to a product, only the members sent are changed. If-Match must carry the ETag returned by GET /products/{id}
+// @Tags Products
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param If-Match header string true "ETag of the product"
+// @Param patch body dto.UpdateProductInput true "Members to change"
+// @Success 200 {object} dto.ProductOutput
+// @Header 200 {string} ETag "New version of the product"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 415 {object} dto.ErrorResponse
+// @Failure 428 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /products/{id} [patch]
+func (h *ProductHandler) PatchProduct(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entityPkg.ParseID(id); err != nil {
+		log.Error(r.Context(), err.Error())
+		ReturnHttpError(w, errors.New("invalid id"), http.StatusBadRequest)
+		return
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != mergepatch.ContentType {
+		ReturnHttpError(w, errors.New("Content-Type must be "+mergepatch.ContentType), http.StatusUnsupportedMediaType)
+		return
+	}
+	patch, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxJSONBody))
+	if err != nil {
+		log.Error(r.Context(), err.Error())
+		returnBodyError(w, err, errors.New("Invalid request body"))
+		return
+	}
+	product, err := h.productDB.FindByID(r.Context(), id)
+	if err != nil {
+		ReturnError(w, r, err, "failed to load product")
+		return
+	}
+	if !checkIfMatch(w, r, product.Version.Int64) {
+		return
+	}
+	// The patch applies to the same document PUT accepts
+	current, err := json.Marshal(dto.UpdateProductInput{Name: product.Name, Price: product.Price})
+	if err != nil {
+		ReturnError(w, r, err, "failed to update product")
+		return
+	}
+	merged, err := mergepatch.Apply(current, patch)
+	if err != nil {
+		log.Error(r.Context(), err.Error())
+		ReturnHttpError(w, mergepatch.ErrInvalidPatch, http.StatusBadRequest)
+		return
+	}
+	var productDTO dto.UpdateProductInput
+	if !decodeJSON(r.Context(), w, bytes.NewReader(merged), &productDTO) {
+		return
+	}
+
+	var changed []string
+	if productDTO.Name != product.Name {
+		product.Name = productDTO.Name
+		changed = append(changed, "Name")
+	}
+	if productDTO.Price != product.Price {
+		product.Price = productDTO.Price
+		changed = append(changed, "Price")
 	}
+	if err := product.Validate(); err != nil {
+		ReturnError(w, r, err, "invalid product data")
+		return
+	}
+	if len(changed) > 0 {
+		if err := h.productDB.Update(r.Context(), product, changed...); err != nil {
+			ReturnError(w, r, err, "failed to update product")
+			return
+		}
+	}
+	productOutput := h.newProductOutput(product)
+	setETag(w, product.Version.Int64)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(productOutput)
@@ -166,40 +272,44 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 
 // Delete Product Godoc
 // @Summary Delete a product
-// @Description Delete a product
+// @Description Delete a product. If-Match must carry the ETag returned by GET /products/{id}
 // @Tags Products
 // @Accept json
 // @Produce json
 // @Param id path string true "Product ID"
+// @Param If-Match header string true "ETag of the product"
 // @Success 204
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 404 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 428 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Security ApiKeyAuth
 // @Router /products/{id} [delete]
 func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		log.Error("id is required")
+		log.Error(r.Context(), "id is required")
 		ReturnHttpError(w, errors.New("id is required"), http.StatusBadRequest)
 		return
 	}
 	_, err := entityPkg.ParseID(id)
 	if err != nil {
-		log.Error(err.Error())
+		log.Error(r.Context(), err.Error())
 		ReturnHttpError(w, err, http.StatusBadRequest)
 		return
 	}
-	product, err := h.productDB.FindByID(id)
+	product, err := h.productDB.FindByID(r.Context(), id)
 	if err != nil {
-		log.Error(err.Error())
-		ReturnHttpError(w, errors.New("product not found"), http.StatusNotFound)
+		ReturnError(w, r, err, "failed to load product")
 		return
 	}
-	err = h.productDB.Delete(product.ID.String())
+	if !checkIfMatch(w, r, product.Version.Int64) {
+		return
+	}
+	err = h.productDB.Delete(r.Context(), product.ID.String(), product.Version.Int64)
 	if err != nil {
-		log.Error(err.Error())
-		ReturnHttpError(w, err, http.StatusInternalServerError)
+		ReturnError(w, r, err, "failed to delete product")
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -212,64 +322,406 @@ func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 // @Tags Products
 // @Accept json
 // @Produce json
-// @Param page query int false "Page number"
-// @Param limit query int false "Number of items per page"
+// @Param page query int false "Page number, from 1" default(1)
+// @Param limit query int false "Number of items per page, 1 to 100" default(10)
 // @Param sort query string false "Sort by"
 // @Param sort_direction query string false "Sort direction"
+// @Param name query string false "Name contains (case insensitive)"
+// @Param currency query string false "Price currency (ISO 4217), required with min_price or max_price"
+// @Param min_price query number false "Minimum price, in currency"
+// @Param max_price query number false "Maximum price, in currency"
+// @Param created_after query string false "Created at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param created_before query string false "Created before (RFC 3339 or YYYY-MM-DD)"
+// @Param q query string false "Full-text search on the name"
+// @Param category query string false "Category ID"
+// @Param pagination query string false "Set to cursor to start a keyset pagination"
+// @Param cursor query string false "next_cursor or prev_cursor of the previous response"
+// @Param include_total query bool false "Set to false to skip the total count"
 // @Success 200 {object} dto.ProductOutput
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Security ApiKeyAuth
 // @Router /products [get]
 func (h *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
-	page := r.URL.Query().Get("page")
-	pageInt, err := strconv.Atoi(page)
+	params, ok := ReadPageParams(w, r, map[string]string{
+		"id":    "prd_id",
+		"name":  "prd_name",
+		"price": "prd_price_amount",
+	}, "prd_id")
+	if !ok {
+		return
+	}
+	filter, errs := readProductFilter(r)
+	if len(errs) > 0 {
+		ReturnHttpErrors(w, errs, http.StatusBadRequest)
+		return
+	}
+	if r.URL.Query().Has("cursor") || r.URL.Query().Get("pagination") == "cursor" {
+		h.getProductsByCursor(w, r, params, filter)
+		return
+	}
+	products, err := h.productDB.FindAll(r.Context(), filter, params.Page, params.Limit, params.OrderBy)
 	if err != nil {
-		pageInt = 1
+		ReturnError(w, r, err, "failed to list products")
+		return
 	}
-	limit := r.URL.Query().Get("limit")
-	limitInt, err := strconv.Atoi(limit)
+	result := entityPkg.NewPageWithoutTotal(newProductOutputs(products), params.Page, params.Limit, params.Sort, params.SortDir)
+	if params.IncludeTotal {
+		count, err := h.productDB.Count(r.Context(), filter)
+		if err != nil {
+			ReturnError(w, r, err, "failed to list products")
+			return
+		}
+		result.Meta.SetTotal(int(count))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+// getProductsByCursor is the keyset mode of GetProducts. It only sorts by id,
+// the UUIDv7 ids follow the creation order.
+func (h *ProductHandler) getProductsByCursor(w http.ResponseWriter, r *http.Request, params PageParams, filter database.ProductFilter) {
+	if params.Sort != "" && params.Sort != "id" {
+		ReturnHttpError(w, &FieldError{Field: "sort", Message: "must be id with cursor pagination"}, http.StatusBadRequest)
+		return
+	}
+	desc := params.SortDir == "desc"
+	var cursor *entityPkg.Cursor
+	if token := r.URL.Query().Get("cursor"); token != "" {
+		decoded, err := entityPkg.DecodeCursor(token)
+		if err != nil {
+			ReturnHttpError(w, &FieldError{Field: "cursor", Message: "is invalid"}, http.StatusBadRequest)
+			return
+		}
+		cursor = &decoded
+		// The cursor keeps the direction it was issued for
+		desc = cursor.Desc
+	}
+	products, more, err := h.productDB.FindByCursor(r.Context(), filter, cursor, desc, params.Limit)
 	if err != nil {
-		limitInt = 10
-	}
-	sort := r.URL.Query().Get("sort")
-	sortDir := r.URL.Query().Get("sort_direction")
-	sorts := make(map[string]string)
-	sorts["id"] = "prd_id"
-	sorts["name"] = "prd_name"
-	sorts["price"] = "prd_price"
-	sortComplete := sorts[sort]
-	if sortComplete == "" {
-		sortComplete = "prd_id"
-	}
-	if sortDir == "desc" {
-		sortComplete = sortComplete + " desc"
-	} else {
-		sortDir = "asc"
-		sortComplete = sortComplete + " " + sortDir
-	}
-	products, err := h.productDB.FindAll(pageInt, limitInt, sortComplete)
+		ReturnError(w, r, err, "failed to list products")
+		return
+	}
+
+	var next, prev string
+	if len(products) > 0 {
+		backward := cursor != nil && cursor.Backward
+		if more || backward {
+			next = entityPkg.Cursor{ID: products[len(products)-1].ID, Desc: desc}.Encode()
+		}
+		if (more && backward) || (cursor != nil && !backward) {
+			prev = entityPkg.Cursor{ID: products[0].ID, Backward: true, Desc: desc}.Encode()
+		}
+	}
+	sortDir := "asc"
+	if desc {
+		sortDir = "desc"
+	}
+	result := entityPkg.NewCursorPage(newProductOutputs(products), params.Limit, next, prev, "id", sortDir)
+	if params.IncludeTotal {
+		count, err := h.productDB.Count(r.Context(), filter)
+		if err != nil {
+			ReturnError(w, r, err, "failed to list products")
+			return
+		}
+		result.Meta.SetTotal(int(count))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+// Get Trash Godoc
+// @Summary List deleted products
+// @Description List the soft deleted products, they can be restored until the purge job removes them for good
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number, from 1" default(1)
+// @Param limit query int false "Number of items per page, 1 to 100" default(10)
+// @Param sort query string false "Sort by"
+// @Param sort_direction query string false "Sort direction"
+// @Param include_total query bool false "Set to false to skip the total count"
+// @Success 200 {object} dto.DeletedProductOutput
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /products/trash [get]
+func (h *ProductHandler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	params, ok := ReadPageParams(w, r, map[string]string{
+		"id":         "prd_id",
+		"name":       "prd_name",
+		"price":      "prd_price_amount",
+		"deleted_at": "deleted_at",
+	}, "deleted_at")
+	if !ok {
+		return
+	}
+	products, err := h.productDB.FindDeleted(r.Context(), params.Page, params.Limit, params.OrderBy)
 	if err != nil {
-		log.Error(err.Error())
-		ReturnHttpError(w, err, http.StatusInternalServerError)
+		ReturnError(w, r, err, "failed to list deleted products")
 		return
 	}
-	dtos := []dto.ProductOutput{}
+	outputs := make([]dto.DeletedProductOutput, 0, len(products))
 	for _, product := range products {
-		dtos = append(dtos, dto.ProductOutput{
-			ID:    product.ID.String(),
-			Name:  product.Name,
-			Price: product.Price,
+		outputs = append(outputs, dto.DeletedProductOutput{
+			ID:        product.ID.String(),
+			Name:      product.Name,
+			Price:     product.Price,
+			DeletedAt: product.DeletedAt.Time,
 		})
 	}
-	count, err := h.productDB.Count()
+	result := entityPkg.NewPageWithoutTotal(outputs, params.Page, params.Limit, params.Sort, params.SortDir)
+	if params.IncludeTotal {
+		count, err := h.productDB.CountDeleted(r.Context())
+		if err != nil {
+			ReturnError(w, r, err, "failed to list deleted products")
+			return
+		}
+		result.Meta.SetTotal(int(count))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+// Restore Product Godoc
+// @Summary Restore a deleted product
+// @Description Take a product out of the trash
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} dto.ProductOutput
+// @Header 200 {string} ETag "Version of the product"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /products/{id}/restore [post]
+func (h *ProductHandler) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := h.productDB.Restore(r.Context(), id); err != nil {
+		ReturnError(w, r, err, "failed to restore product")
+		return
+	}
+	product, err := h.productDB.FindByID(r.Context(), id)
 	if err != nil {
-		log.Error(err.Error())
-		ReturnHttpError(w, err, http.StatusInternalServerError)
+		ReturnError(w, r, err, "failed to load product")
 		return
 	}
-	result := entityPkg.NewPage(dtos, pageInt, limitInt, int(count), sort, sortDir)
+	productOutput := h.newProductOutput(product)
+	setETag(w, product.Version.Int64)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(productOutput)
+}
+
+// Product History Godoc
+// @Summary Get the history of a product
+// @Description List who created, updated, deleted or restored the product, set its categories or added an image, with the values before and after each change
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param page query int false "Page number, from 1" default(1)
+// @Param limit query int false "Number of items per page, 1 to 100" default(10)
+// @Param sort_direction query string false "Sort direction"
+// @Param include_total query bool false "Set to false to skip the total count"
+// @Success 200 {object} dto.ProductAuditOutput
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /products/{id}/history [get]
+func (h *ProductHandler) GetProductHistory(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	// Audit IDs are UUIDv7, their order is the order of the changes
+	params, ok := ReadPageParams(w, r, map[string]string{}, "aud_id")
+	if !ok {
+		return
+	}
+	audits, err := h.productDB.History(r.Context(), id, params.Page, params.Limit, params.OrderBy)
+	if err != nil {
+		ReturnError(w, r, err, "failed to load product history")
+		return
+	}
+	outputs := make([]dto.ProductAuditOutput, 0, len(audits))
+	for _, audit := range audits {
+		outputs = append(outputs, dto.ProductAuditOutput{
+			ID:        audit.ID.String(),
+			Action:    audit.Action,
+			UserID:    audit.UserID,
+			Before:    newProductStateOutput(audit.Before),
+			After:     newProductStateOutput(audit.After),
+			CreatedAt: audit.CreatedAt,
+		})
+	}
+	result := entityPkg.NewPageWithoutTotal(outputs, params.Page, params.Limit, params.Sort, params.SortDir)
+	if params.IncludeTotal {
+		count, err := h.productDB.CountHistory(r.Context(), id)
+		if err != nil {
+			ReturnError(w, r, err, "failed to load product history")
+			return
+		}
+		result.Meta.SetTotal(int(count))
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(result)
 }
+
+// Set Product Categories Godoc
+// @Summary Replace the categories of a product
+// @Description Tag the product with exactly these categories, an empty list removes them all. It doesn't change the version of the product
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param categories body dto.ProductCategoriesInput true "IDs of the categories"
+// @Success 200 {object} dto.ProductOutput
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /products/{id}/categories [put]
+func (h *ProductHandler) SetProductCategories(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var input dto.ProductCategoriesInput
+	if !DecodeJSON(w, r, &input) {
+		return
+	}
+	if err := h.productDB.SetCategories(r.Context(), id, input.CategoryIDs); err != nil {
+		ReturnError(w, r, err, "failed to update product categories")
+		return
+	}
+	product, err := h.productDB.FindByID(r.Context(), id)
+	if err != nil {
+		ReturnError(w, r, err, "failed to load product")
+		return
+	}
+	productOutput := h.newProductOutput(product)
+	setETag(w, product.Version.Int64)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(productOutput)
+}
+
+// newProductOutput is the output of a single product, with its categories
+// and the URLs of its images.
+func (h *ProductHandler) newProductOutput(product *entity.Product) dto.ProductOutput {
+	output := dto.ProductOutput{
+		ID:         product.ID.String(),
+		Name:       product.Name,
+		Price:      product.Price,
+		Categories: newCategoryOutputs(product.Categories),
+	}
+	for _, image := range product.Images {
+		output.Images = append(output.Images, h.newProductImageOutput(&image))
+	}
+	return output
+}
+
+func newProductStateOutput(state *entity.ProductState) *dto.ProductStateOutput {
+	if state == nil {
+		return nil
+	}
+	return &dto.ProductStateOutput{
+		Name:       state.Name,
+		Price:      state.Price,
+		Version:    state.Version,
+		Categories: state.Categories,
+		Images:     state.Images,
+	}
+}
+
+func newProductOutputs(products []entity.Product) []dto.ProductOutput {
+	dtos := []dto.ProductOutput{}
+	for _, product := range products {
+		dtos = append(dtos, dto.ProductOutput{
+			ID:    product.ID.String(),
+			Name:  product.Name,
+			Price: product.Price,
+		})
+	}
+	return dtos
+}
+
+// readProductFilter reads the filters of GetProducts from the query string.
+// Every malformed parameter is reported, not only the first one.
+func readProductFilter(r *http.Request) (database.ProductFilter, []error) {
+	query := r.URL.Query()
+	filter := database.ProductFilter{
+		Name:     strings.TrimSpace(query.Get("name")),
+		Query:    strings.TrimSpace(query.Get("q")),
+		Currency: strings.ToUpper(query.Get("currency")),
+	}
+	var errs []error
+	// The amounts are compared in minor units, only within one currency
+	currency := filter.Currency
+	if currency != "" {
+		if err := entityPkg.NewMoney(0, currency).Validate(); err != nil {
+			errs = append(errs, &FieldError{Field: "currency", Message: "must be a 3 letter ISO 4217 code"})
+			currency = ""
+		}
+	} else if query.Get("min_price") != "" || query.Get("max_price") != "" {
+		errs = append(errs, &FieldError{Field: "currency", Message: "is required with min_price or max_price"})
+	}
+	prices := []struct {
+		param string
+		dst   **int64
+	}{
+		{"min_price", &filter.MinPrice},
+		{"max_price", &filter.MaxPrice},
+	}
+	for _, p := range prices {
+		value := query.Get(p.param)
+		if value == "" {
+			continue
+		}
+		price, err := entityPkg.ParseMoney(value, currency)
+		if err != nil {
+			errs = append(errs, &FieldError{Field: p.param, Message: moneyMessage})
+			continue
+		}
+		*p.dst = &price.Amount
+	}
+	dates := []struct {
+		param string
+		dst   **time.Time
+	}{
+		{"created_after", &filter.CreatedAfter},
+		{"created_before", &filter.CreatedBefore},
+	}
+	for _, d := range dates {
+		value := query.Get(d.param)
+		if value == "" {
+			continue
+		}
+		date, err := parseDate(value)
+		if err != nil {
+			errs = append(errs, &FieldError{Field: d.param, Message: "must be a RFC 3339 date or YYYY-MM-DD"})
+			continue
+		}
+		*d.dst = &date
+	}
+	if value := query.Get("category"); value != "" {
+		category, err := entityPkg.ParseID(value)
+		if err != nil {
+			errs = append(errs, &FieldError{Field: "category", Message: "must be a valid id"})
+		} else {
+			filter.Category = category
+		}
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		errs = append(errs, &FieldError{Field: "min_price", Message: "must not be greater than max_price"})
+	}
+	return filter, errs
+}
+
+func parseDate(value string) (time.Time, error) {
+	if date, err := time.Parse(time.DateOnly, value); err == nil {
+		return date, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,423 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/dto"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/database"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/database/dbtest"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/storage"
+	entityPkg "github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testImageMaxSize and testImportMaxSize keep the uploads of the tests small.
+const (
+	testImageMaxSize  = 1024
+	testImportMaxSize = 4096
+	testImportTimeout = 5 * time.Second
+)
+
+func setupProductHandler(t *testing.T) (*ProductHandler, *database.Product) {
+	productDB := database.NewProductDB(dbtest.Open(t, dbtest.ProductTables...), 0)
+	return newTestProductHandler(t, productDB), productDB
+}
+
+// newTestProductHandler keeps the images in a temporary directory.
+func newTestProductHandler(t *testing.T, productDB database.ProductInterface) *ProductHandler {
+	blobStore, err := storage.NewLocalStore(t.TempDir(), "")
+	require.NoError(t, err)
+	return NewProductHandler(productDB, blobStore, testImageMaxSize, testImportMaxSize, testImportTimeout)
+}
+
+func getProducts(t *testing.T, handler *ProductHandler, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/products?"+query, nil)
+	rec := httptest.NewRecorder()
+	handler.GetProducts(rec, req)
+	return rec
+}
+
+func TestProductHandler_GetProductsFiltered(t *testing.T) {
+	handler, productDB := setupProductHandler(t)
+
+	for _, p := range []struct {
+		name  string
+		price int64
+	}{
+		{"Gaming Laptop", 250000},
+		{"Office Laptop", 90000},
+		{"Gaming Mouse", 8000},
+	} {
+		product, err := entity.NewProduct(p.name, entityPkg.NewMoney(p.price, "BRL"))
+		require.NoError(t, err)
+		require.NoError(t, productDB.Create(context.Background(), product))
+	}
+
+	rec := getProducts(t, handler, "q=laptop&currency=brl&max_price=1000&limit=1")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var page entityPkg.Page[dto.ProductOutput]
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&page))
+	require.Len(t, page.Data, 1)
+	assert.Equal(t, "Office Laptop", page.Data[0].Name)
+	require.NotNil(t, page.Meta.TotalItems)
+	assert.Equal(t, 1, *page.Meta.TotalItems, "Totals must match the filtered set")
+}
+
+func TestProductHandler_GetProductsInvalidFilters(t *testing.T) {
+	handler, _ := setupProductHandler(t)
+
+	rec := getProducts(t, handler, "currency=BRL&min_price=abc&created_after=yesterday")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var response dto.ErrorResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.Equal(t, map[string]string{
+		"min_price":     moneyMessage,
+		"created_after": "must be a RFC 3339 date or YYYY-MM-DD",
+	}, response.Fields)
+
+	rec = getProducts(t, handler, "currency=BRL&min_price=10&max_price=5")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = getProducts(t, handler, "max_price=5")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	response = dto.ErrorResponse{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.Equal(t, map[string]string{"currency": "is required with min_price or max_price"}, response.Fields,
+		"Amounts of different currencies cannot be compared")
+
+	rec = getProducts(t, handler, "currency=reais")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestProductHandler_GetProductsByCursor(t *testing.T) {
+	handler, productDB := setupProductHandler(t)
+
+	for _, name := range []string{"A", "B", "C", "D", "E"} {
+		product, err := entity.NewProduct(name, entityPkg.NewMoney(1000, "BRL"))
+		require.NoError(t, err)
+		require.NoError(t, productDB.Create(context.Background(), product))
+	}
+
+	readPage := func(query string) entityPkg.Page[dto.ProductOutput] {
+		rec := getProducts(t, handler, query)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var page entityPkg.Page[dto.ProductOutput]
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&page))
+		return page
+	}
+	names := func(page entityPkg.Page[dto.ProductOutput]) []string {
+		result := []string{}
+		for _, product := range page.Data {
+			result = append(result, product.Name)
+		}
+		return result
+	}
+
+	first := readPage("pagination=cursor&limit=2&include_total=false")
+	assert.Equal(t, []string{"A", "B"}, names(first))
+	assert.Empty(t, first.Meta.PrevCursor)
+	assert.NotEmpty(t, first.Meta.NextCursor)
+	assert.Nil(t, first.Meta.TotalItems, "The count was skipped")
+
+	second := readPage("limit=2&cursor=" + first.Meta.NextCursor)
+	assert.Equal(t, []string{"C", "D"}, names(second))
+	assert.NotEmpty(t, second.Meta.PrevCursor)
+
+	last := readPage("limit=2&cursor=" + second.Meta.NextCursor)
+	assert.Equal(t, []string{"E"}, names(last))
+	assert.Empty(t, last.Meta.NextCursor)
+
+	back := readPage("limit=2&cursor=" + last.Meta.PrevCursor)
+	assert.Equal(t, []string{"C", "D"}, names(back))
+
+	back = readPage("limit=2&cursor=" + back.Meta.PrevCursor)
+	assert.Equal(t, []string{"A", "B"}, names(back))
+	assert.Empty(t, back.Meta.PrevCursor)
+	assert.NotEmpty(t, back.Meta.NextCursor)
+
+	desc := readPage("pagination=cursor&limit=3&sort_direction=desc")
+	assert.Equal(t, []string{"E", "D", "C"}, names(desc))
+	require.NotNil(t, desc.Meta.TotalItems)
+	assert.Equal(t, 5, *desc.Meta.TotalItems)
+	assert.Equal(t, []string{"B", "A"}, names(readPage("limit=3&cursor="+desc.Meta.NextCursor)))
+}
+
+func TestProductHandler_GetProductsByCursorInvalid(t *testing.T) {
+	handler, _ := setupProductHandler(t)
+
+	assert.Equal(t, http.StatusBadRequest, getProducts(t, handler, "cursor=not-a-cursor").Code)
+	assert.Equal(t, http.StatusBadRequest, getProducts(t, handler, "pagination=cursor&sort=name").Code)
+}
+
+func TestProductHandler_GetProductsInvalidPage(t *testing.T) {
+	handler, _ := setupProductHandler(t)
+
+	for _, query := range []string{
+		"pagination=cursor&limit=-1",
+		"pagination=cursor&limit=0",
+		"limit=0",
+		"limit=101",
+		"limit=ten",
+		"page=0",
+		"page=-3",
+	} {
+		rec := getProducts(t, handler, query)
+		require.Equal(t, http.StatusBadRequest, rec.Code, query)
+		var response dto.ErrorResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+		assert.Len(t, response.Fields, 1, query)
+	}
+	assert.Equal(t, http.StatusOK, getProducts(t, handler, "page=1&limit=100").Code)
+}
+
+func productRequest(method, id, body, ifMatch string) *http.Request {
+	req := httptest.NewRequest(method, "/products/"+id, strings.NewReader(body))
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestProductHandler_ETag(t *testing.T) {
+	handler, productDB := setupProductHandler(t)
+
+	product, err := entity.NewProduct("Keyboard", entityPkg.NewMoney(10000, "BRL"))
+	require.NoError(t, err)
+	require.NoError(t, productDB.Create(context.Background(), product))
+	id := product.ID.String()
+
+	rec := httptest.NewRecorder()
+	handler.GetProduct(rec, productRequest(http.MethodGet, id, "", ""))
+	require.Equal(t, http.StatusOK, rec.Code)
+	etag := rec.Header().Get("ETag")
+	assert.Equal(t, `"1"`, etag)
+
+	body := `{"name":"Keyboard","price":120}`
+
+	rec = httptest.NewRecorder()
+	handler.UpdateProduct(rec, productRequest(http.MethodPut, id, body, ""))
+	assert.Equal(t, http.StatusPreconditionRequired, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.UpdateProduct(rec, productRequest(http.MethodPut, id, body, etag))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+
+	// The old tag is stale now
+	rec = httptest.NewRecorder()
+	handler.UpdateProduct(rec, productRequest(http.MethodPut, id, body, etag))
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.DeleteProduct(rec, productRequest(http.MethodDelete, id, "", etag))
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.DeleteProduct(rec, productRequest(http.MethodDelete, id, "", `W/"2"`))
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code, "Weak tags must not match")
+
+	rec = httptest.NewRecorder()
+	handler.DeleteProduct(rec, productRequest(http.MethodDelete, id, "", `"1", "2"`))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
+
+func TestProductHandler_PatchProduct(t *testing.T) {
+	handler, productDB := setupProductHandler(t)
+
+	product, err := entity.NewProduct("Keyboard", entityPkg.NewMoney(10000, "BRL"))
+	require.NoError(t, err)
+	require.NoError(t, productDB.Create(context.Background(), product))
+	id := product.ID.String()
+
+	patch := func(body, contentType, ifMatch string) *httptest.ResponseRecorder {
+		req := productRequest(http.MethodPatch, id, body, ifMatch)
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		handler.PatchProduct(rec, req)
+		return rec
+	}
+
+	rec := patch(`{"price":120}`, "application/json", `"1"`)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+
+	rec = patch(`{"price":120}`, "application/merge-patch+json", "")
+	assert.Equal(t, http.StatusPreconditionRequired, rec.Code)
+
+	rec = patch(`{"price":120}`, "application/merge-patch+json", `"1"`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+	var output dto.ProductOutput
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&output))
+	assert.Equal(t, "Keyboard", output.Name)
+	assert.Equal(t, entityPkg.NewMoney(12000, "BRL"), output.Price)
+
+	found, err := productDB.FindByID(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, "Keyboard", found.Name)
+	assert.Equal(t, entityPkg.NewMoney(12000, "BRL"), found.Price)
+
+	// Removing a required member breaks the merged product
+	rec = patch(`{"name":null}`, "application/merge-patch+json", `"2"`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = patch(`{"discount":10}`, "application/merge-patch+json", `"2"`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	var response dto.ErrorResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.Equal(t, map[string]string{"discount": "is not allowed"}, response.Fields)
+
+	rec = patch(`{"name":"`+strings.Repeat("a", maxJSONBody)+`"}`, "application/merge-patch+json", `"2"`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	// Nothing changes, nothing is written
+	rec = patch(`{"price":120}`, "application/merge-patch+json", `"2"`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+}
+
+func TestProductHandler_TrashAndRestore(t *testing.T) {
+	handler, productDB := setupProductHandler(t)
+
+	product, err := entity.NewProduct("Keyboard", entityPkg.NewMoney(10000, "BRL"))
+	require.NoError(t, err)
+	require.NoError(t, productDB.Create(context.Background(), product))
+	id := product.ID.String()
+
+	rec := httptest.NewRecorder()
+	handler.DeleteProduct(rec, productRequest(http.MethodDelete, id, "", `"1"`))
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.GetTrash(rec, httptest.NewRequest(http.MethodGet, "/products/trash", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var page entityPkg.Page[dto.DeletedProductOutput]
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&page))
+	require.Len(t, page.Data, 1)
+	assert.Equal(t, id, page.Data[0].ID)
+	assert.False(t, page.Data[0].DeletedAt.IsZero())
+	require.NotNil(t, page.Meta.TotalItems)
+	assert.Equal(t, 1, *page.Meta.TotalItems)
+
+	rec = httptest.NewRecorder()
+	handler.RestoreProduct(rec, productRequest(http.MethodPost, id, "", ""))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+
+	rec = httptest.NewRecorder()
+	handler.GetProduct(rec, productRequest(http.MethodGet, id, "", ""))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// Already restored
+	rec = httptest.NewRecorder()
+	handler.RestoreProduct(rec, productRequest(http.MethodPost, id, "", ""))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestProductHandler_GetProductHistory(t *testing.T) {
+	handler, productDB := setupProductHandler(t)
+	ctx := entity.ContextWithActor(context.Background(), "editor-1")
+
+	product, err := entity.NewProduct("Keyboard", entityPkg.NewMoney(10000, "BRL"))
+	require.NoError(t, err)
+	require.NoError(t, productDB.Create(ctx, product))
+	product.Price = entityPkg.NewMoney(12000, "BRL")
+	require.NoError(t, productDB.Update(ctx, product))
+	id := product.ID.String()
+
+	req := productRequest(http.MethodGet, id, "", "")
+	req.URL.RawQuery = "sort_direction=desc&limit=1"
+	rec := httptest.NewRecorder()
+	handler.GetProductHistory(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var page entityPkg.Page[dto.ProductAuditOutput]
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&page))
+	require.Len(t, page.Data, 1)
+	assert.Equal(t, entity.AuditUpdate, page.Data[0].Action)
+	assert.Equal(t, "editor-1", page.Data[0].UserID)
+	require.NotNil(t, page.Data[0].Before)
+	assert.Equal(t, entityPkg.NewMoney(10000, "BRL"), page.Data[0].Before.Price)
+	assert.Equal(t, entityPkg.NewMoney(12000, "BRL"), page.Data[0].After.Price)
+	require.NotNil(t, page.Meta.TotalItems)
+	assert.Equal(t, 2, *page.Meta.TotalItems)
+
+	rec = httptest.NewRecorder()
+	handler.GetProductHistory(rec, productRequest(http.MethodGet, "invalid", "", ""))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestProductHandler_SetProductCategories(t *testing.T) {
+	db := dbtest.Open(t, dbtest.ProductTables...)
+	productDB := database.NewProductDB(db, 0)
+	categoryDB := database.NewCategoryDB(db, 0)
+	handler := newTestProductHandler(t, productDB)
+
+	product, err := entity.NewProduct("Keyboard", entityPkg.NewMoney(10000, "BRL"))
+	require.NoError(t, err)
+	require.NoError(t, productDB.Create(context.Background(), product))
+	other, err := entity.NewProduct("Monitor", entityPkg.NewMoney(30000, "BRL"))
+	require.NoError(t, err)
+	require.NoError(t, productDB.Create(context.Background(), other))
+	category, err := entity.NewCategory("Peripherals")
+	require.NoError(t, err)
+	require.NoError(t, categoryDB.Create(context.Background(), category))
+	id := product.ID.String()
+
+	setCategories := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.SetProductCategories(rec, productRequest(http.MethodPut, id, body, ""))
+		return rec
+	}
+
+	rec := setCategories(`{"category_ids":["` + category.ID.String() + `"]}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"1"`, rec.Header().Get("ETag"), "Tagging doesn't change the version")
+	var output dto.ProductOutput
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&output))
+	assert.Equal(t, []dto.CategoryOutput{{ID: category.ID.String(), Name: "Peripherals"}}, output.Categories)
+
+	rec = httptest.NewRecorder()
+	handler.GetProduct(rec, productRequest(http.MethodGet, id, "", ""))
+	require.Equal(t, http.StatusOK, rec.Code)
+	output = dto.ProductOutput{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&output))
+	assert.Len(t, output.Categories, 1)
+
+	rec = getProducts(t, handler, "category="+category.ID.String())
+	require.Equal(t, http.StatusOK, rec.Code)
+	var page entityPkg.Page[dto.ProductOutput]
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&page))
+	require.Len(t, page.Data, 1)
+	assert.Equal(t, "Keyboard", page.Data[0].Name)
+
+	rec = getProducts(t, handler, "category=peripherals")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	var response dto.ErrorResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.Equal(t, map[string]string{"category": "must be a valid id"}, response.Fields)
+
+	rec = setCategories(`{"category_ids":["019ab24a-dc97-72a4-9056-cc09f4c13bef"]}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = setCategories(`{}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = setCategories(`{"category_ids":[]}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	output = dto.ProductOutput{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&output))
+	assert.Empty(t, output.Categories)
+}
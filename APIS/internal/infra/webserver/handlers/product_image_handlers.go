@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/dto"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	entityPkg "github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
+	"github.com/jb-oliveira/fullcycle/APIS/pkg/log"
+)
+
+const (
+	multipartContentType = "multipart/form-data"
+	// imageField is the form field of the uploaded image
+	imageField = "image"
+	// multipartOverhead is the room left to the rest of the form by the
+	// body limit of the uploads
+	multipartOverhead = 64 * 1024
+	// multipartMemory is the part of a form kept in memory, the rest of
+	// the file goes to a temporary file
+	multipartMemory = 1 << 20
+	// sniffLen is the number of bytes http.DetectContentType looks at
+	sniffLen = 512
+)
+
+// Upload Product Image Godoc
+// @Summary Upload an image of a product
+// @Description Add a JPEG, PNG, GIF or WebP image to the product, sent in the image field of a multipart form. The type is detected from the content, not from the file name. It doesn't change the version of the product
+// @Tags Products
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param image formData file true "Image file"
+// @Success 201 {object} dto.ProductImageOutput
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 413 {object} dto.ErrorResponse
+// @Failure 415 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /products/{id}/images [post]
+func (h *ProductHandler) UploadProductImage(w http.ResponseWriter, r *http.Request) {
+	productID, err := entityPkg.ParseID(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error(r.Context(), err.Error())
+		ReturnHttpError(w, errors.New("invalid id"), http.StatusBadRequest)
+		return
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != multipartContentType {
+		ReturnHttpError(w, errors.New("Content-Type must be "+multipartContentType), http.StatusUnsupportedMediaType)
+		return
+	}
+	// Checked before the upload so an unknown product stores no file.
+	// AddImage checks again, the product can be deleted meanwhile
+	if _, err := h.productDB.FindByID(r.Context(), productID.String()); err != nil {
+		ReturnError(w, r, err, "failed to load product")
+		return
+	}
+
+	tooLarge := errors.New("image cannot exceed " + strconv.FormatInt(h.maxImageSize, 10) + " bytes")
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxImageSize+multipartOverhead)
+	if err := r.ParseMultipartForm(multipartMemory); err != nil {
+		log.Error(r.Context(), err.Error())
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			ReturnHttpError(w, tooLarge, http.StatusRequestEntityTooLarge)
+			return
+		}
+		ReturnHttpError(w, errors.New("Invalid request body"), http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+	file, header, err := r.FormFile(imageField)
+	if err != nil {
+		ReturnHttpError(w, &FieldError{Field: imageField, Message: "is required"}, http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	if header.Size > h.maxImageSize {
+		ReturnHttpError(w, tooLarge, http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// The type the client claims is not trusted, the content decides
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		ReturnError(w, r, err, "failed to read the image")
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		ReturnError(w, r, err, "failed to read the image")
+		return
+	}
+	image, err := entity.NewProductImage(productID, http.DetectContentType(head[:n]), header.Size)
+	if errors.Is(err, entity.ErrInvalidImageType) {
+		ReturnHttpError(w, err, http.StatusUnsupportedMediaType)
+		return
+	}
+	if err != nil {
+		ReturnError(w, r, err, "invalid image")
+		return
+	}
+
+	if err := h.blobStore.Put(r.Context(), image.Key, file, image.Size, image.ContentType); err != nil {
+		ReturnError(w, r, err, "failed to store the image")
+		return
+	}
+	if err := h.productDB.AddImage(r.Context(), image); err != nil {
+		// Without its metadata the file would never be referenced
+		if deleteErr := h.blobStore.Delete(r.Context(), image.Key); deleteErr != nil {
+			log.Error(r.Context(), deleteErr.Error())
+		}
+		ReturnError(w, r, err, "failed to save the image")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(h.newProductImageOutput(image))
+}
+
+func (h *ProductHandler) newProductImageOutput(image *entity.ProductImage) dto.ProductImageOutput {
+	return dto.ProductImageOutput{
+		ID:          image.ID.String(),
+		URL:         h.blobStore.URL(image.Key),
+		ContentType: image.ContentType,
+		Size:        image.Size,
+		CreatedAt:   image.CreatedAt,
+	}
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jb-oliveira/fullcycle/APIS/internal/dto"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/database"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/database/dbtest"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/storage"
+	entityPkg "github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pngHeader is enough for http.DetectContentType to see a PNG.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// uploadImage posts content as the field of a multipart form.
+func uploadImage(t *testing.T, handler *ProductHandler, id, field string, content []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile(field, "photo.jpg")
+	require.NoError(t, err)
+	part.Write(content)
+	require.NoError(t, writer.Close())
+
+	req := productRequest(http.MethodPost, id, body.String(), "")
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rec := httptest.NewRecorder()
+	handler.UploadProductImage(rec, req)
+	return rec
+}
+
+func TestProductHandler_UploadProductImage(t *testing.T) {
+	handler, productDB := setupProductHandler(t)
+
+	product, err := entity.NewProduct("Keyboard", entityPkg.NewMoney(10000, "BRL"))
+	require.NoError(t, err)
+	require.NoError(t, productDB.Create(context.Background(), product))
+	id := product.ID.String()
+
+	rec := uploadImage(t, handler, id, imageField, pngHeader)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var image dto.ProductImageOutput
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&image))
+	assert.Equal(t, "image/png", image.ContentType, "The type comes from the content, not the file name")
+	assert.Equal(t, int64(len(pngHeader)), image.Size)
+	assert.Equal(t, "/media/products/"+id+"/"+image.ID+".png", image.URL)
+
+	rec = httptest.NewRecorder()
+	handler.GetProduct(rec, productRequest(http.MethodGet, id, "", ""))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var output dto.ProductOutput
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&output))
+	require.Len(t, output.Images, 1)
+	assert.Equal(t, image.URL, output.Images[0].URL)
+	assert.Equal(t, `"1"`, rec.Header().Get("ETag"), "An upload doesn't bump the version")
+}
+
+func TestProductHandler_UploadProductImageErrors(t *testing.T) {
+	handler, productDB := setupProductHandler(t)
+
+	product, err := entity.NewProduct("Keyboard", entityPkg.NewMoney(10000, "BRL"))
+	require.NoError(t, err)
+	require.NoError(t, productDB.Create(context.Background(), product))
+	id := product.ID.String()
+
+	rec := uploadImage(t, handler, id, imageField, []byte("%PDF-1.7"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code, "Only images are accepted")
+
+	rec = uploadImage(t, handler, id, imageField, append(pngHeader, make([]byte, testImageMaxSize)...))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	rec = uploadImage(t, handler, id, "file", pngHeader)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	var response dto.ErrorResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.Equal(t, map[string]string{"image": "is required"}, response.Fields)
+
+	rec = uploadImage(t, handler, "019ab24a-dc97-72a4-9056-cc09f4c13bef", imageField, pngHeader)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	rec = uploadImage(t, handler, "invalid", imageField, pngHeader)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	req := productRequest(http.MethodPost, id, `{"image":"data"}`, "")
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	handler.UploadProductImage(rec, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+
+	found, err := productDB.FindByID(context.Background(), id)
+	require.NoError(t, err)
+	assert.Empty(t, found.Images, "No failed upload must be saved")
+}
+
+// countingStore records the keys put in and deleted from a BlobStore.
+type countingStore struct {
+	storage.BlobStore
+	put, deleted []string
+}
+
+func (s *countingStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	s.put = append(s.put, key)
+	return s.BlobStore.Put(ctx, key, body, size, contentType)
+}
+
+func (s *countingStore) Delete(ctx context.Context, key string) error {
+	s.deleted = append(s.deleted, key)
+	return s.BlobStore.Delete(ctx, key)
+}
+
+// failingImages is a product repository that can't save images.
+type failingImages struct {
+	*database.Product
+}
+
+func (failingImages) AddImage(context.Context, *entity.ProductImage) error {
+	return errors.New("database is down")
+}
+
+func TestProductHandler_UploadProductImageLeavesNoOrphanFile(t *testing.T) {
+	productDB := database.NewProductDB(dbtest.Open(t, dbtest.ProductTables...), 0)
+	localStore, err := storage.NewLocalStore(t.TempDir(), "")
+	require.NoError(t, err)
+	store := &countingStore{BlobStore: localStore}
+	handler := NewProductHandler(productDB, store, testImageMaxSize, testImportMaxSize, testImportTimeout)
+
+	product, err := entity.NewProduct("Keyboard", entityPkg.NewMoney(10000, "BRL"))
+	require.NoError(t, err)
+	require.NoError(t, productDB.Create(context.Background(), product))
+	require.NoError(t, productDB.Delete(context.Background(), product.ID.String(), product.Version.Int64))
+
+	rec := uploadImage(t, handler, "019ab24a-dc97-72a4-9056-cc09f4c13bef", imageField, pngHeader)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	rec = uploadImage(t, handler, product.ID.String(), imageField, pngHeader)
+	assert.Equal(t, http.StatusNotFound, rec.Code, "A deleted product takes no image")
+	assert.Empty(t, store.put, "Nothing must be stored for a missing product")
+
+	require.NoError(t, productDB.Restore(context.Background(), product.ID.String()))
+	handler = NewProductHandler(failingImages{productDB}, store, testImageMaxSize, testImportMaxSize, testImportTimeout)
+	rec = uploadImage(t, handler, product.ID.String(), imageField, pngHeader)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	require.Len(t, store.put, 1)
+	assert.Equal(t, store.put, store.deleted, "The file of a failed upload must be deleted")
+}
@@ -3,34 +3,56 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"io"
+	"math"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/jb-oliveira/fullcycle/APIS/pkg/log"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/jwtauth"
 	"github.com/jb-oliveira/fullcycle/APIS/internal/dto"
 	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
 	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/database"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/throttle"
+	entityPkg "github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
 )
 
+var errInvalidCredentials = errors.New("Invalid credentials")
+
+// dummyUser is checked when the email doesn't exist, so the response time
+// doesn't tell which accounts exist.
+var dummyUser = sync.OnceValue(func() *entity.User {
+	user, _ := entity.NewUser("dummy", "dummy@example.com", "dummy-password")
+	return user
+})
+
 type UserHandler struct {
-	userDB        database.UserInterface
-	jwtAuth       *jwtauth.JWTAuth
-	jwtExpiration int
+	userDB            database.UserInterface
+	tokenDB           database.TokenInterface
+	loginThrottle     *throttle.LoginThrottle
+	jwtAuth           *jwtauth.JWTAuth
+	jwtExpiration     int
+	refreshExpiration int
 }
 
-func NewUserHandler(userDB database.UserInterface, jwtAuth *jwtauth.JWTAuth, expiration int) *UserHandler {
+func NewUserHandler(userDB database.UserInterface, tokenDB database.TokenInterface, loginThrottle *throttle.LoginThrottle, jwtAuth *jwtauth.JWTAuth, expiration, refreshExpiration int) *UserHandler {
 	return &UserHandler{
-		userDB:        userDB,
-		jwtAuth:       jwtAuth,
-		jwtExpiration: expiration,
+		userDB:            userDB,
+		tokenDB:           tokenDB,
+		loginThrottle:     loginThrottle,
+		jwtAuth:           jwtAuth,
+		jwtExpiration:     expiration,
+		refreshExpiration: refreshExpiration,
 	}
 }
 
 // Auth godoc
 // @Summary      Authenticate user
-// @Description  Authenticate user with email and password and return JWT token
+// @Description  Authenticate user with email and password and return a JWT access token and a refresh token
 // @Tags         users
 // @Accept       json
 // @Produce      json
@@ -38,49 +60,212 @@ func NewUserHandler(userDB database.UserInterface, jwtAuth *jwtauth.JWTAuth, exp
 // @Success      200 {object} dto.AuthResponse "Authentication successful"
 // @Failure      400 {object} dto.ErrorResponse
 // @Failure      401 {object} dto.ErrorResponse
-// @Failure      404 {object} dto.ErrorResponse
+// @Failure      429 {object} dto.ErrorResponse "Too many failed attempts, see Retry-After"
 // @Failure      500 {object} dto.ErrorResponse
 // @Router       /users/auth [post]
 func (h *UserHandler) Auth(w http.ResponseWriter, r *http.Request) {
 	userLogin := &dto.LoginInput{}
-	err := json.NewDecoder(r.Body).Decode(userLogin)
-	if err != nil {
-		log.Error(err.Error())
-		ReturnHttpError(w, errors.New("Invalid request body"), http.StatusBadRequest)
+	if !DecodeJSON(w, r, userLogin) {
+		return
+	}
+
+	ip := ClientIP(r)
+	if wait := h.loginThrottle.Locked(userLogin.Email, ip); wait > 0 {
+		returnTooManyAttempts(w, wait)
 		return
 	}
 
-	user, err := h.userDB.FindByEmail(userLogin.Email)
+	user, err := h.userDB.FindByEmail(r.Context(), userLogin.Email)
+	if err != nil && !errors.Is(err, entity.ErrNotFound) {
+		ReturnError(w, r, err, "Failed to authenticate")
+		return
+	}
 	if err != nil {
-		log.Error(err.Error())
-		ReturnHttpError(w, errors.New("User not found"), http.StatusNotFound)
+		log.Error(r.Context(), err.Error())
+		dummyUser().ValidatePassword(userLogin.Password)
+		h.loginThrottle.Fail(userLogin.Email, ip)
+		ReturnHttpError(w, errInvalidCredentials, http.StatusUnauthorized)
 		return
 	}
 
 	if !user.ValidatePassword(userLogin.Password) {
-		log.Error("Invalid credentials")
-		ReturnHttpError(w, errors.New("Invalid credentials"), http.StatusUnauthorized)
+		log.Error(r.Context(), "Invalid credentials")
+		h.loginThrottle.Fail(userLogin.Email, ip)
+		ReturnHttpError(w, errInvalidCredentials, http.StatusUnauthorized)
+		return
+	}
+	h.loginThrottle.Succeed(userLogin.Email, ip)
+
+	refreshToken, plainRefresh, err := entity.NewRefreshToken(user.ID, time.Second*time.Duration(h.refreshExpiration))
+	if err != nil {
+		log.Error(r.Context(), err.Error())
+		ReturnHttpError(w, errors.New("Failed to generate token"), http.StatusInternalServerError)
+		return
+	}
+	err = h.tokenDB.CreateRefreshToken(r.Context(), refreshToken)
+	if err != nil {
+		log.Error(r.Context(), err.Error())
+		ReturnHttpError(w, errors.New("Failed to generate token"), http.StatusInternalServerError)
+		return
+	}
+
+	h.writeTokens(w, r, user, plainRefresh)
+}
+
+// Refresh godoc
+// @Summary      Refresh access token
+// @Description  Exchange a refresh token for a new access token. The refresh token is rotated, the old one can't be used again
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.RefreshTokenInput true "Refresh token"
+// @Success      200 {object} dto.AuthResponse "Token refreshed"
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /users/auth/refresh [post]
+func (h *UserHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	input := dto.RefreshTokenInput{}
+	if !DecodeJSON(w, r, &input) {
 		return
 	}
 
-	accessToken := dto.AuthResponse{}
+	current, err := h.tokenDB.FindRefreshTokenByHash(r.Context(), entity.HashToken(input.RefreshToken))
+	if err != nil && !errors.Is(err, entity.ErrNotFound) {
+		ReturnError(w, r, err, "Failed to generate token")
+		return
+	}
+	if err != nil {
+		log.Error(r.Context(), err.Error())
+		ReturnHttpError(w, entity.ErrTokenInvalid, http.StatusUnauthorized)
+		return
+	}
+	if current.IsRevoked() {
+		// A rotated token being presented again means it leaked,
+		// so every session of the user is terminated.
+		log.Warn(r.Context(), "refresh token reuse detected for user "+current.UserID.String())
+		if err := h.tokenDB.RevokeUserRefreshTokens(r.Context(), current.UserID.String()); err != nil {
+			log.Error(r.Context(), err.Error())
+		}
+		ReturnHttpError(w, entity.ErrTokenRevoked, http.StatusUnauthorized)
+		return
+	}
+	if current.IsExpired(time.Now()) {
+		ReturnHttpError(w, entity.ErrTokenExpired, http.StatusUnauthorized)
+		return
+	}
 
+	user, err := h.userDB.FindByID(r.Context(), current.UserID.String())
+	if err != nil {
+		log.Error(r.Context(), err.Error())
+		ReturnHttpError(w, entity.ErrTokenInvalid, http.StatusUnauthorized)
+		return
+	}
+
+	next, plainRefresh, err := entity.NewRefreshToken(user.ID, time.Second*time.Duration(h.refreshExpiration))
+	if err != nil {
+		log.Error(r.Context(), err.Error())
+		ReturnHttpError(w, errors.New("Failed to generate token"), http.StatusInternalServerError)
+		return
+	}
+	err = h.tokenDB.RotateRefreshToken(r.Context(), current, next)
+	if errors.Is(err, entity.ErrTokenRevoked) {
+		ReturnHttpError(w, entity.ErrTokenRevoked, http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		log.Error(r.Context(), err.Error())
+		ReturnHttpError(w, errors.New("Failed to generate token"), http.StatusInternalServerError)
+		return
+	}
+
+	h.writeTokens(w, r, user, plainRefresh)
+}
+
+// Logout godoc
+// @Summary      Logout
+// @Description  Revoke the current access token and the given refresh token. Without a refresh token every session of the user is revoked
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.RefreshTokenInput false "Refresh token to revoke"
+// @Success      204
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Security     ApiKeyAuth
+// @Router       /users/logout [post]
+func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	token, claims, err := jwtauth.FromContext(r.Context())
+	if err != nil || token == nil {
+		ReturnHttpError(w, entity.ErrTokenInvalid, http.StatusUnauthorized)
+		return
+	}
+	userID, _ := claims["sub"].(string)
+
+	input := dto.RefreshTokenInput{}
+	// The body is optional, an empty one revokes every refresh token of the user
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxJSONBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+		log.Error(r.Context(), err.Error())
+		returnBodyError(w, err, decodeError(err))
+		return
+	}
+
+	if token.JwtID() != "" {
+		err = h.tokenDB.RevokeAccessToken(r.Context(), token.JwtID(), token.Expiration())
+		if err != nil {
+			log.Error(r.Context(), err.Error())
+			ReturnHttpError(w, errors.New("Failed to revoke token"), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if input.RefreshToken == "" {
+		err = h.tokenDB.RevokeUserRefreshTokens(r.Context(), userID)
+	} else {
+		var refreshToken *entity.RefreshToken
+		refreshToken, err = h.tokenDB.FindRefreshTokenByHash(r.Context(), entity.HashToken(input.RefreshToken))
+		if err == nil && refreshToken.UserID.String() == userID {
+			err = h.tokenDB.RevokeRefreshToken(r.Context(), refreshToken.ID.String())
+		}
+	}
+	if err != nil {
+		log.Error(r.Context(), err.Error())
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func returnTooManyAttempts(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	ReturnHttpError(w, errors.New("Too many failed login attempts, try again later"), http.StatusTooManyRequests)
+}
+
+// writeTokens signs a new access token for the user and writes it
+// together with the refresh token.
+func (h *UserHandler) writeTokens(w http.ResponseWriter, r *http.Request, user *entity.User, refreshToken string) {
 	_, token, err := h.jwtAuth.Encode(map[string]interface{}{
 		"sub": user.ID.String(),
 		"eml": user.Email,
+		"rol": user.Role,
+		"jti": entityPkg.NewID().String(),
+		"iat": time.Now().Unix(),
 		"exp": time.Now().Add(time.Second * time.Duration(h.jwtExpiration)).Unix(),
 	})
 	if err != nil {
-		log.Error(err.Error())
+		log.Error(r.Context(), err.Error())
 		ReturnHttpError(w, errors.New("Failed to generate token"), http.StatusInternalServerError)
 		return
 	}
 
-	accessToken.Token = token
-
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(accessToken)
+	json.NewEncoder(w).Encode(dto.AuthResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    h.jwtExpiration,
+	})
 }
 
 // Create User godoc
@@ -92,33 +277,302 @@ func (h *UserHandler) Auth(w http.ResponseWriter, r *http.Request) {
 // @Param        request body dto.CreateUserInput true "User creation data"
 // @Success      201 {object} dto.UserOutput "User created successfully"
 // @Failure      400 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse "Email already in use"
 // @Failure      500 {object} dto.ErrorResponse
 // @Router       /users [post]
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	inserInput := dto.CreateUserInput{}
-	err := json.NewDecoder(r.Body).Decode(&inserInput)
-	if err != nil {
-		log.Error(err.Error())
-		ReturnHttpError(w, errors.New("Invalid request body"), http.StatusBadRequest)
+	if !DecodeJSON(w, r, &inserInput) {
 		return
 	}
 	user, err := entity.NewUser(inserInput.Name, inserInput.Email, inserInput.Password)
 	if err != nil {
-		log.Error(err.Error())
-		ReturnHttpError(w, err, http.StatusBadRequest)
+		ReturnError(w, r, err, "Failed to create user")
 		return
 	}
-	err = h.userDB.Create(user)
+	err = h.userDB.Create(r.Context(), user)
 	if err != nil {
-		log.Error(err.Error())
-		ReturnHttpError(w, errors.New("Failed to create user"), http.StatusInternalServerError)
+		ReturnError(w, r, err, "Failed to create user")
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(dto.UserOutput{
+	json.NewEncoder(w).Encode(newUserOutput(user))
+}
+
+// Update User Role godoc
+// @Summary      Change the role of a user
+// @Description  Change the role of a user and revoke its sessions. Only admins can call it
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "User ID"
+// @Param        request body dto.UpdateUserRoleInput true "New role"
+// @Success      200 {object} dto.UserOutput
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Security     ApiKeyAuth
+// @Router       /users/{id}/role [put]
+func (h *UserHandler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	input := dto.UpdateUserRoleInput{}
+	if !DecodeJSON(w, r, &input) {
+		return
+	}
+	user, err := h.userDB.FindByID(r.Context(), id)
+	if err != nil {
+		ReturnError(w, r, err, "Failed to load user")
+		return
+	}
+	if err := user.SetRole(input.Role); err != nil {
+		ReturnError(w, r, err, "Failed to update user")
+		return
+	}
+	err = h.userDB.Update(r.Context(), user)
+	if err != nil {
+		ReturnError(w, r, err, "Failed to update user")
+		return
+	}
+	// The access tokens with the old role are turned away by the Verifier,
+	// the refresh tokens must not sign new ones
+	if err := h.tokenDB.RevokeUserRefreshTokens(r.Context(), user.ID.String()); err != nil {
+		log.Error(r.Context(), err.Error())
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(newUserOutput(user))
+}
+
+// Get Me godoc
+// @Summary      Get the authenticated user
+// @Description  Get the user that owns the access token
+// @Tags         users
+// @Produce      json
+// @Success      200 {object} dto.UserOutput
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Security     ApiKeyAuth
+// @Router       /users/me [get]
+func (h *UserHandler) GetMe(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(newUserOutput(user))
+}
+
+// Update Me godoc
+// @Summary      Update the authenticated user
+// @Description  Update name and email of the user that owns the access token
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.UpdateUserInput true "User data"
+// @Success      200 {object} dto.UserOutput
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse "Email already in use"
+// @Failure      500 {object} dto.ErrorResponse
+// @Security     ApiKeyAuth
+// @Router       /users/me [put]
+func (h *UserHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	input := dto.UpdateUserInput{}
+	if !DecodeJSON(w, r, &input) {
+		return
+	}
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+	user.Name = input.Name
+	user.Email = input.Email
+	if err := user.Validate(); err != nil {
+		ReturnError(w, r, err, "Failed to update user")
+		return
+	}
+	err := h.userDB.Update(r.Context(), user)
+	if err != nil {
+		ReturnError(w, r, err, "Failed to update user")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(newUserOutput(user))
+}
+
+// Change Password godoc
+// @Summary      Change password
+// @Description  Change the password of the authenticated user. The old password is required and every other session is revoked
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.ChangePasswordInput true "Old and new password"
+// @Success      204
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Security     ApiKeyAuth
+// @Router       /users/me/password [put]
+func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	input := dto.ChangePasswordInput{}
+	if !DecodeJSON(w, r, &input) {
+		return
+	}
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+	if !user.ValidatePassword(input.OldPassword) {
+		ReturnHttpError(w, entity.ErrWrongPassword, http.StatusBadRequest)
+		return
+	}
+	if err := user.ChangePassword(input.NewPassword); err != nil {
+		ReturnError(w, r, err, "Failed to update user")
+		return
+	}
+	err := h.userDB.Update(r.Context(), user)
+	if err != nil {
+		ReturnError(w, r, err, "Failed to update user")
+		return
+	}
+	if err := h.tokenDB.RevokeUserRefreshTokens(r.Context(), user.ID.String()); err != nil {
+		log.Error(r.Context(), err.Error())
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Delete Me godoc
+// @Summary      Delete the authenticated user
+// @Description  Soft delete the user that owns the access token and revoke its sessions, the access token included
+// @Tags         users
+// @Success      204
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Security     ApiKeyAuth
+// @Router       /users/me [delete]
+func (h *UserHandler) DeleteMe(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok || !h.deleteUser(w, r, user) {
+		return
+	}
+	token, _, _ := jwtauth.FromContext(r.Context())
+	if err := h.tokenDB.RevokeAccessToken(r.Context(), token.JwtID(), token.Expiration()); err != nil {
+		log.Error(r.Context(), err.Error())
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Delete User godoc
+// @Summary      Delete a user
+// @Description  Soft delete a user and revoke its sessions. Only admins can call it
+// @Tags         users
+// @Param        id path string true "User ID"
+// @Success      204
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Security     ApiKeyAuth
+// @Router       /users/{id} [delete]
+func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.userDB.FindByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		ReturnError(w, r, err, "Failed to load user")
+		return
+	}
+	if h.deleteUser(w, r, user) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Get Users godoc
+// @Summary      List users
+// @Description  List users with pagination. Only admins can call it
+// @Tags         users
+// @Produce      json
+// @Param        page query int false "Page number, from 1" default(1)
+// @Param        limit query int false "Number of items per page, 1 to 100" default(10)
+// @Param        sort query string false "Sort by (id, name, email)"
+// @Param        sort_direction query string false "Sort direction"
+// @Param        include_total query bool false "Set to false to skip the total count"
+// @Success      200 {object} entityPkg.Page[dto.UserOutput]
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Security     ApiKeyAuth
+// @Router       /users [get]
+func (h *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
+	params, ok := ReadPageParams(w, r, map[string]string{
+		"id":    "usr_id",
+		"name":  "usr_name",
+		"email": "usr_email",
+	}, "usr_id")
+	if !ok {
+		return
+	}
+	users, err := h.userDB.FindAll(r.Context(), params.Page, params.Limit, params.OrderBy)
+	if err != nil {
+		ReturnError(w, r, err, "Failed to list users")
+		return
+	}
+	dtos := []dto.UserOutput{}
+	for _, user := range users {
+		dtos = append(dtos, newUserOutput(&user))
+	}
+	result := entityPkg.NewPageWithoutTotal(dtos, params.Page, params.Limit, params.Sort, params.SortDir)
+	if params.IncludeTotal {
+		count, err := h.userDB.Count(r.Context())
+		if err != nil {
+			ReturnError(w, r, err, "Failed to list users")
+			return
+		}
+		result.Meta.SetTotal(int(count))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+// currentUser loads the user identified by the "sub" claim of the verified
+// token. When it can't, the error response is already written.
+func (h *UserHandler) currentUser(w http.ResponseWriter, r *http.Request) (*entity.User, bool) {
+	_, claims, err := jwtauth.FromContext(r.Context())
+	if err != nil {
+		ReturnHttpError(w, entity.ErrTokenInvalid, http.StatusUnauthorized)
+		return nil, false
+	}
+	userID, _ := claims["sub"].(string)
+	user, err := h.userDB.FindByID(r.Context(), userID)
+	if err != nil {
+		ReturnError(w, r, err, "Failed to load user")
+		return nil, false
+	}
+	return user, true
+}
+
+// deleteUser soft deletes the user and revokes its refresh tokens. When it
+// fails, the error response is already written.
+func (h *UserHandler) deleteUser(w http.ResponseWriter, r *http.Request, user *entity.User) bool {
+	err := h.userDB.Delete(r.Context(), user.ID.String())
+	if err != nil {
+		ReturnError(w, r, err, "Failed to delete user")
+		return false
+	}
+	if err := h.tokenDB.RevokeUserRefreshTokens(r.Context(), user.ID.String()); err != nil {
+		log.Error(r.Context(), err.Error())
+	}
+	return true
+}
+
+func newUserOutput(user *entity.User) dto.UserOutput {
+	return dto.UserOutput{
 		ID:    user.ID.String(),
 		Name:  user.Name,
 		Email: user.Email,
-	})
+		Role:  user.Role,
+	}
 }
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/jwtauth"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/dto"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/database"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/database/dbtest"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/throttle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupUserHandler(t *testing.T) (*UserHandler, *database.User) {
+	db := dbtest.Open(t, dbtest.UserTables...)
+
+	userDB := database.NewUserDB(db, 0)
+	emailPolicy := throttle.Policy{MaxAttempts: 3, BaseLockout: time.Minute, MaxLockout: time.Hour}
+	ipPolicy := throttle.Policy{MaxAttempts: 10, BaseLockout: time.Minute, MaxLockout: time.Hour}
+	handler := NewUserHandler(userDB, database.NewTokenDB(db, 0), throttle.NewLoginThrottle(emailPolicy, ipPolicy),
+		jwtauth.New("HS256", []byte("secret"), nil), 300, 3600)
+	return handler, userDB
+}
+
+func TestUserHandler_AuthUniformFailures(t *testing.T) {
+	handler, userDB := setupUserHandler(t)
+
+	user, err := entity.NewUser("John Doe", "john@example.com", "password123")
+	require.NoError(t, err)
+	require.NoError(t, userDB.Create(context.Background(), user))
+
+	unknown := postJSON(t, handler.Auth, dto.LoginInput{Email: "nobody@example.com", Password: "password123"})
+	wrong := postJSON(t, handler.Auth, dto.LoginInput{Email: "john@example.com", Password: "wrong"})
+
+	assert.Equal(t, http.StatusUnauthorized, unknown.Code)
+	assert.Equal(t, http.StatusUnauthorized, wrong.Code)
+	assert.Equal(t, unknown.Body.String(), wrong.Body.String(), "Unknown emails must not be distinguishable")
+}
+
+func TestUserHandler_AuthLockout(t *testing.T) {
+	handler, userDB := setupUserHandler(t)
+
+	user, err := entity.NewUser("John Doe", "john@example.com", "password123")
+	require.NoError(t, err)
+	require.NoError(t, userDB.Create(context.Background(), user))
+
+	for i := 0; i < 3; i++ {
+		rec := postJSON(t, handler.Auth, dto.LoginInput{Email: "john@example.com", Password: "wrong"})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+
+	// Even the right password is refused while the account is locked
+	rec := postJSON(t, handler.Auth, dto.LoginInput{Email: "john@example.com", Password: "password123"})
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+
+	// Other accounts are not affected
+	other, err := entity.NewUser("Jane Doe", "jane@example.com", "password123")
+	require.NoError(t, err)
+	require.NoError(t, userDB.Create(context.Background(), other))
+	rec = postJSON(t, handler.Auth, dto.LoginInput{Email: "jane@example.com", Password: "password123"})
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response dto.AuthResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.NotEmpty(t, response.Token)
+	assert.NotEmpty(t, response.RefreshToken)
+}
+
+func TestUserHandler_CreateUserDuplicateEmail(t *testing.T) {
+	handler, _ := setupUserHandler(t)
+
+	input := dto.CreateUserInput{Name: "John Doe", Email: "john@example.com", Password: "password123"}
+	rec := postJSON(t, handler.CreateUser, input)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = postJSON(t, handler.CreateUser, input)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	var response dto.ErrorResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.Equal(t, []string{entity.ErrEmailTaken.Error()}, response.Messages)
+}
+
+// login authenticates the user and returns the tokens.
+func login(t *testing.T, handler *UserHandler, email, password string) dto.AuthResponse {
+	t.Helper()
+	rec := postJSON(t, handler.Auth, dto.LoginInput{Email: email, Password: password})
+	require.Equal(t, http.StatusOK, rec.Code)
+	var response dto.AuthResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	return response
+}
+
+func TestUserHandler_DeleteMeRevokesAccessToken(t *testing.T) {
+	handler, userDB := setupUserHandler(t)
+
+	user, err := entity.NewUser("John Doe", "john@example.com", "password123")
+	require.NoError(t, err)
+	require.NoError(t, userDB.Create(context.Background(), user))
+	tokens := login(t, handler, "john@example.com", "password123")
+
+	token, err := handler.jwtAuth.Decode(tokens.Token)
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodDelete, "/users/me", nil)
+	req = req.WithContext(jwtauth.NewContext(req.Context(), token, nil))
+	rec := httptest.NewRecorder()
+	handler.DeleteMe(rec, req)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	revoked, err := handler.tokenDB.IsAccessTokenRevoked(context.Background(), token.JwtID())
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	rec = postJSON(t, handler.Refresh, dto.RefreshTokenInput{RefreshToken: tokens.RefreshToken})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestUserHandler_UpdateUserRoleRevokesSessions(t *testing.T) {
+	handler, userDB := setupUserHandler(t)
+
+	user, err := entity.NewUser("John Doe", "john@example.com", "password123")
+	require.NoError(t, err)
+	require.NoError(t, user.SetRole(entity.RoleEditor))
+	require.NoError(t, userDB.Create(context.Background(), user))
+	tokens := login(t, handler, "john@example.com", "password123")
+
+	req := httptest.NewRequest(http.MethodPut, "/users/"+user.ID.String()+"/role", strings.NewReader(`{"role":"viewer"}`))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", user.ID.String())
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rec := httptest.NewRecorder()
+	handler.UpdateUserRole(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// The refresh token would sign a new access token with the old role
+	rec = postJSON(t, handler.Refresh, dto.RefreshTokenInput{RefreshToken: tokens.RefreshToken})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
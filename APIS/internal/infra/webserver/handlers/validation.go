@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	entityPkg "github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
+	"github.com/jb-oliveira/fullcycle/APIS/pkg/log"
+)
+
+// validate checks the `binding` tags of the dto structs. Fields are reported
+// by their json name, the one the client sent.
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.SetTagName("binding")
+	// gt and the other number tags apply to the amount of the prices
+	v.RegisterCustomTypeFunc(func(field reflect.Value) any {
+		return field.Interface().(entityPkg.Money).Amount
+	}, entityPkg.Money{})
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+	return v
+}
+
+// moneyMessage is the error of a malformed amount of money.
+const moneyMessage = "must be a number with at most the decimals of its currency"
+
+// FieldError is a validation error on a single field of the request body.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// maxJSONBody bounds the JSON request bodies, a larger one gets a 413.
+const maxJSONBody = 1 << 20
+
+// DecodeJSON decodes the request body into dst, rejecting unknown fields,
+// and validates it against its `binding` tags. On failure the response is
+// already written and false is returned.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, maxJSONBody)
+	return decodeJSON(r.Context(), w, r.Body, dst)
+}
+
+func decodeJSON(ctx context.Context, w http.ResponseWriter, body io.Reader, dst interface{}) bool {
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		log.Error(ctx, err.Error())
+		returnBodyError(w, err, decodeError(err))
+		return false
+	}
+	errs := ValidateStruct(dst)
+	if len(errs) > 0 {
+		ReturnHttpErrors(w, errs, http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// returnBodyError writes a 413 when err is the body going over its limit,
+// otherwise a 400 with message.
+func returnBodyError(w http.ResponseWriter, err error, message error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		ReturnHttpError(w, errors.New("request body cannot exceed "+strconv.FormatInt(maxBytesErr.Limit, 10)+" bytes"),
+			http.StatusRequestEntityTooLarge)
+		return
+	}
+	ReturnHttpError(w, message, http.StatusBadRequest)
+}
+
+// ValidateStruct returns one FieldError for every field that breaks its
+// `binding` tags.
+func ValidateStruct(s interface{}) []error {
+	err := validate.Struct(s)
+	if err == nil {
+		return nil
+	}
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return []error{err}
+	}
+	errs := make([]error, 0, len(validationErrors))
+	for _, fieldErr := range validationErrors {
+		errs = append(errs, &FieldError{Field: fieldErr.Field(), Message: fieldMessage(fieldErr)})
+	}
+	return errs
+}
+
+func fieldMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "gt":
+		return "must be greater than " + fieldErr.Param()
+	case "gte":
+		return "must be at least " + fieldErr.Param()
+	case "lt":
+		return "must be less than " + fieldErr.Param()
+	case "lte":
+		return "must be at most " + fieldErr.Param()
+	case "min":
+		return "must have at least " + fieldErr.Param() + " characters"
+	case "max":
+		return "must have at most " + fieldErr.Param() + " characters"
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fieldErr.Param(), " ", ", ")
+	case "email":
+		return "must be a valid email"
+	default:
+		return fmt.Sprintf("failed on %s", fieldErr.Tag())
+	}
+}
+
+// decodeError turns the json errors into messages that don't leak Go types.
+func decodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Type == reflect.TypeFor[entityPkg.Money]() {
+		// When encoding/json is backed by json v2, Field is left empty for
+		// the errors of UnmarshalJSON methods, the amount is named instead
+		if typeErr.Field == "" {
+			return errors.New(typeErr.Value + " " + moneyMessage)
+		}
+		return &FieldError{Field: typeErr.Field, Message: moneyMessage}
+	}
+	if errors.As(err, &typeErr) {
+		return &FieldError{Field: typeErr.Field, Message: "must be a " + typeErr.Type.Kind().String()}
+	}
+	if field, found := strings.CutPrefix(err.Error(), "json: unknown field "); found {
+		return &FieldError{Field: strings.Trim(field, `"`), Message: "is not allowed"}
+	}
+	return errors.New("Invalid request body")
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jb-oliveira/fullcycle/APIS/internal/dto"
+	entityPkg "github.com/jb-oliveira/fullcycle/APIS/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantOK     bool
+		wantFields map[string]string
+		// wantMessage is checked instead of wantFields, the amount may
+		// come without its field
+		wantMessage string
+	}{
+		{
+			name:   "valid body",
+			body:   `{"name":"Laptop","price":999.99}`,
+			wantOK: true,
+		},
+		{
+			name:   "every field error is returned",
+			body:   `{"price":-1}`,
+			wantOK: false,
+			wantFields: map[string]string{
+				"name":  "is required",
+				"price": "must be greater than 0",
+			},
+		},
+		{
+			name:       "unknown field",
+			body:       `{"name":"Laptop","price":10,"discount":5}`,
+			wantOK:     false,
+			wantFields: map[string]string{"discount": "is not allowed"},
+		},
+		{
+			name:       "wrong type",
+			body:       `{"name":10,"price":10}`,
+			wantOK:     false,
+			wantFields: map[string]string{"name": "must be a string"},
+		},
+		{
+			name:        "malformed amount",
+			body:        `{"name":"Laptop","price":"ten"}`,
+			wantOK:      false,
+			wantMessage: moneyMessage,
+		},
+		{
+			name:   "malformed json",
+			body:   `{"name":`,
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+
+			var input dto.CreateProductInput
+			ok := DecodeJSON(rec, req, &input)
+			assert.Equal(t, tt.wantOK, ok)
+			if tt.wantOK {
+				return
+			}
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			var response dto.ErrorResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+			require.NotEmpty(t, response.Messages)
+			if tt.wantMessage != "" {
+				assert.Contains(t, response.Messages[0], tt.wantMessage)
+				return
+			}
+			assert.Equal(t, tt.wantFields, response.Fields)
+		})
+	}
+}
+
+func TestDecodeJSON_TooLarge(t *testing.T) {
+	body := `{"name":"` + strings.Repeat("a", maxJSONBody) + `","price":10}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	var input dto.CreateProductInput
+	assert.False(t, DecodeJSON(rec, req, &input))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
+
+func TestDecodeError_Money(t *testing.T) {
+	type order struct {
+		Total entityPkg.Money `json:"total"`
+		Items []struct {
+			Cost entityPkg.Money `json:"cost"`
+		} `json:"items"`
+	}
+	tests := []struct {
+		body  string
+		field string
+		value string
+	}{
+		{`{"total":"ten"}`, "total", `"ten"`},
+		{`{"total":"1.00","items":[{"cost":"1"},{"cost":"1.234"}]}`, "items.cost", `"1.234"`},
+	}
+	for _, tt := range tests {
+		var dst order
+		err := json.Unmarshal([]byte(tt.body), &dst)
+		require.Error(t, err, tt.body)
+		// The field when encoding/json reports it, otherwise the amount
+		var fieldErr *FieldError
+		if err := decodeError(err); errors.As(err, &fieldErr) {
+			assert.Equal(t, &FieldError{Field: tt.field, Message: moneyMessage}, fieldErr, tt.body)
+		} else {
+			assert.EqualError(t, err, tt.value+" "+moneyMessage, tt.body)
+		}
+	}
+}
+
+func TestValidateStruct_Oneof(t *testing.T) {
+	errs := ValidateStruct(&dto.UpdateUserRoleInput{Role: "root"})
+	require.Len(t, errs, 1)
+	assert.Equal(t, "role must be one of admin, editor, viewer", errs[0].Error())
+
+	assert.Empty(t, ValidateStruct(&dto.UpdateUserRoleInput{Role: "editor"}))
+}
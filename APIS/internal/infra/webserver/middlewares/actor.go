@@ -0,0 +1,22 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/go-chi/jwtauth"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+)
+
+// Actor puts the subject of the verified token in the request context, the
+// repositories record it as the author of the changes. It must run after
+// the Verifier.
+func Actor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, _, err := jwtauth.FromContext(r.Context())
+		if err != nil || token == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(entity.ContextWithActor(r.Context(), token.Subject())))
+	})
+}
@@ -0,0 +1,32 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/jwtauth"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActor(t *testing.T) {
+	ja := jwtauth.New("HS256", []byte("secret"), nil)
+	var actor string
+	handler := jwtauth.Verifier(ja)(Actor(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor = entity.ActorFromContext(r.Context())
+	})))
+
+	_, token, err := ja.Encode(map[string]interface{}{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "user-1", actor)
+
+	actor = "unchanged"
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(t, "", actor, "Requests without a token have no actor")
+}
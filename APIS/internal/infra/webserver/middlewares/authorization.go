@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/jwtauth"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/webserver/handlers"
+)
+
+// RequireRole only lets the request through when the "rol" claim of the
+// verified token is one of the given roles. It must run after
+// jwtauth.Authenticator.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, claims, err := jwtauth.FromContext(r.Context())
+			if err != nil {
+				handlers.ReturnHttpError(w, entity.ErrTokenInvalid, http.StatusUnauthorized)
+				return
+			}
+			role, _ := claims["rol"].(string)
+			if !slices.Contains(roles, role) {
+				handlers.ReturnHttpError(w, entity.ErrForbidden, http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/jwtauth"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireRole(t *testing.T) {
+	ja := jwtauth.New("HS256", []byte("secret"), nil)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	server := jwtauth.Verifier(ja)(jwtauth.Authenticator(
+		RequireRole(entity.RoleEditor, entity.RoleAdmin)(ok),
+	))
+
+	tests := []struct {
+		name     string
+		claims   map[string]interface{}
+		expected int
+	}{
+		{
+			name:     "admin is allowed",
+			claims:   map[string]interface{}{"sub": "user", "rol": entity.RoleAdmin},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "editor is allowed",
+			claims:   map[string]interface{}{"sub": "user", "rol": entity.RoleEditor},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "viewer is forbidden",
+			claims:   map[string]interface{}{"sub": "user", "rol": entity.RoleViewer},
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "token without role is forbidden",
+			claims:   map[string]interface{}{"sub": "user"},
+			expected: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.claims["exp"] = time.Now().Add(time.Hour).Unix()
+			_, token, err := ja.Encode(tt.claims)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+
+			server.ServeHTTP(rec, req)
+			assert.Equal(t, tt.expected, rec.Code)
+		})
+	}
+}
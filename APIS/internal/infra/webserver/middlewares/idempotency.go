@@ -0,0 +1,144 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/database"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/webserver/handlers"
+	"github.com/jb-oliveira/fullcycle/APIS/pkg/log"
+)
+
+const (
+	// IdempotencyKeyHeader carries the key chosen by the client
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks a response sent again from the store
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	// maxIdempotentBody bounds the body read to hash the request
+	maxIdempotentBody = 1 << 20
+)
+
+// replayedHeaders are the response headers stored with the body. The
+// others, like the RateLimit-* ones, belong to the request that sends them.
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// Idempotency runs a request sent with an Idempotency-Key header only once
+// per user. The response is stored for ttl and sent again, with
+// Idempotent-Replayed, to every retry with the same key and body. Reusing
+// the key with another body is answered 422, and a retry while the first
+// request still runs 409. Server errors are not stored, the request can be
+// retried with the same key. It must run after the Verifier.
+func Idempotency(store database.IdempotencyInterface, ttl time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			value := r.Header.Get(IdempotencyKeyHeader)
+			if value == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBody))
+			if err != nil {
+				log.Error(r.Context(), err.Error())
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					handlers.ReturnHttpError(w, errors.New("request body cannot exceed "+strconv.Itoa(maxIdempotentBody)+" bytes"), http.StatusRequestEntityTooLarge)
+					return
+				}
+				handlers.ReturnHttpError(w, errors.New("Invalid request body"), http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			scope := BySubject(r) + " " + r.Method + " " + r.URL.Path
+			key, err := entity.NewIdempotencyKey(scope, value, hashBody(body), ttl)
+			if err != nil {
+				handlers.ReturnError(w, r, err, "invalid Idempotency-Key")
+				return
+			}
+			existing, err := store.Reserve(r.Context(), key)
+			if err != nil {
+				handlers.ReturnError(w, r, err, "failed to reserve the Idempotency-Key")
+				return
+			}
+			if existing != nil {
+				replay(w, existing, key.RequestHash)
+				return
+			}
+
+			// The client may be gone, the key must still be completed or
+			// released
+			ctx := context.WithoutCancel(r.Context())
+			// A panic must not hold the key until it expires
+			defer func() {
+				if p := recover(); p != nil {
+					release(ctx, store, key)
+					panic(p)
+				}
+			}()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			var response bytes.Buffer
+			ww.Tee(&response)
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status >= http.StatusInternalServerError {
+				release(ctx, store, key)
+				return
+			}
+			key.Status = status
+			key.Header = make(map[string]string)
+			for _, name := range replayedHeaders {
+				if v := ww.Header().Get(name); v != "" {
+					key.Header[name] = v
+				}
+			}
+			key.Body = response.Bytes()
+			if err := store.Complete(ctx, key); err != nil {
+				log.Error(ctx, "failed to store the response of the Idempotency-Key: "+err.Error())
+			}
+		})
+	}
+}
+
+// replay answers a request whose key is already in the store.
+func replay(w http.ResponseWriter, existing *entity.IdempotencyKey, requestHash string) {
+	var err error
+	if existing.RequestHash != requestHash {
+		err = entity.ErrIdempotencyKeyReused
+	} else if !existing.IsCompleted() {
+		err = entity.ErrIdempotencyKeyInUse
+	}
+	if err != nil {
+		handlers.ReturnHttpError(w, err, handlers.HttpStatus(err))
+		return
+	}
+	for name, value := range existing.Header {
+		w.Header().Set(name, value)
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(existing.Status)
+	w.Write(existing.Body)
+}
+
+func release(ctx context.Context, store database.IdempotencyInterface, key *entity.IdempotencyKey) {
+	if err := store.Release(ctx, key); err != nil {
+		log.Error(ctx, "failed to release the Idempotency-Key: "+err.Error())
+	}
+}
+
+func hashBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
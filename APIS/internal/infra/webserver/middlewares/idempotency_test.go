@@ -0,0 +1,146 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryKeys is an in memory database.IdempotencyInterface.
+type memoryKeys struct {
+	mu   sync.Mutex
+	keys map[string]*entity.IdempotencyKey
+}
+
+func newMemoryKeys() *memoryKeys {
+	return &memoryKeys{keys: make(map[string]*entity.IdempotencyKey)}
+}
+
+func (m *memoryKeys) Reserve(_ context.Context, key *entity.IdempotencyKey) (*entity.IdempotencyKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, ok := m.keys[key.Scope+" "+key.Key]; ok && !existing.IsExpired(time.Now()) {
+		copied := *existing
+		return &copied, nil
+	}
+	m.keys[key.Scope+" "+key.Key] = key
+	return nil, nil
+}
+
+func (m *memoryKeys) Complete(_ context.Context, key *entity.IdempotencyKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	copied := *key
+	m.keys[key.Scope+" "+key.Key] = &copied
+	return nil
+}
+
+func (m *memoryKeys) Release(_ context.Context, key *entity.IdempotencyKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.keys, key.Scope+" "+key.Key)
+	return nil
+}
+
+func (m *memoryKeys) Purge(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestIdempotency(t *testing.T) {
+	var calls int
+	status := http.StatusCreated
+	create := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"1"`)
+		w.Header().Set("RateLimit-Remaining", "9")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"call":` + strconv.Itoa(calls) + `}`))
+	})
+
+	post := func(handler http.Handler, remoteAddr, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(body))
+		req.RemoteAddr = remoteAddr
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("should replay the first response", func(t *testing.T) {
+		calls = 0
+		handler := Idempotency(newMemoryKeys(), time.Hour)(create)
+
+		rec := post(handler, "10.0.0.1:1234", "retry-1", `{"name":"Laptop"}`)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, `{"call":1}`, rec.Body.String())
+		assert.Empty(t, rec.Header().Get(IdempotentReplayedHeader))
+
+		rec = post(handler, "10.0.0.1:1234", "retry-1", `{"name":"Laptop"}`)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, `{"call":1}`, rec.Body.String())
+		assert.Equal(t, "true", rec.Header().Get(IdempotentReplayedHeader))
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.Equal(t, `"1"`, rec.Header().Get("ETag"))
+		assert.Empty(t, rec.Header().Get("RateLimit-Remaining"), "Only the headers of the response are replayed")
+		assert.Equal(t, 1, calls)
+
+		rec = post(handler, "10.0.0.1:1234", "retry-1", `{"name":"Mouse"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Contains(t, rec.Body.String(), "different request")
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("should keep the keys of every client apart", func(t *testing.T) {
+		calls = 0
+		handler := Idempotency(newMemoryKeys(), time.Hour)(create)
+
+		assert.Equal(t, `{"call":1}`, post(handler, "10.0.0.1:1234", "retry-1", `{}`).Body.String())
+		assert.Equal(t, `{"call":2}`, post(handler, "10.0.0.2:1234", "retry-1", `{}`).Body.String())
+		assert.Equal(t, `{"call":3}`, post(handler, "10.0.0.1:1234", "retry-2", `{}`).Body.String())
+		assert.Equal(t, `{"call":4}`, post(handler, "10.0.0.1:1234", "", `{}`).Body.String(), "No key, no replay")
+		assert.Equal(t, `{"call":5}`, post(handler, "10.0.0.1:1234", "", `{}`).Body.String())
+	})
+
+	t.Run("should not store server errors", func(t *testing.T) {
+		calls = 0
+		status = http.StatusInternalServerError
+		defer func() { status = http.StatusCreated }()
+		handler := Idempotency(newMemoryKeys(), time.Hour)(create)
+
+		assert.Equal(t, http.StatusInternalServerError, post(handler, "10.0.0.1:1234", "retry-1", `{}`).Code)
+		status = http.StatusCreated
+		rec := post(handler, "10.0.0.1:1234", "retry-1", `{}`)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Empty(t, rec.Header().Get(IdempotentReplayedHeader))
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("should reject a retry while the first request runs", func(t *testing.T) {
+		store := newMemoryKeys()
+		key, err := entity.NewIdempotencyKey("ip:10.0.0.1 POST /products", "retry-1", hashBody([]byte(`{}`)), time.Hour)
+		require.NoError(t, err)
+		_, err = store.Reserve(context.Background(), key)
+		require.NoError(t, err)
+
+		rec := post(Idempotency(store, time.Hour)(create), "10.0.0.1:1234", "retry-1", `{}`)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Contains(t, rec.Body.String(), "still in progress")
+	})
+
+	t.Run("should reject an invalid key", func(t *testing.T) {
+		rec := post(Idempotency(newMemoryKeys(), time.Hour)(create), "10.0.0.1:1234", strings.Repeat("k", 256), `{}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
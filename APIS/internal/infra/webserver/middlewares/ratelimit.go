@@ -0,0 +1,72 @@
+package middlewares
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/jwtauth"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/ratelimit"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/webserver/handlers"
+	"github.com/jb-oliveira/fullcycle/APIS/pkg/log"
+)
+
+var errTooManyRequests = errors.New("Too many requests, try again later")
+
+// KeyFunc returns the client a request is counted against.
+type KeyFunc func(r *http.Request) string
+
+// ByIP counts the requests per client IP, for the public routes.
+func ByIP(r *http.Request) string {
+	return "ip:" + handlers.ClientIP(r)
+}
+
+// BySubject counts the requests per user, the "sub" claim of the verified
+// token. Requests without one fall back to their IP. It must run after the
+// Verifier.
+func BySubject(r *http.Request) string {
+	token, _, err := jwtauth.FromContext(r.Context())
+	if err != nil || token == nil || token.Subject() == "" {
+		return ByIP(r)
+	}
+	return "sub:" + token.Subject()
+}
+
+// RateLimit takes a token from the bucket of the client for every request
+// and answers 429 once it is empty. group keeps the buckets of every route
+// group apart. The state of the bucket is sent in the RateLimit-* headers.
+// A failing store lets the requests through, it must not take the API down.
+func RateLimit(store ratelimit.Store, group string, limit ratelimit.Limit, key KeyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !limit.Enabled() {
+			return next
+		}
+		policy := strconv.Itoa(limit.Requests) + ";w=" + strconv.Itoa(seconds(limit.Period))
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err := store.Take(r.Context(), group+":"+key(r), limit)
+			if err != nil {
+				log.Error(r.Context(), "rate limit store failed: "+err.Error())
+				next.ServeHTTP(w, r)
+				return
+			}
+			header := w.Header()
+			header.Set("RateLimit-Policy", policy)
+			header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			header.Set("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+			if !result.Allowed {
+				header.Set("Retry-After", strconv.Itoa(max(1, seconds(result.RetryAfter))))
+				handlers.ReturnHttpError(w, errTooManyRequests, http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// seconds rounds d up, the headers only take whole seconds.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
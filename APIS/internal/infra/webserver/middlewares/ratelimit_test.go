@@ -0,0 +1,100 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/jwtauth"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("store is down")
+}
+
+func TestRateLimit(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	limit := ratelimit.Limit{Requests: 2, Period: time.Minute}
+
+	get := func(handler http.Handler, remoteAddr, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("should limit by IP and send the headers", func(t *testing.T) {
+		handler := RateLimit(ratelimit.NewMemoryStore(), "public", limit, ByIP)(ok)
+
+		rec := get(handler, "10.0.0.1:1234", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "2;w=60", rec.Header().Get("RateLimit-Policy"))
+		assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "30", rec.Header().Get("RateLimit-Reset"))
+		assert.Empty(t, rec.Header().Get("Retry-After"))
+
+		assert.Equal(t, http.StatusOK, get(handler, "10.0.0.1:5678", "").Code, "the port is not part of the key")
+		rec = get(handler, "10.0.0.1:1234", "")
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "30", rec.Header().Get("Retry-After"))
+		assert.Contains(t, rec.Body.String(), "Too many requests")
+
+		assert.Equal(t, http.StatusOK, get(handler, "10.0.0.2:1234", "").Code, "another IP has its own bucket")
+	})
+
+	t.Run("should limit by token subject", func(t *testing.T) {
+		ja := jwtauth.New("HS256", []byte("secret"), nil)
+		handler := jwtauth.Verifier(ja)(RateLimit(ratelimit.NewMemoryStore(), "products", limit, BySubject)(ok))
+		token := func(sub string) string {
+			_, signed, err := ja.Encode(map[string]interface{}{"sub": sub, "exp": time.Now().Add(time.Hour).Unix()})
+			require.NoError(t, err)
+			return signed
+		}
+		john, mary := token("john"), token("mary")
+
+		assert.Equal(t, http.StatusOK, get(handler, "10.0.0.1:1", john).Code)
+		assert.Equal(t, http.StatusOK, get(handler, "10.0.0.2:1", john).Code)
+		assert.Equal(t, http.StatusTooManyRequests, get(handler, "10.0.0.3:1", john).Code, "the user is limited from any IP")
+		assert.Equal(t, http.StatusOK, get(handler, "10.0.0.1:1", mary).Code, "users behind the same IP don't share a bucket")
+	})
+
+	t.Run("should keep the groups apart", func(t *testing.T) {
+		store := ratelimit.NewMemoryStore()
+		auth := RateLimit(store, "auth", ratelimit.Limit{Requests: 1, Period: time.Minute}, ByIP)(ok)
+		users := RateLimit(store, "users", ratelimit.Limit{Requests: 1, Period: time.Minute}, ByIP)(ok)
+
+		assert.Equal(t, http.StatusOK, get(auth, "10.0.0.1:1", "").Code)
+		assert.Equal(t, http.StatusOK, get(users, "10.0.0.1:1", "").Code)
+		assert.Equal(t, http.StatusTooManyRequests, get(auth, "10.0.0.1:1", "").Code)
+	})
+
+	t.Run("should not limit with a zero limit", func(t *testing.T) {
+		handler := RateLimit(ratelimit.NewMemoryStore(), "public", ratelimit.Limit{}, ByIP)(ok)
+		for range 5 {
+			rec := get(handler, "10.0.0.1:1", "")
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+		}
+	})
+
+	t.Run("should let the requests through when the store fails", func(t *testing.T) {
+		handler := RateLimit(failingStore{}, "public", limit, ByIP)(ok)
+		assert.Equal(t, http.StatusOK, get(handler, "10.0.0.1:1", "").Code)
+	})
+}
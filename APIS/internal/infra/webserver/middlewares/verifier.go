@@ -0,0 +1,48 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/jwtauth"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/infra/database"
+	"github.com/jb-oliveira/fullcycle/APIS/pkg/log"
+)
+
+// Verifier works like jwtauth.Verifier, but also marks the token as
+// invalid when its jti was revoked through logout, when its user was
+// deleted or when the "rol" claim is no longer the role of the user. The
+// result is left on the request context for jwtauth.Authenticator to
+// accept or reject.
+func Verifier(ja *jwtauth.JWTAuth, tokenDB database.TokenInterface, userDB database.UserInterface) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, err := jwtauth.VerifyRequest(ja, r, jwtauth.TokenFromHeader, jwtauth.TokenFromCookie)
+			if err == nil {
+				revoked, checkErr := tokenDB.IsAccessTokenRevoked(r.Context(), token.JwtID())
+				if checkErr != nil {
+					log.Error(r.Context(), checkErr.Error())
+					err = jwtauth.ErrUnauthorized
+				} else if revoked {
+					err = entity.ErrTokenRevoked
+				}
+			}
+			if err == nil {
+				// Deletes and role changes take effect before the token expires
+				role, _ := token.Get("rol")
+				user, checkErr := userDB.FindByID(r.Context(), token.Subject())
+				if errors.Is(checkErr, entity.ErrUserNotFound) {
+					err = entity.ErrTokenRevoked
+				} else if checkErr != nil {
+					log.Error(r.Context(), checkErr.Error())
+					err = jwtauth.ErrUnauthorized
+				} else if user.Role != role {
+					err = entity.ErrTokenRevoked
+				}
+			}
+			ctx := jwtauth.NewContext(r.Context(), token, err)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/jwtauth"
+	"github.com/jb-oliveira/fullcycle/APIS/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// revokedTokens is an in memory database.TokenInterface that only
+// knows about revoked access tokens.
+type revokedTokens map[string]bool
+
+func (revokedTokens) CreateRefreshToken(context.Context, *entity.RefreshToken) error { return nil }
+func (revokedTokens) FindRefreshTokenByHash(context.Context, string) (*entity.RefreshToken, error) {
+	return nil, entity.ErrTokenInvalid
+}
+func (revokedTokens) RotateRefreshToken(_ context.Context, _, _ *entity.RefreshToken) error {
+	return nil
+}
+func (revokedTokens) RevokeRefreshToken(context.Context, string) error      { return nil }
+func (revokedTokens) RevokeUserRefreshTokens(context.Context, string) error { return nil }
+func (r revokedTokens) RevokeAccessToken(_ context.Context, jti string, _ time.Time) error {
+	r[jti] = true
+	return nil
+}
+func (r revokedTokens) IsAccessTokenRevoked(_ context.Context, jti string) (bool, error) {
+	return r[jti], nil
+}
+func (revokedTokens) CreatePasswordResetToken(context.Context, *entity.PasswordResetToken) error {
+	return nil
+}
+func (revokedTokens) FindPasswordResetTokenByHash(context.Context, string) (*entity.PasswordResetToken, error) {
+	return nil, entity.ErrTokenInvalid
+}
+func (revokedTokens) UsePasswordResetToken(context.Context, *entity.PasswordResetToken) error {
+	return nil
+}
+
+// userRoles is an in memory database.UserInterface that only knows the
+// role of each user, a missing user is a deleted one.
+type userRoles map[string]string
+
+func (userRoles) Create(context.Context, *entity.User) error { return nil }
+func (userRoles) FindByEmail(context.Context, string) (*entity.User, error) {
+	return nil, entity.ErrUserNotFound
+}
+func (u userRoles) FindByID(_ context.Context, id string) (*entity.User, error) {
+	role, ok := u[id]
+	if !ok {
+		return nil, entity.ErrUserNotFound
+	}
+	return &entity.User{Role: role}, nil
+}
+func (userRoles) Update(context.Context, *entity.User) error { return nil }
+func (userRoles) Delete(context.Context, string) error       { return nil }
+func (userRoles) FindAll(context.Context, int, int, string) ([]entity.User, error) {
+	return nil, nil
+}
+func (userRoles) Count(context.Context) (int64, error) { return 0, nil }
+
+func newVerifierServer(ja *jwtauth.JWTAuth, tokens revokedTokens, users userRoles) http.Handler {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return Verifier(ja, tokens, users)(jwtauth.Authenticator(ok))
+}
+
+func signToken(t *testing.T, ja *jwtauth.JWTAuth, jti string) string {
+	t.Helper()
+	_, token, err := ja.Encode(map[string]interface{}{
+		"sub": "user",
+		"rol": entity.RoleEditor,
+		"jti": jti,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	require.NoError(t, err)
+	return token
+}
+
+func TestVerifier(t *testing.T) {
+	ja := jwtauth.New("HS256", []byte("secret"), nil)
+
+	t.Run("should accept a valid token", func(t *testing.T) {
+		server := newVerifierServer(ja, revokedTokens{}, userRoles{"user": entity.RoleEditor})
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+signToken(t, ja, "jti-1"))
+		rec := httptest.NewRecorder()
+
+		server.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("should reject a revoked token", func(t *testing.T) {
+		server := newVerifierServer(ja, revokedTokens{"jti-1": true}, userRoles{"user": entity.RoleEditor})
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+signToken(t, ja, "jti-1"))
+		rec := httptest.NewRecorder()
+
+		server.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Contains(t, rec.Body.String(), entity.ErrTokenRevoked.Error())
+	})
+
+	t.Run("should reject the token of a deleted user", func(t *testing.T) {
+		server := newVerifierServer(ja, revokedTokens{}, userRoles{})
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+signToken(t, ja, "jti-1"))
+		rec := httptest.NewRecorder()
+
+		server.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Contains(t, rec.Body.String(), entity.ErrTokenRevoked.Error())
+	})
+
+	t.Run("should reject a token with an outdated role", func(t *testing.T) {
+		server := newVerifierServer(ja, revokedTokens{}, userRoles{"user": entity.RoleViewer})
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+signToken(t, ja, "jti-1"))
+		rec := httptest.NewRecorder()
+
+		server.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Contains(t, rec.Body.String(), entity.ErrTokenRevoked.Error())
+	})
+
+	t.Run("should reject a request without token", func(t *testing.T) {
+		server := newVerifierServer(ja, revokedTokens{}, userRoles{"user": entity.RoleEditor})
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+
+		server.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is a position for keyset pagination. IDs are UUIDv7, so ordering
+// by ID is ordering by creation time.
+type Cursor struct {
+	ID       ID   `json:"id"`
+	Backward bool `json:"b,omitempty"` // the items before ID instead of after it
+	Desc     bool `json:"d,omitempty"` // the sort direction the cursor was issued for
+}
+
+// Encode returns the opaque token sent to the clients.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a token returned by Encode.
+func DecodeCursor(token string) (Cursor, error) {
+	var c Cursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
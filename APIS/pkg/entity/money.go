@@ -0,0 +1,187 @@
+package entity
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidMoney    = errors.New("invalid amount of money")
+	ErrInvalidCurrency = errors.New("currency must be a 3 letter ISO 4217 code")
+)
+
+// DefaultCurrency is the currency of the amounts sent without one, the
+// numeric prices of the older clients among them.
+const DefaultCurrency = "BRL"
+
+// currencyDigits are the ISO 4217 minor units of the currencies that don't
+// have two decimal places, a yen has no cents and a dinar has 1000 fils.
+var currencyDigits = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0,
+	"XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// MinorDigits is the number of decimal places of the amounts in currency,
+// two unless ISO 4217 says otherwise.
+func MinorDigits(currency string) int {
+	if digits, ok := currencyDigits[currency]; ok {
+		return digits
+	}
+	return 2
+}
+
+// Money is an amount in minor units (cents, yen, fils) of its currency.
+// Integers keep the sums exact, the JSON has the amount as a decimal string.
+type Money struct {
+	Amount   int64  `json:"amount" gorm:"column:amount" swaggertype:"string" example:"12.34"`
+	Currency string `json:"currency" gorm:"column:currency;size:3" example:"BRL"`
+}
+
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney reads a decimal amount like "12.34" or "-5", with at most the
+// MinorDigits of the currency. The currency is upper-cased, an empty one
+// is DefaultCurrency.
+func ParseMoney(amount, currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	money := Money{Currency: currency}
+	if err := money.Validate(); err != nil {
+		return Money{}, err
+	}
+
+	minorDigits := MinorDigits(currency)
+	digits, negative := strings.CutPrefix(amount, "-")
+	units, fraction, hasPoint := strings.Cut(digits, ".")
+	if units == "" || !isDigits(units) || !isDigits(fraction) || len(fraction) > minorDigits || (hasPoint && minorDigits == 0) {
+		return Money{}, ErrInvalidMoney
+	}
+	fraction += strings.Repeat("0", minorDigits-len(fraction))
+	minor, err := strconv.ParseInt(units+fraction, 10, 64)
+	if err != nil {
+		return Money{}, ErrInvalidMoney
+	}
+	if negative {
+		minor = -minor
+	}
+	money.Amount = minor
+	return money, nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks the currency, any amount is valid.
+func (m Money) Validate() error {
+	if len(m.Currency) != 3 {
+		return ErrInvalidCurrency
+	}
+	for _, c := range m.Currency {
+		if c < 'A' || c > 'Z' {
+			return ErrInvalidCurrency
+		}
+	}
+	return nil
+}
+
+// Decimal returns the amount with the decimal places of its currency,
+// "12.34" BRL or "1234" JPY.
+func (m Money) Decimal() string {
+	minorDigits := MinorDigits(m.Currency)
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	digits := strconv.FormatInt(amount, 10)
+	if minorDigits == 0 {
+		return sign + digits
+	}
+	if len(digits) <= minorDigits {
+		digits = strings.Repeat("0", minorDigits-len(digits)+1) + digits
+	}
+	split := len(digits) - minorDigits
+	return sign + digits[:split] + "." + digits[split:]
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+type moneyJSON struct {
+	Amount   json.RawMessage `json:"amount"`
+	Currency string          `json:"currency"`
+}
+
+// MarshalJSON writes {"amount": "12.34", "currency": "BRL"}. Up to API
+// version 1.0 prices were written as a bare number, version 2.0 breaks the
+// clients that still read them that way.
+func (m Money) MarshalJSON() ([]byte, error) {
+	amount, _ := json.Marshal(m.Decimal())
+	return json.Marshal(moneyJSON{Amount: amount, Currency: m.Currency})
+}
+
+// UnmarshalJSON accepts {"amount": "12.34", "currency": "USD"}, the amount
+// being a string or a number, and a bare number or string in DefaultCurrency
+// as the plain prices used to be sent.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	currency := ""
+	if len(data) > 0 && data[0] == '{' {
+		var object moneyJSON
+		if err := json.Unmarshal(data, &object); err != nil {
+			return moneyTypeError(data)
+		}
+		data, currency = object.Amount, object.Currency
+	}
+	amount, err := jsonAmount(data)
+	if err != nil {
+		return moneyTypeError(data)
+	}
+	money, err := ParseMoney(amount, currency)
+	if err != nil {
+		return moneyTypeError(data)
+	}
+	*m = money
+	return nil
+}
+
+// jsonAmount is the text of a number or string amount.
+func jsonAmount(data []byte) (string, error) {
+	if len(data) > 0 && data[0] == '"' {
+		var amount string
+		err := json.Unmarshal(data, &amount)
+		return amount, err
+	}
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return "", err
+	}
+	return number.String(), nil
+}
+
+// moneyTypeError lets encoding/json add the name of the field.
+func moneyTypeError(data []byte) error {
+	return &json.UnmarshalTypeError{Value: string(data), Type: reflect.TypeFor[Money]()}
+}
@@ -0,0 +1,105 @@
+package entity
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		amount string
+		want   int64
+	}{
+		{"12.34", 1234},
+		{"12.3", 1230},
+		{"12", 1200},
+		{"0.05", 5},
+		{"-7.5", -750},
+	}
+	for _, tt := range tests {
+		money, err := ParseMoney(tt.amount, "USD")
+		require.NoError(t, err, tt.amount)
+		assert.Equal(t, NewMoney(tt.want, "USD"), money, tt.amount)
+	}
+
+	money, err := ParseMoney("1", "")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultCurrency, money.Currency)
+
+	for _, amount := range []string{"", "abc", "19.999", "1e2", ".5", "1,5", "--1"} {
+		_, err := ParseMoney(amount, "USD")
+		assert.ErrorIs(t, err, ErrInvalidMoney, amount)
+	}
+	money, err = ParseMoney("1", "usd")
+	require.NoError(t, err)
+	assert.Equal(t, NewMoney(100, "USD"), money, "The currency is upper-cased")
+	_, err = ParseMoney("1", "us1")
+	assert.ErrorIs(t, err, ErrInvalidCurrency)
+
+	// The decimal places depend on the currency
+	money, err = ParseMoney("1234", "JPY")
+	require.NoError(t, err)
+	assert.Equal(t, NewMoney(1234, "JPY"), money)
+	money, err = ParseMoney("1.5", "KWD")
+	require.NoError(t, err)
+	assert.Equal(t, NewMoney(1500, "KWD"), money)
+	for _, amount := range []string{"12.5", "12.", "12.0"} {
+		_, err = ParseMoney(amount, "JPY")
+		assert.ErrorIs(t, err, ErrInvalidMoney, amount)
+	}
+	_, err = ParseMoney("1.2345", "BHD")
+	assert.ErrorIs(t, err, ErrInvalidMoney)
+}
+
+func TestMoney_Decimal(t *testing.T) {
+	assert.Equal(t, "12.34", NewMoney(1234, "BRL").Decimal())
+	assert.Equal(t, "0.05", NewMoney(5, "BRL").Decimal())
+	assert.Equal(t, "0.00", NewMoney(0, "BRL").Decimal())
+	assert.Equal(t, "-0.50", NewMoney(-50, "BRL").Decimal())
+	assert.Equal(t, "99999999.99 BRL", NewMoney(9999999999, "BRL").String())
+	assert.Equal(t, "1234", NewMoney(1234, "JPY").Decimal())
+	assert.Equal(t, "-5", NewMoney(-5, "KRW").Decimal())
+	assert.Equal(t, "1.500", NewMoney(1500, "KWD").Decimal())
+	assert.Equal(t, "0.005", NewMoney(5, "BHD").Decimal())
+}
+
+func TestMoney_JSON(t *testing.T) {
+	data, err := json.Marshal(NewMoney(1234, "USD"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"12.34","currency":"USD"}`, string(data))
+
+	tests := []struct {
+		input string
+		want  Money
+	}{
+		{`{"amount":"12.34","currency":"USD"}`, NewMoney(1234, "USD")},
+		{`{"amount":12.34,"currency":"USD"}`, NewMoney(1234, "USD")},
+		{`{"amount":"12.34"}`, NewMoney(1234, DefaultCurrency)},
+		{`12.34`, NewMoney(1234, DefaultCurrency)},
+		{`"12.34"`, NewMoney(1234, DefaultCurrency)},
+		// 0.1 + 0.2 is not 0.3 in float64, the amounts are read as text
+		{`0.3`, NewMoney(30, DefaultCurrency)},
+		{`{"amount":500,"currency":"JPY"}`, NewMoney(500, "JPY")},
+		{`{"amount":"1.25","currency":"KWD"}`, NewMoney(1250, "KWD")},
+		{`{"amount":"12.34","currency":"usd"}`, NewMoney(1234, "USD")},
+	}
+	for _, tt := range tests {
+		var money Money
+		require.NoError(t, json.Unmarshal([]byte(tt.input), &money), tt.input)
+		assert.Equal(t, tt.want, money, tt.input)
+	}
+
+	var input struct {
+		Price Money `json:"price"`
+	}
+	for _, body := range []string{`{"price":"ten"}`, `{"price":19.999}`, `{"price":true}`, `{"price":{"amount":"1","currency":"dollar"}}`, `{"price":{"amount":"1.5","currency":"JPY"}}`} {
+		err := json.Unmarshal([]byte(body), &input)
+		var typeErr *json.UnmarshalTypeError
+		require.ErrorAs(t, err, &typeErr, body)
+		assert.Equal(t, reflect.TypeFor[Money](), typeErr.Type, body)
+	}
+}
@@ -2,13 +2,18 @@ package entity
 
 import "math"
 
+// Meta describes a page. Page mode fills CurrentPage, cursor mode fills
+// NextCursor and PrevCursor. The totals are nil when the client skipped
+// the count.
 type Meta struct {
-	CurrentPage   int    `json:"current_page"`
+	CurrentPage   int    `json:"current_page,omitempty"`
 	PageSize      int    `json:"page_size"`
-	TotalItems    int    `json:"total_items"`
-	TotalPages    int    `json:"total_pages"`
+	TotalItems    *int   `json:"total_items,omitempty"`
+	TotalPages    *int   `json:"total_pages,omitempty"`
 	SortField     string `json:"sort_field"`
 	SortDirection string `json:"sort_direction"`
+	NextCursor    string `json:"next_cursor,omitempty"`
+	PrevCursor    string `json:"prev_cursor,omitempty"`
 }
 
 // Page is the generic struct.
@@ -20,17 +25,42 @@ type Page[T any] struct {
 
 // NewPage is a helper constructor to calculate total pages automatically
 func NewPage[T any](items []T, page, pageSize, totalItems int, sortFld, sortDir string) Page[T] {
-	totalPages := int(math.Ceil(float64(totalItems) / float64(pageSize)))
+	result := NewPageWithoutTotal(items, page, pageSize, sortFld, sortDir)
+	result.Meta.SetTotal(totalItems)
+	return result
+}
 
+// NewPageWithoutTotal builds a page when the count was skipped.
+func NewPageWithoutTotal[T any](items []T, page, pageSize int, sortFld, sortDir string) Page[T] {
 	return Page[T]{
 		Data: items,
 		Meta: Meta{
 			CurrentPage:   page,
 			PageSize:      pageSize,
-			TotalItems:    totalItems,
-			TotalPages:    totalPages,
 			SortField:     sortFld,
 			SortDirection: sortDir,
 		},
 	}
 }
+
+// NewCursorPage builds a keyset page. An empty cursor means there is
+// nothing more in that direction.
+func NewCursorPage[T any](items []T, pageSize int, next, prev, sortFld, sortDir string) Page[T] {
+	return Page[T]{
+		Data: items,
+		Meta: Meta{
+			PageSize:      pageSize,
+			SortField:     sortFld,
+			SortDirection: sortDir,
+			NextCursor:    next,
+			PrevCursor:    prev,
+		},
+	}
+}
+
+// SetTotal fills the totals from the number of items of the whole set.
+func (m *Meta) SetTotal(totalItems int) {
+	totalPages := int(math.Ceil(float64(totalItems) / float64(m.PageSize)))
+	m.TotalItems = &totalItems
+	m.TotalPages = &totalPages
+}
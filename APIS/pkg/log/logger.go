@@ -1,8 +1,10 @@
 package log
 
 import (
+	"context"
 	"os"
 
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -12,21 +14,35 @@ func init() {
 		logger = zap.Must(zap.NewDevelopment())
 	}
 
-	zap.ReplaceGlobals(logger)
+	// The caller of a line is the code that called this package
+	zap.ReplaceGlobals(logger.WithOptions(zap.AddCallerSkip(1)))
 }
 
-func Info(msg string) {
-	zap.L().Info(msg)
+// Info, Error, Warn and Debug add the trace and span ids of ctx to the line,
+// so it can be found from the trace of its request.
+func Info(ctx context.Context, msg string) {
+	zap.L().Info(msg, traceFields(ctx)...)
 }
 
-func Error(msg string) {
-	zap.L().Error(msg)
+func Error(ctx context.Context, msg string) {
+	zap.L().Error(msg, traceFields(ctx)...)
 }
 
-func Warn(msg string) {
-	zap.L().Warn(msg)
+func Warn(ctx context.Context, msg string) {
+	zap.L().Warn(msg, traceFields(ctx)...)
 }
 
-func Debug(msg string) {
-	zap.L().Debug(msg)
+func Debug(ctx context.Context, msg string) {
+	zap.L().Debug(msg, traceFields(ctx)...)
+}
+
+func traceFields(ctx context.Context) []zap.Field {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return nil
+	}
+	return []zap.Field{
+		zap.String("trace_id", spanContext.TraceID().String()),
+		zap.String("span_id", spanContext.SpanID().String()),
+	}
 }
@@ -0,0 +1,56 @@
+package log
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func observe(t *testing.T) *observer.ObservedLogs {
+	core, logs := observer.New(zapcore.DebugLevel)
+	restore := zap.ReplaceGlobals(zap.New(core))
+	t.Cleanup(restore)
+	return logs
+}
+
+func TestLog_TraceFields(t *testing.T) {
+	t.Run("should add the ids of the span", func(t *testing.T) {
+		logs := observe(t)
+		traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+		spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+		ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    traceID,
+			SpanID:     spanID,
+			TraceFlags: trace.FlagsSampled,
+		}))
+
+		Error(ctx, "failed")
+
+		require.Equal(t, 1, logs.Len())
+		entry := logs.All()[0]
+		assert.Equal(t, "failed", entry.Message)
+		assert.Equal(t, zapcore.ErrorLevel, entry.Level)
+		fields := entry.ContextMap()
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", fields["trace_id"])
+		assert.Equal(t, "00f067aa0ba902b7", fields["span_id"])
+	})
+
+	t.Run("should log without ids outside a span", func(t *testing.T) {
+		logs := observe(t)
+
+		Info(context.Background(), "started")
+		Warn(context.Background(), "slow")
+		Debug(context.Background(), "details")
+
+		require.Equal(t, 3, logs.Len())
+		for _, entry := range logs.All() {
+			assert.Empty(t, entry.Context)
+		}
+	})
+}
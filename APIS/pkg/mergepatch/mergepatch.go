@@ -0,0 +1,63 @@
+// Package mergepatch applies JSON Merge Patch documents (RFC 7396).
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// ContentType is the media type of a merge patch document.
+const ContentType = "application/merge-patch+json"
+
+var ErrInvalidPatch = errors.New("invalid merge patch document")
+
+// Apply returns doc with patch merged into it. Members of patch replace the
+// ones of doc, null members remove them and objects are merged recursively.
+// A patch that is not an object replaces the whole document.
+func Apply(doc, patch []byte) ([]byte, error) {
+	patchValue, err := decode(patch)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidPatch, err)
+	}
+	var docValue any
+	if len(bytes.TrimSpace(doc)) > 0 {
+		if docValue, err = decode(doc); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(merge(docValue, patchValue))
+}
+
+func merge(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = merge(targetObject[name], value)
+	}
+	return targetObject
+}
+
+// decode keeps numbers as json.Number so they aren't rounded through float64.
+func decode(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("unexpected data after the JSON document")
+	}
+	return value, nil
+}